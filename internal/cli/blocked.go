@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// blockedPortAttempts 为 --port 检查的握手次数，任意一次成功即视为端口可达。
+const blockedPortAttempts = 3
+
+func newBlockedCommand() *cobra.Command {
+	var (
+		port      int
+		protocol  string
+		maxHops   int
+		count     int
+		ipVersion int
+		timeout   time.Duration
+	)
+	opts := &rootOptions{
+		geoip:   "ip2region",
+		ip2rDB:  geoip.DefaultIP2RegionDBPath(),
+		geoipDL: "ask",
+	}
+	cmd := &cobra.Command{
+		Use:     "blocked <target>",
+		Short:   i18n.T("cmd.blocked.short"),
+		Example: i18n.T("cmd.blocked.example"),
+		Args:    exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if port < 0 || port > 65535 {
+				return errors.New(i18n.Tf("err.tcpRTTPortInvalid", map[string]interface{}{"Port": port}))
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			opts.offline, _ = cmd.Flags().GetBool("offline")
+			cfg := &mtr.Config{
+				Target:    args[0],
+				MaxHops:   maxHops,
+				Count:     count,
+				Timeout:   timeout,
+				Protocol:  mtr.Protocol(protocol),
+				IPVersion: ipVersion,
+				Safety:    safetyLimits(cmd),
+				EnableDNS: true,
+				Offline:   opts.offline,
+			}
+			cfg.ApplyProtocolProfile()
+
+			resolver, err := newResolver(cmd, opts)
+			if err != nil {
+				return err
+			}
+			defer resolver.Close()
+			prober, err := mtr.NewProberWithFallback(cfg)
+			if err != nil {
+				return err
+			}
+			defer prober.Close()
+			warnProbeFallback(cmd.ErrOrStderr(), cfg)
+			controller, err := mtr.NewController(cfg, prober, resolver)
+			if err != nil {
+				return err
+			}
+			if err := controller.Run(ctx); err != nil {
+				return err
+			}
+			snapshot := controller.Snapshot()
+
+			var portState string
+			if port > 0 {
+				portState = mtr.CheckTCPPort(ctx, net.ParseIP(snapshot.TargetIP), port, blockedPortAttempts, cfg.Timeout)
+			}
+			verdict := mtr.AnalyzeBlock(snapshot, port, portState)
+			if opts.json {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(verdict); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintln(cmd.OutOrStdout(), formatBlockVerdict(verdict))
+			}
+			if verdict.Blocked() {
+				return errors.New(i18n.T("err.blocked"))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&port, "port", 0, i18n.T("cmd.flag.blockedPort"))
+	cmd.Flags().StringVar(&protocol, "protocol", string(mtr.ProtocolICMP), i18n.T("cmd.flag.protocol"))
+	cmd.Flags().IntVar(&maxHops, "max-hops", 30, i18n.T("cmd.flag.maxHops"))
+	cmd.Flags().IntVar(&count, "count", 5, i18n.T("cmd.flag.count"))
+	cmd.Flags().IntVar(&ipVersion, "ip-version", 4, i18n.T("cmd.flag.ipVersion"))
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, i18n.T("cmd.flag.timeout"))
+	cmd.Flags().BoolVar(&opts.json, "json", false, i18n.T("cmd.flag.json"))
+	addGeoIPFlags(cmd, opts)
+	return cmd
+}
+
+// formatBlockVerdict 将判定结果写成一段面向服务台的结论。
+func formatBlockVerdict(v mtr.BlockVerdict) string {
+	data := map[string]interface{}{
+		"Target": v.Target,
+		"Port":   v.Port,
+		"TTL":    v.TTL,
+		"Hop":    blockHop(v),
+		"Reason": v.Reason,
+	}
+	var msg string
+	switch v.State {
+	case mtr.BlockProhibited:
+		msg = i18n.Tf("blocked.prohibited", data)
+	case mtr.BlockUnreachable:
+		msg = i18n.Tf("blocked.unreachable", data)
+	case mtr.BlockDropped:
+		if v.TTL == 0 {
+			msg = i18n.Tf("blocked.droppedFirst", data)
+		} else {
+			msg = i18n.Tf("blocked.dropped", data)
+		}
+	case mtr.BlockPortFiltered:
+		msg = i18n.Tf("blocked.portFiltered", data)
+	case mtr.BlockPortClosed:
+		msg = i18n.Tf("blocked.portClosed", data)
+	default:
+		if v.Port > 0 {
+			msg = i18n.Tf("blocked.portOpen", data)
+		} else {
+			msg = i18n.Tf("blocked.none", data)
+		}
+	}
+	return msg
+}
+
+// blockHop 描述阻断点：地址、主机名与归属（地理位置/运营商）。
+func blockHop(v mtr.BlockVerdict) string {
+	s := v.IP
+	if s == "" {
+		s = "*"
+	}
+	if v.Hostname != "" && v.Hostname != v.IP {
+		s += " " + v.Hostname
+	}
+	if loc := v.Location.String(); loc != "" {
+		s += " [" + loc + "]"
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// checkComparisonModes 检查多路径模式互斥（各自以不同方式展开探测，无法组合），
+// 且 --compare-dscp 至少给出两个取值。
+func checkComparisonModes(opts *rootOptions) error {
+	var modes []string
+	if len(opts.compareSources) > 0 {
+		modes = append(modes, "--compare-sources")
+	}
+	if len(opts.compareDSCP) > 0 {
+		modes = append(modes, "--compare-dscp")
+	}
+	if opts.subnet != "" {
+		modes = append(modes, "--subnet")
+	}
+	if opts.allIPs {
+		modes = append(modes, "--all-ips")
+	}
+	if opts.ecmpFlows > 0 {
+		modes = append(modes, "--ecmp-flows")
+	}
+	if len(modes) > 1 {
+		return errors.New(i18n.Tf("err.comparisonModesExclusive", map[string]interface{}{"Flags": strings.Join(modes, ", ")}))
+	}
+	if len(opts.compareDSCP) == 1 {
+		return errors.New(i18n.T("err.compareDSCPTooFew"))
+	}
+	return nil
+}
+
+// runComparison 并行执行多份配置的探测，并按 TTL 对齐输出差异（写入 out，告警与策略违规写入 errOut）；
+// 各路径的 Controller 由 newController 创建，与单次探测共享 --hop-rule、--record 与 --path-policy。
+func runComparison(ctx context.Context, out, errOut io.Writer, opts *rootOptions, labels []string, cfgs []*mtr.Config, newController func(*mtr.Config) (*mtr.Controller, mtr.Prober, error)) error {
+	if len(cfgs) > 0 {
+		if err := cfgs[0].Safety.CheckTargets(len(cfgs)); err != nil {
+			return err
+		}
+	}
+	snaps := make([]*mtr.Snapshot, len(cfgs))
+	errs := make([]error, len(cfgs))
+
+	var wg sync.WaitGroup
+	for i, cfg := range cfgs {
+		controller, prober, err := newController(cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", labels[i], err)
+		}
+		defer prober.Close()
+		warnProbeFallback(errOut, cfg)
+
+		wg.Add(1)
+		go func(i int, c *mtr.Controller) {
+			defer wg.Done()
+			errs[i] = c.Run(ctx)
+			snaps[i] = c.Snapshot()
+		}(i, controller)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("%s: %w", labels[i], err)
+		}
+	}
+
+	cmp := mtr.ComparePaths(labels, snaps)
+	if opts.json {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cmp); err != nil {
+			return err
+		}
+	} else if err := renderComparison(out, cmp); err != nil {
+		return err
+	}
+	return comparisonPolicyError(errOut, cmp)
+}
+
+// comparisonPolicyError 将各路径运行期间违反过的路径策略逐条写入 w（stderr），并以非零退出码返回。
+func comparisonPolicyError(w io.Writer, cmp *mtr.PathComparison) error {
+	n := 0
+	for i, s := range cmp.Paths {
+		for _, v := range s.Violated {
+			fmt.Fprintf(w, "%s: %s\n", cmp.Labels[i], v.Message())
+			n++
+		}
+	}
+	if n > 0 {
+		return errors.New(i18n.Tf("err.policyViolated", map[string]interface{}{"Count": n}))
+	}
+	return nil
+}
+
+func renderComparison(out io.Writer, cmp *mtr.PathComparison) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TTL\t%s\t\n", strings.Join(cmp.Labels, "\t"))
+	for _, row := range cmp.Rows {
+		cells := make([]string, 0, len(row.Hops))
+		for _, hop := range row.Hops {
+			cells = append(cells, compareCell(hop))
+		}
+		mark := ""
+		if row.Divergent {
+			mark = "≠"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", row.TTL, strings.Join(cells, "\t"), mark)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	if len(cmp.Divergent) == 0 {
+		fmt.Fprintln(out, i18n.T("cli.compare.identical"))
+		return nil
+	}
+	fmt.Fprintln(out, i18n.Tf("cli.compare.divergent", map[string]interface{}{"TTLs": joinInts(cmp.Divergent)}))
+	if cmp.CommonPrefix > 0 {
+		fmt.Fprintln(out, i18n.Tf("cli.compare.commonPrefix", map[string]interface{}{"TTL": cmp.CommonPrefix, "Next": cmp.CommonPrefix + 1}))
+	}
+	return nil
+}
+
+func compareCell(hop *mtr.SnapshotHop) string {
+	if hop == nil {
+		return "-"
+	}
+	addr := hop.IP
+	if addr == "" {
+		addr = "*"
+	}
+	return fmt.Sprintf("%s (%.0f%%, %s)", addr, hop.Stats.Loss, emptyAsDash(hop.Stats.Avg))
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestCheckComparisonModes(t *testing.T) {
+	cases := []struct {
+		opts rootOptions
+		ok   bool
+	}{
+		{rootOptions{}, true},
+		{rootOptions{compareDSCP: []string{"ef", "cs1"}}, true},
+		{rootOptions{compareDSCP: []string{"ef"}}, false},
+		{rootOptions{compareSources: []string{"a", "b"}, compareDSCP: []string{"ef", "cs1"}}, false},
+		{rootOptions{allIPs: true, ecmpFlows: 4}, false},
+		{rootOptions{subnet: "192.0.2.0/24", allIPs: true}, false},
+	}
+	for i, c := range cases {
+		if err := checkComparisonModes(&c.opts); (err == nil) != c.ok {
+			t.Fatalf("case %d: unexpected result %v", i, err)
+		}
+	}
+}
+
+func TestComparisonOutputUsesWriters(t *testing.T) {
+	i18n.SetLanguage("en")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	v := mtr.PolicyViolation{Policy: "p", Detail: "d"}
+	snaps := []*mtr.Snapshot{
+		{Hops: []mtr.SnapshotHop{{TTL: 1, IP: "192.0.2.1"}}, Violated: []mtr.PolicyViolation{v}},
+		{Hops: []mtr.SnapshotHop{{TTL: 1, IP: "192.0.2.2"}}},
+	}
+	cmp := mtr.ComparePaths([]string{"a", "b"}, snaps)
+
+	var out, errOut bytes.Buffer
+	if err := renderComparison(&out, cmp); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "192.0.2.2") {
+		t.Fatalf("expected comparison table in writer:\n%s", out.String())
+	}
+	if err := comparisonPolicyError(&errOut, cmp); err == nil {
+		t.Fatal("expected policy violation error")
+	}
+	if !strings.HasPrefix(errOut.String(), "a: ") {
+		t.Fatalf("expected labelled violation on the error writer, got %q", errOut.String())
+	}
+
+	var ecmp bytes.Buffer
+	g := &mtr.ECMPGraph{Target: "example.com", Protocol: "udp", Flows: 2, MaxWidth: 1, DestinationReached: true,
+		Nodes: []mtr.ECMPNode{{TTL: 1, IP: "192.0.2.1", Flows: []int{0, 1}}}}
+	if err := renderECMP(&ecmp, g); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ecmp.String(), "192.0.2.1") {
+		t.Fatalf("expected ECMP tree in writer:\n%s", ecmp.String())
+	}
+}
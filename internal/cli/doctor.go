@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+type checkStatus string
+
+const (
+	checkOK   checkStatus = "OK"
+	checkWarn checkStatus = "WARN"
+	checkFail checkStatus = "FAIL"
+	checkSkip checkStatus = "SKIP"
+)
+
+type checkResult struct {
+	name   string
+	status checkStatus
+	detail string
+}
+
+func newDoctorCommand() *cobra.Command {
+	var ip2rDB string
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Short:   i18n.T("cmd.doctor.short"),
+		Example: i18n.T("cmd.doctor.example"),
+		Args:    noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			offline, _ := cmd.Flags().GetBool("offline")
+			results := runDoctorChecks(ctx, ip2rDB, offline)
+			return renderDoctor(cmd.OutOrStdout(), results)
+		},
+	}
+	cmd.Flags().StringVar(&ip2rDB, "ip2region-db", geoip.DefaultIP2RegionDBPath(), i18n.T("cmd.flag.ip2regionDB"))
+	return cmd
+}
+
+// runDoctorChecks 依次执行各项自检；offline 为 true 时跳过需要访问网络的检查（DNS、cip.cc）。
+func runDoctorChecks(ctx context.Context, ip2rDB string, offline bool) []checkResult {
+	var results []checkResult
+
+	results = append(results, checkProbeSockets()...)
+
+	for _, v := range []int{4, 6} {
+		name := i18n.Tf("doctor.check.udpTTL", map[string]interface{}{"Version": v})
+		status := checkFail
+		if v == 6 {
+			// IPv6 不可用时属于环境限制，不视为故障
+			status = checkWarn
+		}
+		if err := mtr.CheckUDPTTL(v); err != nil {
+			results = append(results, checkResult{name, status, err.Error()})
+		} else {
+			results = append(results, checkResult{name, checkOK, ""})
+		}
+	}
+
+	if ip, err := mtr.CheckIPv6Route(); err != nil {
+		results = append(results, checkResult{i18n.T("doctor.check.ipv6"), checkWarn, err.Error()})
+	} else {
+		results = append(results, checkResult{i18n.T("doctor.check.ipv6"), checkOK, ip.String()})
+	}
+
+	if offline {
+		results = append(results, checkResult{i18n.T("doctor.check.dns"), checkSkip, i18n.T("doctor.skippedOffline")})
+	} else {
+		results = append(results, checkDNS(ctx))
+	}
+
+	if err := geoip.CheckIP2RegionDB(ip2rDB); err != nil {
+		results = append(results, checkResult{i18n.T("doctor.check.ip2region"), checkWarn, err.Error()})
+	} else {
+		results = append(results, checkResult{i18n.T("doctor.check.ip2region"), checkOK, ip2rDB})
+	}
+
+	if offline {
+		results = append(results, checkResult{i18n.T("doctor.check.cip"), checkSkip, i18n.T("doctor.skippedOffline")})
+	} else {
+		results = append(results, checkCIP(ctx))
+	}
+
+	return results
+}
+
+// probeSocketCheck 为单个地址族的探测套接字检查结果：原始套接字不可用时再检查无特权降级方式。
+type probeSocketCheck struct {
+	rawErr      error
+	fallback    string
+	fallbackErr error
+}
+
+func (p probeSocketCheck) usable() bool {
+	return p.rawErr == nil || p.fallbackErr == nil
+}
+
+// checkProbeSockets 检查各地址族能否探测：没有原始套接字权限但可以降级为无特权探测时为 WARN（与 warnProbeFallback 一致），
+// 原始套接字与降级方式都不可用时该地址族不可探测；只有一个地址族不可探测（如仅 IPv4 的主机）时仍为 WARN，两者都不可探测才视为故障。
+func checkProbeSockets() []checkResult {
+	checks := make(map[int]probeSocketCheck, 2)
+	for _, v := range []int{4, 6} {
+		c := probeSocketCheck{rawErr: mtr.CheckRawICMP(v)}
+		if c.rawErr != nil {
+			c.fallback, c.fallbackErr = mtr.CheckUnprivilegedProbe(v)
+		}
+		checks[v] = c
+	}
+	unusable := checkWarn
+	if !checks[4].usable() && !checks[6].usable() {
+		unusable = checkFail
+	}
+	results := make([]checkResult, 0, 2)
+	for _, v := range []int{4, 6} {
+		name := i18n.Tf("doctor.check.rawICMP", map[string]interface{}{"Version": v})
+		c := checks[v]
+		switch {
+		case c.rawErr == nil:
+			results = append(results, checkResult{name, checkOK, ""})
+		case c.fallbackErr == nil:
+			results = append(results, checkResult{name, checkWarn, i18n.Tf("doctor.probeFallback", map[string]interface{}{"Mode": c.fallback, "Error": c.rawErr.Error()})})
+		default:
+			results = append(results, checkResult{name, unusable, i18n.Tf("doctor.probeUnavailable", map[string]interface{}{"Error": c.rawErr.Error(), "FallbackError": c.fallbackErr.Error()})})
+		}
+	}
+	return results
+}
+
+func checkDNS(ctx context.Context) checkResult {
+	dnsCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(dnsCtx, "example.com")
+	if err != nil {
+		return checkResult{i18n.T("doctor.check.dns"), checkFail, err.Error()}
+	}
+	return checkResult{i18n.T("doctor.check.dns"), checkOK, fmt.Sprintf("example.com -> %d addrs", len(addrs))}
+}
+
+func checkCIP(ctx context.Context) checkResult {
+	cipCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	if err := geoip.NewCIPResolver().Ping(cipCtx); err != nil {
+		return checkResult{i18n.T("doctor.check.cip"), checkWarn, err.Error()}
+	}
+	return checkResult{i18n.T("doctor.check.cip"), checkOK, ""}
+}
+
+func renderDoctor(out io.Writer, results []checkResult) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Check\tStatus\tDetail")
+	failed := 0
+	for _, r := range results {
+		if r.status == checkFail {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.name, r.status, emptyAsDash(r.detail))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.New(i18n.Tf("doctor.failed", map[string]interface{}{"Count": failed}))
+	}
+	return nil
+}
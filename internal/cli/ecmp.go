@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// runECMP 以多条固定流标识枚举并行路径，并以树形（或 JSON）将合并后的 DAG 写入 out，告警写入 errOut。
+func runECMP(ctx context.Context, out, errOut io.Writer, opts *rootOptions, cfg *mtr.Config) error {
+	prober, err := mtr.NewProberWithFallback(cfg)
+	if err != nil {
+		return err
+	}
+	defer prober.Close()
+	warnProbeFallback(errOut, cfg)
+
+	g, err := mtr.DiscoverECMP(ctx, cfg, prober, opts.ecmpFlows)
+	if err != nil {
+		return err
+	}
+	if opts.json {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	}
+	return renderECMP(out, g)
+}
+
+func renderECMP(out io.Writer, g *mtr.ECMPGraph) error {
+	fmt.Fprintln(out, i18n.Tf("cli.ecmp.header", map[string]interface{}{
+		"Target":   g.Target,
+		"IP":       g.TargetIP,
+		"Protocol": formatECMPProtocol(g),
+		"Flows":    g.Flows,
+	}))
+	fmt.Fprintln(out)
+
+	maxTTL := 0
+	for _, n := range g.Nodes {
+		if n.TTL > maxTTL {
+			maxTTL = n.TTL
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TTL\tHost\tFlows\tFrom\n")
+	for ttl := 1; ttl <= maxTTL; ttl++ {
+		nodes := g.NodesAt(ttl)
+		if len(nodes) == 0 {
+			fmt.Fprintf(w, "%d\t*\t\t\n", ttl)
+			continue
+		}
+		for i, n := range nodes {
+			label := ""
+			if i == 0 {
+				label = strconv.Itoa(ttl)
+			}
+			branch := ""
+			if len(nodes) > 1 {
+				branch = "├─ "
+				if i == len(nodes)-1 {
+					branch = "└─ "
+				}
+			}
+			host := n.IP
+			if n.Hostname != "" {
+				host = fmt.Sprintf("%s (%s)", n.Hostname, n.IP)
+			}
+			fmt.Fprintf(w, "%s\t%s%s\t%s\t%s\n", label, branch, host, formatFlows(n.Flows), ecmpParents(g, n))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	if g.MaxWidth > 1 {
+		fmt.Fprintln(out, i18n.Tf("cli.ecmp.summary", map[string]interface{}{"Width": g.MaxWidth}))
+	} else {
+		fmt.Fprintln(out, i18n.T("cli.ecmp.single"))
+	}
+	if !g.DestinationReached {
+		fmt.Fprintln(out, i18n.T("cli.ecmp.unreached"))
+	}
+	return nil
+}
+
+// formatECMPProtocol 与 formatProtocol 相同：降级为无特权探测时附带实际使用的方式。
+func formatECMPProtocol(g *mtr.ECMPGraph) string {
+	if g.ProbeMode == "" {
+		return g.Protocol
+	}
+	return fmt.Sprintf("%s (unprivileged: %s)", g.Protocol, g.ProbeMode)
+}
+
+func ecmpParents(g *mtr.ECMPGraph, n mtr.ECMPNode) string {
+	edges := g.Parents(n.TTL, n.IP)
+	if len(edges) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(edges))
+	for _, e := range edges {
+		parts = append(parts, e.From)
+	}
+	return "← " + strings.Join(parts, ", ")
+}
+
+// formatFlows 将有序的 flow 编号压缩为区间形式，例如 [0 1 2 5] -> "0-2,5"。
+func formatFlows(flows []int) string {
+	var parts []string
+	for i := 0; i < len(flows); {
+		j := i
+		for j+1 < len(flows) && flows[j+1] == flows[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", flows[i], flows[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(flows[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
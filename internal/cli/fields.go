@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// hopFieldNames 为 --fields 可选的字段：hop 顶层字段与 stats 内字段（展开到同一层），
+// 直接取自 JSON tag，随快照结构自动更新。
+var hopFieldNames = func() map[string]bool {
+	names := make(map[string]bool)
+	for _, t := range []reflect.Type{reflect.TypeOf(mtr.SnapshotHop{}), reflect.TypeOf(mtr.SnapshotHopSta{})} {
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}()
+
+// parseFields 解析逗号分隔的字段列表；为空时返回 nil（输出完整结构）。
+func parseFields(spec string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		if !hopFieldNames[f] {
+			valid := make([]string, 0, len(hopFieldNames))
+			for name := range hopFieldNames {
+				valid = append(valid, name)
+			}
+			sort.Strings(valid)
+			return nil, errors.New(i18n.Tf("err.fieldUnknown", map[string]interface{}{"Field": f, "Valid": strings.Join(valid, ",")}))
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// fieldObject 为按指定顺序输出键的 JSON 对象。
+type fieldObject struct {
+	keys   []string
+	values []json.RawMessage
+}
+
+func (o fieldObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(o.values[i])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// filteredSnapshot 保留快照的其他字段，仅将 hops 裁剪为所选字段。
+type filteredSnapshot struct {
+	*mtr.Snapshot
+	Hops []fieldObject `json:"hops"`
+}
+
+// filterHopFields 将每个 hop 投影为只含 fields 的扁平对象；值为空而被 omitempty 省略的字段不输出。
+func filterHopFields(s *mtr.Snapshot, fields []string) (*filteredSnapshot, error) {
+	out := &filteredSnapshot{Snapshot: s, Hops: make([]fieldObject, 0, len(s.Hops))}
+	for _, hop := range s.Hops {
+		raw, err := json.Marshal(hop)
+		if err != nil {
+			return nil, err
+		}
+		var top map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &top); err != nil {
+			return nil, err
+		}
+		var stats map[string]json.RawMessage
+		if err := json.Unmarshal(top["stats"], &stats); err != nil {
+			return nil, err
+		}
+
+		var obj fieldObject
+		for _, f := range fields {
+			v, ok := top[f]
+			if !ok {
+				v, ok = stats[f]
+			}
+			if ok {
+				obj.keys = append(obj.keys, f)
+				obj.values = append(obj.values, v)
+			}
+		}
+		out.Hops = append(out.Hops, obj)
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestFilterHopFields(t *testing.T) {
+	fields, err := parseFields(" TTL,ip,loss, avg_ms,ip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("expected duplicates to be dropped, got %v", fields)
+	}
+	if _, err := parseFields("ttl,bogus"); err == nil {
+		t.Fatal("expected unknown field to be rejected")
+	}
+
+	s := &mtr.Snapshot{
+		Target: "example.com",
+		Hops: []mtr.SnapshotHop{
+			{TTL: 1, IP: "192.0.2.1", Stats: mtr.SnapshotHopSta{Sent: 3, Received: 3, AvgMs: 4}},
+			{TTL: 2, Lost: true, Stats: mtr.SnapshotHopSta{Sent: 3, Loss: 100}},
+		},
+	}
+	filtered, err := filterHopFields(s, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(filtered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Target string            `json:"target"`
+		Hops   []json.RawMessage `json:"hops"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Target != "example.com" || len(got.Hops) != 2 {
+		t.Fatalf("unexpected snapshot: %s", out)
+	}
+	// 键按 --fields 顺序输出，被 omitempty 省略的 ip 不出现
+	if string(got.Hops[0]) != `{"ttl":1,"ip":"192.0.2.1","loss":0,"avg_ms":4}` || string(got.Hops[1]) != `{"ttl":2,"loss":100,"avg_ms":0}` {
+		t.Fatalf("unexpected hops: %s", out)
+	}
+}
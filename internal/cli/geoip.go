@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// geoLookupResult 为 geoip lookup 的单条结果（--json 输出）。
+type geoLookupResult struct {
+	IP       string             `json:"ip"`
+	Source   string             `json:"source"`
+	Location *geoip.GeoLocation `json:"location,omitempty"`
+}
+
+// newGeoIPCommand 返回 geoip 子命令组：无需发起追踪即可单独查询地址、预先下载数据库（如制作镜像时）、查看与清理本地数据库。
+func newGeoIPCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "geoip",
+		Short:   i18n.T("cmd.geoip.short"),
+		Example: i18n.T("cmd.geoip.example"),
+	}
+	cmd.AddCommand(newGeoIPLookupCommand())
+	cmd.AddCommand(newGeoIPDownloadCommand())
+	cmd.AddCommand(newGeoIPStatusCommand())
+	cmd.AddCommand(newGeoIPDBCommand())
+	return cmd
+}
+
+func newGeoIPLookupCommand() *cobra.Command {
+	opts := &rootOptions{
+		geoip:   "ip2region",
+		ip2rDB:  geoip.DefaultIP2RegionDBPath(),
+		geoipDL: "ask",
+	}
+	cmd := &cobra.Command{
+		Use:   "lookup <ip>...",
+		Short: i18n.T("cmd.geoip.lookup.short"),
+		Args:  minArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ips := make([]net.IP, 0, len(args))
+			for _, a := range args {
+				ip := net.ParseIP(a)
+				if ip == nil {
+					return errors.New(i18n.Tf("err.geoipLookupInvalid", map[string]interface{}{"Value": a}))
+				}
+				ips = append(ips, ip)
+			}
+			opts.offline, _ = cmd.Flags().GetBool("offline")
+			resolver, err := newResolver(cmd, opts)
+			if err != nil {
+				return err
+			}
+			defer resolver.Close()
+
+			results := make([]geoLookupResult, 0, len(ips))
+			for _, ip := range ips {
+				results = append(results, geoLookupResult{IP: ip.String(), Source: resolver.Source(), Location: resolver.Resolve(ip)})
+			}
+			return renderGeoLookup(cmd.OutOrStdout(), results, opts.json)
+		},
+	}
+	addGeoIPFlags(cmd, opts)
+	cmd.Flags().BoolVar(&opts.json, "json", false, i18n.T("cmd.flag.json"))
+	return cmd
+}
+
+func renderGeoLookup(out io.Writer, results []geoLookupResult, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\tSource\tLocation")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.IP, r.Source, emptyAsDash(r.Location.String()))
+	}
+	return w.Flush()
+}
+
+func newGeoIPDownloadCommand() *cobra.Command {
+	var (
+		ip2rDB    string
+		ip2rURL   string
+		ipVersion int
+		force     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "download",
+		Short: i18n.T("cmd.geoip.download.short"),
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if offline, _ := cmd.Flags().GetBool("offline"); offline {
+				return errors.New(i18n.T("err.geoipDownloadOffline"))
+			}
+			if !force {
+				if info, err := geoip.StatIP2RegionDB(ip2rDB); err == nil {
+					fmt.Fprintln(cmd.OutOrStdout(), i18n.Tf("cli.geoipDBExists", map[string]interface{}{"Path": info.Path}))
+					return nil
+				}
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			info, err := geoip.DownloadIP2RegionDB(ctx, ip2rDB, ip2rURL, ipVersion)
+			if err != nil {
+				return errors.New(i18n.Tf("geoip.ip2region.downloadFailed", map[string]interface{}{"Error": err.Error()}))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), i18n.Tf("cli.geoipDownloaded", map[string]interface{}{
+				"Path": info.Path, "Size": info.HumanSize(), "Family": info.Family,
+			}))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&ip2rDB, "ip2region-db", geoip.DefaultIP2RegionDBPath(), i18n.T("cmd.flag.ip2regionDB"))
+	cmd.Flags().StringVar(&ip2rURL, "geoip-ip2region-url", "", i18n.T("cmd.flag.ip2regionURL"))
+	cmd.Flags().IntVar(&ipVersion, "download-ip-version", 0, i18n.T("cmd.flag.downloadIPVersion"))
+	cmd.Flags().BoolVar(&force, "force", false, i18n.T("cmd.flag.geoipForce"))
+	return cmd
+}
+
+func newGeoIPStatusCommand() *cobra.Command {
+	var ip2rDB string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: i18n.T("cmd.geoip.status.short"),
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info, err := geoip.StatIP2RegionDB(ip2rDB)
+			return renderGeoStatus(cmd.OutOrStdout(), info, err)
+		},
+	}
+	cmd.Flags().StringVar(&ip2rDB, "ip2region-db", geoip.DefaultIP2RegionDBPath(), i18n.T("cmd.flag.ip2regionDB"))
+	return cmd
+}
+
+// renderGeoStatus 输出本地数据库状态；数据库缺失或损坏时仍输出路径与原因，并以非零状态退出。
+func renderGeoStatus(out io.Writer, info geoip.IP2RegionDBInfo, statErr error) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Path\t%s\n", info.Path)
+	if statErr != nil {
+		fmt.Fprintf(w, "Status\t%s\t%s\n", checkFail, statErr.Error())
+	} else {
+		fmt.Fprintf(w, "Status\t%s\n", checkOK)
+		fmt.Fprintf(w, "Family\t%s\n", info.Family)
+		fmt.Fprintf(w, "Size\t%s\n", info.HumanSize())
+		fmt.Fprintf(w, "Modified\t%s\n", info.ModTime.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if statErr != nil {
+		return errors.New(i18n.T("err.geoipDBUnavailable"))
+	}
+	return nil
+}
+
+func newGeoIPDBCommand() *cobra.Command {
+	var ip2rDB string
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: i18n.T("cmd.geoip.db.short"),
+	}
+	cmd.PersistentFlags().StringVar(&ip2rDB, "ip2region-db", geoip.DefaultIP2RegionDBPath(), i18n.T("cmd.flag.ip2regionDB"))
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: i18n.T("cmd.geoip.db.path.short"),
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Fprintln(cmd.OutOrStdout(), ip2rDB)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove",
+		Short: i18n.T("cmd.geoip.db.remove.short"),
+		Args:  noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := geoip.RemoveIP2RegionDB(ip2rDB)
+			for _, p := range removed {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Tf("cli.geoipRemoved", map[string]interface{}{"Path": p}))
+			}
+			if err != nil {
+				return err
+			}
+			if len(removed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), i18n.Tf("cli.geoipNothingRemoved", map[string]interface{}{"Path": ip2rDB}))
+			}
+			return nil
+		},
+	})
+	return cmd
+}
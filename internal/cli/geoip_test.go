@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+func TestRenderGeoLookup(t *testing.T) {
+	results := []geoLookupResult{
+		{IP: "1.1.1.1", Source: "ip2region", Location: &geoip.GeoLocation{Country: "Australia", ISP: "Cloudflare"}},
+		{IP: "10.0.0.1", Source: "ip2region"},
+	}
+	var out bytes.Buffer
+	if err := renderGeoLookup(&out, results, false); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "Australia Cloudflare") || !strings.Contains(got, "10.0.0.1  ip2region  -") {
+		t.Fatalf("unexpected table:\n%s", got)
+	}
+
+	out.Reset()
+	if err := renderGeoLookup(&out, results, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"country": "Australia"`) {
+		t.Fatalf("unexpected json:\n%s", out.String())
+	}
+}
+
+func TestRenderGeoStatusMissing(t *testing.T) {
+	var out bytes.Buffer
+	err := renderGeoStatus(&out, geoip.IP2RegionDBInfo{Path: "/nonexistent.xdb"}, errors.New("not found"))
+	if err == nil {
+		t.Fatal("expected missing database to fail")
+	}
+	if got := out.String(); !strings.Contains(got, "/nonexistent.xdb") || !strings.Contains(got, "FAIL") {
+		t.Fatalf("unexpected status:\n%s", got)
+	}
+}
+
+func TestGeoIPHTTPFlagsNeedCIP(t *testing.T) {
+	cmd := NewRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"geoip", "lookup", "--geoip", "off", "--geoip-http-timeout", "1s", "192.0.2.1"})
+	err := cmd.Execute()
+	want := i18n.Tf("err.geoipHTTPFlagSource", map[string]interface{}{"Flag": "--geoip-http-timeout", "Source": "off"})
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// usageTemplate 为 cobra 默认用法模板的本地化版本（结构与 cobra v1.8 的默认模板一致，只替换标题文案）。
+func usageTemplate() string {
+	return i18n.T("help.usage") + `{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+` + i18n.T("help.aliases") + `
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+` + i18n.T("help.examples") + `
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}{{$cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+` + i18n.T("help.commands") + `{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{.Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+` + i18n.T("help.additionalCommands") + `{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+` + i18n.T("help.flags") + `
+{{.LocalFlags | flagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+` + i18n.T("help.globalFlags") + `
+{{.InheritedFlags | flagUsages | trimTrailingWhitespaces}}{{end}}{{if .HasHelpSubCommands}}
+
+` + i18n.T("help.topics") + `{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+` + i18n.Tf("help.moreInfo", map[string]interface{}{"Command": "{{.CommandPath}}"}) + `{{end}}
+`
+}
+
+func init() {
+	cobra.AddTemplateFunc("flagUsages", flagUsages)
+}
+
+// flagUsages 与 FlagSet.FlagUsages 相同，但本地化 pflag 固定输出的 "(default ...)" 前缀。
+func flagUsages(fs *pflag.FlagSet) string {
+	return strings.ReplaceAll(fs.FlagUsages(), " (default ", " ("+i18n.T("help.default")+" ")
+}
+
+// localizeHelp 将 cobra 自带的英文文案（用法模板、help/completion 命令、-h 标志说明、标志解析错误）
+// 替换为 i18n 文案；须在所有子命令注册完成后调用。
+func localizeHelp(root *cobra.Command) {
+	root.SetUsageTemplate(usageTemplate())
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errors.New(i18n.Tf("err.flag", map[string]interface{}{"Error": err.Error(), "Command": cmd.CommandPath()}))
+	})
+
+	// 提前创建默认的 help/completion 命令，Execute 时 cobra 会沿用已有命令而不再新建
+	root.InitDefaultHelpCmd()
+	root.InitDefaultCompletionCmd()
+	for _, sub := range root.Commands() {
+		switch sub.Name() {
+		case "help":
+			sub.Short = i18n.T("cmd.help.short")
+			sub.Long = i18n.Tf("cmd.help.long", map[string]interface{}{"Name": root.Name()})
+		case "completion":
+			sub.Short = i18n.T("cmd.completion.short")
+			sub.Long = i18n.Tf("cmd.completion.long", map[string]interface{}{"Name": root.Name()})
+			for _, shell := range sub.Commands() {
+				shell.Short = i18n.Tf("cmd.completion.shell", map[string]interface{}{"Shell": shell.Name()})
+				if f := shell.Flags().Lookup("no-descriptions"); f != nil {
+					f.Usage = i18n.T("cmd.flag.noDescriptions")
+				}
+			}
+		}
+	}
+
+	var walk func(*cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		cmd.InitDefaultHelpFlag()
+		if f := cmd.Flags().Lookup("help"); f != nil {
+			f.Usage = i18n.Tf("cmd.flag.help", map[string]interface{}{"Name": cmd.Name()})
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
+
+// exactArgs 与 cobra.ExactArgs 相同，但错误信息经过 i18n。
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != n {
+			return errors.New(i18n.Tf("err.argsCount", map[string]interface{}{
+				"Command": cmd.CommandPath(), "Expected": n, "Received": len(args), "Usage": cmd.UseLine(),
+			}))
+		}
+		return nil
+	}
+}
+
+// minArgs 与 cobra.MinimumNArgs 相同，但错误信息经过 i18n。
+func minArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) < n {
+			return errors.New(i18n.Tf("err.argsMin", map[string]interface{}{
+				"Command": cmd.CommandPath(), "Expected": n, "Received": len(args), "Usage": cmd.UseLine(),
+			}))
+		}
+		return nil
+	}
+}
+
+// noArgs 与 cobra.NoArgs 相同，但错误信息经过 i18n。
+func noArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return errors.New(i18n.Tf("err.argsUnexpected", map[string]interface{}{"Command": cmd.CommandPath(), "Args": strings.Join(args, " ")}))
+	}
+	return nil
+}
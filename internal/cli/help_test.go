@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+func runHelp(t *testing.T, lang string, args ...string) string {
+	t.Helper()
+	i18n.SetLanguage(lang)
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	cmd := NewRootCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestHelpLocalized(t *testing.T) {
+	cases := []struct {
+		lang string
+		want []string
+	}{
+		{"en", []string{"Usage:", "Examples:", "Available Commands:", "Flags:", "help for mymtr", "Help about any command", "(default 10)", "mymtr --no-tui --count 5 example.com"}},
+		{"zh", []string{"用法：", "示例：", "可用命令：", "选项：", "显示 mymtr 的帮助", "查看任意命令的帮助", "(默认 10)", "探测轮数"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.lang, func(t *testing.T) {
+			out := runHelp(t, tc.lang, "--help")
+			for _, w := range tc.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("--help output missing %q:\n%s", w, out)
+				}
+			}
+		})
+	}
+}
+
+func TestSubcommandHelpLocalized(t *testing.T) {
+	out := runHelp(t, "zh", "doctor", "--help")
+	for _, w := range []string{"用法：", "mymtr doctor --offline", "全局选项：", "显示 doctor 的帮助"} {
+		if !strings.Contains(out, w) {
+			t.Errorf("doctor --help output missing %q:\n%s", w, out)
+		}
+	}
+	if strings.Contains(out, "Usage:") || strings.Contains(out, "Global Flags:") {
+		t.Errorf("untranslated heading in zh help:\n%s", out)
+	}
+}
+
+func TestArgErrorsLocalized(t *testing.T) {
+	i18n.SetLanguage("zh")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	cmd := NewRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"version", "extra"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "不接受参数") {
+		t.Fatalf("expected localized argument error, got %v", err)
+	}
+
+	cmd = NewRootCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--no-such-flag"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "查看用法") {
+		t.Fatalf("expected localized flag error, got %v", err)
+	}
+}
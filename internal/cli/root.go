@@ -3,11 +3,12 @@ package cli
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/netip"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/hyqhyq3/mymtr/internal/geoip"
 	"github.com/hyqhyq3/mymtr/internal/i18n"
 	"github.com/hyqhyq3/mymtr/internal/mtr"
+	"github.com/hyqhyq3/mymtr/internal/rules"
 	"github.com/hyqhyq3/mymtr/internal/tui"
 )
 
@@ -25,6 +27,7 @@ type rootOptions struct {
 	count     int
 	interval  time.Duration
 	timeout   time.Duration
+	hopTmo    string
 	protocol  string
 	ipVersion int
 	noDNS     bool
@@ -32,29 +35,147 @@ type rootOptions struct {
 	ip2rDB    string
 	ip2rURL   string
 	geoipDL   string
+	dlIPVer   int
 	noGeoIP   bool
+	geoStats  bool
 	json      bool
 	tui       bool
 	noTUI     bool
+	castFile  string
+	noTitle   bool
+	dumpView  string
+	record    string
+	shutdown  time.Duration
+	source    string
+	offline   bool
+	gentle    bool
+	fields    string
+	subnet    string
+	samples   int
+	allIPs    bool
+
+	outputs        []string
+	jsonFile       string
+	compareSources []string
+	compareDSCP    []string
+	hopRules       []string
+	pathPolicies   []string
+	ecmpFlows      int
+	stopUnreach    bool
+	unprivileged   bool
+	strictMatch    bool
+	pinFlow        bool
+	hostRules      []string
+	shortHosts     bool
+	tcpRTTPort     int
+	checkDNS       bool
+	darkAfter      int
+	darkEvery      int
+	units          string
+	precision      int
+
+	geoHTTPURL     string
+	geoHTTPHeaders []string
+	geoHTTPCA      string
+	geoHTTPPins    []string
+	geoHTTPTimeout time.Duration
 }
 
 func NewRootCommand() *cobra.Command {
 	opts := &rootOptions{
-		tui:     true,
-		geoip:   "ip2region",
-		ip2rDB:  geoip.DefaultIP2RegionDBPath(),
-		geoipDL: "ask",
+		tui:         true,
+		geoip:       "ip2region",
+		ip2rDB:      geoip.DefaultIP2RegionDBPath(),
+		geoipDL:     "ask",
+		shutdown:    300 * time.Millisecond,
+		stopUnreach: true,
+		samples:     4,
 	}
 
 	cmd := &cobra.Command{
-		Use:           "mymtr <target>",
-		Short:         i18n.T("cmd.short"),
-		Args:          cobra.ExactArgs(1),
+		Use:     "mymtr <target>",
+		Short:   i18n.T("cmd.short"),
+		Example: i18n.T("cmd.example"),
+		Args: func(cmd *cobra.Command, args []string) error {
+			// --subnet 自带目标，不再接受位置参数
+			if opts.subnet != "" {
+				return noArgs(cmd, args)
+			}
+			return exactArgs(1)(cmd, args)
+		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			target := args[0]
-			useTUI := opts.tui && !opts.noTUI && !opts.json
+			target := opts.subnet
+			if len(args) > 0 {
+				target = args[0]
+			}
+			unit, err := mtr.ParseDurationUnit(opts.units)
+			if err != nil {
+				return err
+			}
+			hopTimeouts, err := mtr.ParseHopTimeouts(opts.hopTmo)
+			if err != nil {
+				return err
+			}
+			hostRules, err := mtr.ParseHostnameRules(opts.hostRules)
+			if err != nil {
+				return err
+			}
+			if opts.shortHosts {
+				hostRules = append(hostRules, mtr.DefaultHostnameRules...)
+			}
+			fields, err := parseFields(opts.fields)
+			if err != nil {
+				return err
+			}
+			if err := validateOutputs(opts.outputs); err != nil {
+				return err
+			}
+			if len(fields) > 0 && !opts.json && opts.jsonFile == "" && !hasJSONOutput(opts.outputs) {
+				return errors.New(i18n.T("err.fieldsNeedJSON"))
+			}
+			if err := checkComparisonModes(opts); err != nil {
+				return err
+			}
+			var samples []netip.Addr
+			if opts.subnet != "" {
+				if samples, err = mtr.SubnetSamples(opts.subnet, opts.samples); err != nil {
+					return err
+				}
+			}
+			if opts.allIPs {
+				ctx := cmd.Context()
+				if ctx == nil {
+					ctx = context.Background()
+				}
+				addrs, err := mtr.ResolveTargetIPs(ctx, target, opts.ipVersion, opts.offline)
+				if err != nil {
+					return err
+				}
+				// 只解析到一个地址时按普通追踪处理（仍可使用 TUI）
+				if len(addrs) > 1 {
+					samples = addrs
+				}
+			}
+			dscps := make([]int, 0, len(opts.compareDSCP))
+			for _, v := range opts.compareDSCP {
+				d, err := mtr.ParseDSCP(v)
+				if err != nil {
+					return err
+				}
+				dscps = append(dscps, d)
+			}
+			// 对比与 ECMP 模式各自汇总多次探测，不经过单次探测的输出 sink
+			multiRun := len(opts.compareSources) > 0 || len(dscps) > 0 || len(samples) > 0 || opts.ecmpFlows > 0
+			if multiRun && (len(opts.outputs) > 0 || opts.jsonFile != "") {
+				return errors.New(i18n.T("err.outputsMultiRun"))
+			}
+			// ECMP 直接使用 prober 枚举路径，不创建 Controller
+			if opts.ecmpFlows > 0 && (len(opts.hopRules) > 0 || len(opts.pathPolicies) > 0 || opts.record != "") {
+				return errors.New(i18n.T("err.ecmpControllerFlags"))
+			}
+			useTUI := opts.tui && !opts.noTUI && !opts.json && !outputsUseStdout(opts.outputs) && !multiRun
 
 			count := opts.count
 			if useTUI && count == 10 && !cmd.Flags().Changed("count") {
@@ -73,121 +194,385 @@ func NewRootCommand() *cobra.Command {
 				Protocol:  mtr.Protocol(opts.protocol),
 				IPVersion: opts.ipVersion,
 				EnableDNS: !opts.noDNS,
+				Source:    opts.source,
+
+				ContinueOnUnreachable: !opts.stopUnreach,
+				DurationFormat:        mtr.DurationFormat{Unit: unit, Precision: opts.precision},
+				Offline:               opts.offline,
+				Gentle:                opts.gentle,
+				Unprivileged:          opts.unprivileged,
+				StrictMatch:           opts.strictMatch,
+				PinFlow:               opts.pinFlow,
+				TCPRTTPort:            opts.tcpRTTPort,
+				Safety:                safetyLimits(cmd),
+				HopTimeouts:           hopTimeouts,
+				HostnameRules:         hostRules,
+				CheckDNS:              opts.checkDNS,
+				DarkHopAfter:          opts.darkAfter,
+				DarkHopEvery:          opts.darkEvery,
 			}
 
-			prober, err := mtr.NewProber(cfg.Protocol, cfg.IPVersion, cfg.Timeout)
+			// 未显式指定的 interval/timeout 交给协议默认档位决定
+			cfg.ApplyProtocolProfile()
+
+			resolver, err := newResolver(cmd, opts)
 			if err != nil {
 				return err
 			}
-			defer prober.Close()
+			var geoMetrics *geoip.MetricsResolver
+			if opts.geoStats {
+				geoMetrics = geoip.WithMetrics(resolver)
+				resolver = geoMetrics
+			}
+			defer func() {
+				// 在线查询可能卡在慢速接口上，退出时最多等待 --shutdown-timeout
+				ctx, cancel := context.WithTimeout(context.Background(), opts.shutdown)
+				defer cancel()
+				resolver.Shutdown(ctx)
+				if geoMetrics != nil {
+					fmt.Fprintln(cmd.ErrOrStderr(), formatGeoMetrics(geoMetrics.Metrics()))
+				}
+			}()
 
-			geoipSource := opts.geoip
-			if opts.noGeoIP {
-				geoipSource = "off"
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
 			}
-			downloadAnswer, err := parseDownloadAnswer(opts.geoipDL)
+
+			hook, err := rules.Parse(opts.hopRules)
 			if err != nil {
 				return err
 			}
-			var prompt geoip.DownloadPrompt
-			if downloadAnswer == geoip.DownloadAsk {
-				prompt = newDownloadPrompt(cmd)
-			}
-			resolver, err := geoip.NewResolver(geoipSource, geoip.Options{
-				IP2RegionDB:  opts.ip2rDB,
-				IP2RegionURL: opts.ip2rURL,
-				Download: geoip.DownloadOption{
-					Answer: downloadAnswer,
-					Prompt: prompt,
-				},
-			})
+			policies, err := rules.ParsePolicies(opts.pathPolicies)
 			if err != nil {
 				return err
 			}
-			defer resolver.Close()
+			var recorder *mtr.ProbeRecorder
+			if opts.record != "" {
+				f, err := os.OpenFile(opts.record, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				recorder = mtr.NewProbeRecorder(f)
+			}
+			// sinks 仅在单次探测时创建；对比模式经同一工厂挂载规则、记录器与路径策略
+			var sinks *sinkSet
+			newController := func(cfg *mtr.Config) (*mtr.Controller, mtr.Prober, error) {
+				prober, err := mtr.NewProberWithFallback(cfg)
+				if err != nil {
+					return nil, nil, err
+				}
+				controller, err := mtr.NewController(cfg, prober, resolver)
+				if err != nil {
+					prober.Close()
+					return nil, nil, err
+				}
+				if hook != nil {
+					// 告警状态按 Controller 独立，对比模式下并行运行的路径互不影响
+					controller.SetHopHook(hook.Clone())
+				}
+				if recorder != nil {
+					controller.SetProbeRecorder(recorder)
+				}
+				if policies != nil {
+					controller.SetPathPolicy(policies)
+				}
+				if sinks != nil {
+					controller.AddEventObserver(sinks.observe)
+				}
+				return controller, prober, nil
+			}
+
+			if len(opts.compareSources) > 0 {
+				cfgs := make([]*mtr.Config, 0, len(opts.compareSources))
+				for _, src := range opts.compareSources {
+					c := *cfg
+					c.Source = strings.TrimSpace(src)
+					cfgs = append(cfgs, &c)
+				}
+				return runComparison(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, opts.compareSources, cfgs, newController)
+			}
+			if len(dscps) > 0 {
+				// 各流量类别并行探测，探测包在时间上交错，经历相同的网络状况
+				labels := make([]string, 0, len(dscps))
+				cfgs := make([]*mtr.Config, 0, len(dscps))
+				for i, d := range dscps {
+					c := *cfg
+					c.DSCP = d
+					cfgs = append(cfgs, &c)
+					labels = append(labels, fmt.Sprintf("%s (%d)", strings.ToUpper(strings.TrimSpace(opts.compareDSCP[i])), d))
+				}
+				return runComparison(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, labels, cfgs, newController)
+			}
+			if len(samples) > 0 {
+				labels := make([]string, 0, len(samples))
+				cfgs := make([]*mtr.Config, 0, len(samples))
+				for _, addr := range samples {
+					c := *cfg
+					c.Target = addr.String()
+					if addr.Is6() {
+						c.IPVersion = 6
+					} else {
+						c.IPVersion = 4
+					}
+					labels = append(labels, c.Target)
+					cfgs = append(cfgs, &c)
+				}
+				return runComparison(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, labels, cfgs, newController)
+			}
+			if opts.ecmpFlows > 0 {
+				return runECMP(ctx, cmd.OutOrStdout(), cmd.ErrOrStderr(), opts, cfg)
+			}
 
-			controller, err := mtr.NewController(cfg, prober, resolver)
+			outputs := opts.outputs
+			if opts.jsonFile != "" {
+				// --json-file 只追加一份 JSON 快照文件，终端上仍保留原有的文本/TUI 输出
+				outputs = append(outputs[:len(outputs):len(outputs)], "json:"+opts.jsonFile)
+			}
+			sinks, err = openSinks(outputs, fields)
 			if err != nil {
 				return err
 			}
+			defer sinks.Close()
+			if !useTUI {
+				// 未指定 --output 时沿用原有的单一输出：--json 输出快照 JSON，否则输出文本报告
+				if opts.json {
+					sinks.add(&jsonSink{w: os.Stdout, fields: fields})
+				} else if len(opts.outputs) == 0 {
+					sinks.add(&textSink{w: os.Stdout})
+				}
+			}
 
-			ctx := cmd.Context()
-			if ctx == nil {
-				ctx = context.Background()
+			controller, prober, err := newController(cfg)
+			if err != nil {
+				return err
 			}
+			defer prober.Close()
+			warnProbeFallback(cmd.ErrOrStderr(), cfg)
 
 			if useTUI {
 				ctx, cancel := context.WithCancel(ctx)
-				errCh := make(chan error, 1)
-				go func() { errCh <- controller.Run(ctx) }()
+				restart := func(cfg *mtr.Config) (*mtr.Controller, error) {
+					c, p, err := newController(cfg)
+					if err != nil {
+						return nil, err
+					}
+					// 命令模式重建的 prober 在对应 Controller 结束后关闭
+					go func() {
+						<-c.Done()
+						p.Close()
+					}()
+					return c, nil
+				}
 
-				if err := tui.Run(ctx, cancel, controller); err != nil {
+				controller, err = tui.Run(ctx, cancel, controller, tui.Options{
+					CastFile: opts.castFile,
+					DumpFile: opts.dumpView,
+					Restart:  restart,
+					NoTitle:  opts.noTitle,
+				})
+				if err != nil {
 					cancel()
 					return err
 				}
 
 				cancel()
 				select {
-				case err = <-errCh:
-					if err != nil && !errors.Is(err, context.Canceled) {
-						return err
-					}
-					return nil
-				case <-time.After(300 * time.Millisecond):
+				case <-controller.Done():
+				case <-time.After(opts.shutdown):
 					// 不阻塞退出：defer 会关闭 prober/resolver，Probe 会被打断并退出。
-					return nil
 				}
+				// 探测过程中出现的错误（如权限问题）与未满足的路径策略以非零退出码返回
+				if err := controller.Err(); err != nil {
+					return err
+				}
+				if err := sinks.finish(controller.Snapshot()); err != nil {
+					return err
+				}
+				return policyError(controller.Snapshot())
 			}
 
-			if err := controller.Run(ctx); err != nil {
+			printed := make(chan struct{})
+			go func() {
+				defer close(printed)
+				printEvents(cmd.ErrOrStderr(), controller.Events())
+			}()
+			err = controller.Run(ctx)
+			<-printed
+			if err != nil {
 				return err
 			}
 
 			snapshot := controller.Snapshot()
-			if opts.json {
-				enc := json.NewEncoder(os.Stdout)
-				enc.SetIndent("", "  ")
-				return enc.Encode(snapshot)
+			if err := sinks.finish(snapshot); err != nil {
+				return err
 			}
-
-			return renderText(snapshot)
+			return policyError(snapshot)
 		},
 	}
 
+	cmd.AddCommand(newDoctorCommand())
+	cmd.AddCommand(newVerifyCommand())
+	cmd.AddCommand(newVersionCommand())
+	cmd.AddCommand(newSelftestCommand())
+	cmd.AddCommand(newGeoIPCommand())
+	cmd.AddCommand(newBlockedCommand())
+
+	// --offline 为全局开关，子命令（如 doctor）同样遵守
+	cmd.PersistentFlags().BoolVar(&opts.offline, "offline", false, i18n.T("cmd.flag.offline"))
+	cmd.PersistentFlags().Bool("i-know-what-im-doing", false, i18n.T("cmd.flag.iKnowWhatImDoing"))
+
 	cmd.Flags().IntVar(&opts.maxHops, "max-hops", 30, i18n.T("cmd.flag.maxHops"))
 	cmd.Flags().IntVar(&opts.count, "count", 10, i18n.T("cmd.flag.count"))
-	cmd.Flags().DurationVar(&opts.interval, "interval", time.Second, i18n.T("cmd.flag.interval"))
-	cmd.Flags().DurationVar(&opts.timeout, "timeout", time.Second, i18n.T("cmd.flag.timeout"))
+	cmd.Flags().DurationVar(&opts.interval, "interval", 0, i18n.T("cmd.flag.interval"))
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, i18n.T("cmd.flag.timeout"))
+	cmd.Flags().StringVar(&opts.hopTmo, "timeout-per-hop", "", i18n.T("cmd.flag.timeoutPerHop"))
 	cmd.Flags().StringVar(&opts.protocol, "protocol", string(mtr.ProtocolICMP), i18n.T("cmd.flag.protocol"))
 	cmd.Flags().IntVar(&opts.ipVersion, "ip-version", 4, i18n.T("cmd.flag.ipVersion"))
+	cmd.Flags().StringVar(&opts.source, "source", "", i18n.T("cmd.flag.source"))
+	cmd.Flags().StringVar(&opts.subnet, "subnet", "", i18n.T("cmd.flag.subnet"))
+	cmd.Flags().IntVar(&opts.samples, "subnet-samples", opts.samples, i18n.T("cmd.flag.subnetSamples"))
+	cmd.Flags().BoolVar(&opts.allIPs, "all-ips", false, i18n.T("cmd.flag.allIPs"))
+	cmd.Flags().StringSliceVar(&opts.compareSources, "compare-sources", nil, i18n.T("cmd.flag.compareSources"))
+	cmd.Flags().StringSliceVar(&opts.compareDSCP, "compare-dscp", nil, i18n.T("cmd.flag.compareDSCP"))
+	cmd.Flags().IntVar(&opts.ecmpFlows, "ecmp-flows", 0, i18n.T("cmd.flag.ecmpFlows"))
+	cmd.Flags().BoolVar(&opts.gentle, "gentle", false, i18n.T("cmd.flag.gentle"))
+	cmd.Flags().IntVar(&opts.darkAfter, "dark-hop-after", 0, i18n.T("cmd.flag.darkHopAfter"))
+	cmd.Flags().IntVar(&opts.darkEvery, "dark-hop-every", 5, i18n.T("cmd.flag.darkHopEvery"))
+	cmd.Flags().BoolVar(&opts.unprivileged, "unprivileged", false, i18n.T("cmd.flag.unprivileged"))
+	cmd.Flags().BoolVar(&opts.strictMatch, "strict-match", false, i18n.T("cmd.flag.strictMatch"))
+	cmd.Flags().BoolVar(&opts.checkDNS, "check-dns", false, i18n.T("cmd.flag.checkDNS"))
+	cmd.Flags().BoolVar(&opts.pinFlow, "pin-flow", false, i18n.T("cmd.flag.pinFlow"))
+	cmd.Flags().IntVar(&opts.tcpRTTPort, "tcp-rtt", 0, i18n.T("cmd.flag.tcpRTT"))
+	cmd.Flags().BoolVar(&opts.stopUnreach, "stop-on-unreachable", opts.stopUnreach, i18n.T("cmd.flag.stopOnUnreachable"))
 	cmd.Flags().BoolVar(&opts.noDNS, "no-dns", false, i18n.T("cmd.flag.noDNS"))
-	cmd.Flags().StringVar(&opts.geoip, "geoip", opts.geoip, i18n.T("cmd.flag.geoip"))
-	cmd.Flags().StringVar(&opts.ip2rDB, "ip2region-db", opts.ip2rDB, i18n.T("cmd.flag.ip2regionDB"))
-	cmd.Flags().StringVar(&opts.ip2rURL, "geoip-ip2region-url", "", i18n.T("cmd.flag.ip2regionURL"))
-	cmd.Flags().StringVar(&opts.geoipDL, "geoip-download", opts.geoipDL, i18n.T("cmd.flag.geoipDownload"))
+	addGeoIPFlags(cmd, opts)
+	cmd.Flags().BoolVar(&opts.geoStats, "geoip-stats", false, i18n.T("cmd.flag.geoipStats"))
 	cmd.Flags().BoolVar(&opts.noGeoIP, "no-geoip", false, i18n.T("cmd.flag.noGeoIP"))
+	cmd.Flags().StringArrayVar(&opts.hopRules, "hop-rule", nil, i18n.T("cmd.flag.hopRule"))
+	cmd.Flags().StringArrayVar(&opts.pathPolicies, "path-policy", nil, i18n.T("cmd.flag.pathPolicy"))
+	cmd.Flags().StringArrayVar(&opts.hostRules, "hostname-rule", nil, i18n.T("cmd.flag.hostnameRule"))
+	cmd.Flags().BoolVar(&opts.shortHosts, "short-hostnames", false, i18n.T("cmd.flag.shortHostnames"))
+	cmd.Flags().StringVar(&opts.units, "units", string(mtr.DurationUnitMs), i18n.T("cmd.flag.units"))
+	cmd.Flags().IntVar(&opts.precision, "precision", 0, i18n.T("cmd.flag.precision"))
 	cmd.Flags().BoolVar(&opts.json, "json", false, i18n.T("cmd.flag.json"))
+	cmd.Flags().StringVar(&opts.fields, "fields", "", i18n.T("cmd.flag.fields"))
 	cmd.Flags().BoolVar(&opts.tui, "tui", true, i18n.T("cmd.flag.tui"))
 	cmd.Flags().BoolVar(&opts.noTUI, "no-tui", false, i18n.T("cmd.flag.noTUI"))
+	cmd.Flags().BoolVar(&opts.noTitle, "no-title", false, i18n.T("cmd.flag.noTitle"))
+	cmd.Flags().StringVar(&opts.castFile, "record-cast", "", i18n.T("cmd.flag.recordCast"))
+	cmd.Flags().StringVar(&opts.record, "record", "", i18n.T("cmd.flag.record"))
+	cmd.Flags().StringArrayVar(&opts.outputs, "output", nil, i18n.T("cmd.flag.output"))
+	cmd.Flags().StringVar(&opts.jsonFile, "json-file", "", i18n.T("cmd.flag.jsonFile"))
+	cmd.Flags().StringVar(&opts.dumpView, "dump-view", "", i18n.T("cmd.flag.dumpView"))
+	cmd.Flags().DurationVar(&opts.shutdown, "shutdown-timeout", opts.shutdown, i18n.T("cmd.flag.shutdownTimeout"))
 
+	localizeHelp(cmd)
 	return cmd
 }
 
-func renderText(s *mtr.Snapshot) error {
+// addGeoIPFlags 注册地理位置数据源相关标志，主命令与 geoip lookup 共用。
+func addGeoIPFlags(cmd *cobra.Command, opts *rootOptions) {
+	cmd.Flags().StringVar(&opts.geoip, "geoip", opts.geoip, i18n.T("cmd.flag.geoip"))
+	cmd.Flags().StringVar(&opts.ip2rDB, "ip2region-db", opts.ip2rDB, i18n.T("cmd.flag.ip2regionDB"))
+	cmd.Flags().StringVar(&opts.ip2rURL, "geoip-ip2region-url", "", i18n.T("cmd.flag.ip2regionURL"))
+	cmd.Flags().StringVar(&opts.geoipDL, "geoip-download", opts.geoipDL, i18n.T("cmd.flag.geoipDownload"))
+	cmd.Flags().IntVar(&opts.dlIPVer, "download-ip-version", 0, i18n.T("cmd.flag.downloadIPVersion"))
+	cmd.Flags().StringVar(&opts.geoHTTPURL, "geoip-http-url", "", i18n.T("cmd.flag.geoipHTTPURL"))
+	cmd.Flags().StringArrayVar(&opts.geoHTTPHeaders, "geoip-http-header", nil, i18n.T("cmd.flag.geoipHTTPHeader"))
+	cmd.Flags().StringVar(&opts.geoHTTPCA, "geoip-http-ca", "", i18n.T("cmd.flag.geoipHTTPCA"))
+	cmd.Flags().StringSliceVar(&opts.geoHTTPPins, "geoip-http-pin", nil, i18n.T("cmd.flag.geoipHTTPPin"))
+	cmd.Flags().DurationVar(&opts.geoHTTPTimeout, "geoip-http-timeout", 0, i18n.T("cmd.flag.geoipHTTPTimeout"))
+}
+
+// geoipHTTPFlags 为仅适用于 --geoip=cip 的 HTTP 客户端标志。
+var geoipHTTPFlags = []string{"geoip-http-url", "geoip-http-header", "geoip-http-ca", "geoip-http-pin", "geoip-http-timeout"}
+
+func newResolver(cmd *cobra.Command, opts *rootOptions) (geoip.GeoResolver, error) {
+	geoipSource := opts.geoip
+	if opts.noGeoIP {
+		geoipSource = "off"
+	}
+	if src := strings.ToLower(strings.TrimSpace(geoipSource)); src != "cip" && src != "cip.cc" {
+		// --geoip-http-* 只作用于在线数据源，其他数据源下指定时直接报错，避免误以为已生效
+		for _, name := range geoipHTTPFlags {
+			if cmd.Flags().Changed(name) {
+				return nil, errors.New(i18n.Tf("err.geoipHTTPFlagSource", map[string]interface{}{"Flag": "--" + name, "Source": geoipSource}))
+			}
+		}
+	}
+	downloadAnswer, err := parseDownloadAnswer(opts.geoipDL)
+	if err != nil {
+		return nil, err
+	}
+	var prompt geoip.DownloadPrompt
+	if downloadAnswer == geoip.DownloadAsk {
+		prompt = newDownloadPrompt(cmd)
+	}
+	headers := make(map[string]string, len(opts.geoHTTPHeaders))
+	for _, h := range opts.geoHTTPHeaders {
+		name, value, err := geoip.ParseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = value
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return geoip.NewResolverContext(ctx, geoipSource, geoip.Options{
+		IP2RegionDB:  opts.ip2rDB,
+		IP2RegionURL: opts.ip2rURL,
+		Download: geoip.DownloadOption{
+			Answer:    downloadAnswer,
+			Prompt:    prompt,
+			IPVersion: opts.dlIPVer,
+		},
+		HTTP: geoip.HTTPOptions{
+			BaseURL:   opts.geoHTTPURL,
+			Headers:   headers,
+			CAFile:    opts.geoHTTPCA,
+			PinSHA256: opts.geoHTTPPins,
+			Timeout:   opts.geoHTTPTimeout,
+		},
+		Offline: opts.offline,
+	})
+}
+
+func renderText(out io.Writer, s *mtr.Snapshot) error {
 	if s == nil {
 		return errors.New(i18n.T("err.emptyResult"))
 	}
 
-	fmt.Printf("Target: %s (%s)  Protocol: %s  Rounds: %d\n\n", s.Target, s.TargetIP, s.Protocol, s.Count)
+	fmt.Fprintf(out, "Target: %s (%s)  Protocol: %s  Rounds: %d  Path: %s\n\n", s.Target, s.TargetIP, formatProtocol(s), s.Count, formatCompleteness(s.Completeness))
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "TTL\tLoss%\tSnt\tRcv\tLast\tAvg\tBest\tWrst\tStDev\tAddress\tHostname\tLocation")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TTL\tLoss%\tSnt\tRcv\tLast\tAvg\tSeg\tBest\tWrst\tStDev\tAddress\tHostname\tLocation")
 	for _, hop := range s.Hops {
+		if hop.Hidden {
+			continue
+		}
 		address := "*"
 		if hop.IP != "" {
 			address = hop.IP
+		} else if hop.SendFailed {
+			address = "! send failed"
+		}
+		if hop.Loop {
+			address += " [loop]"
 		}
-		hostname := hop.Hostname
+		if hop.Foreign {
+			address += " [foreign]"
+		}
+		if hop.Stats.Suspicious > 0 {
+			address += " [suspicious]"
+		}
+		if len(hop.Tags) > 0 {
+			address += " [" + strings.Join(hop.Tags, ",") + "]"
+		}
+		hostname := hop.DisplayHostname()
 		if strings.TrimSpace(hostname) == "" {
 			hostname = "-"
 		}
@@ -202,13 +587,14 @@ func renderText(s *mtr.Snapshot) error {
 		stats := hop.Stats
 		fmt.Fprintf(
 			w,
-			"%d\t%.1f\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			"%d\t%.1f\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 			hop.TTL,
 			stats.Loss,
 			stats.Sent,
 			stats.Received,
 			emptyAsDash(stats.Last),
 			emptyAsDash(stats.Avg),
+			emptyAsDash(stats.Segment),
 			emptyAsDash(stats.Best),
 			emptyAsDash(stats.Worst),
 			emptyAsDash(stats.StdDev),
@@ -217,7 +603,104 @@ func renderText(s *mtr.Snapshot) error {
 			location,
 		)
 	}
-	return w.Flush()
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	unreachable := s.Destination.State == mtr.DestinationStateUnreachable
+	if len(s.Loops) > 0 || s.ProbeErrors.Total() > 0 || unreachable || s.Anycast != nil || s.DNSCheck.Mismatch() || len(s.Violated) > 0 || s.TCPRTT != nil {
+		fmt.Fprintln(out)
+	}
+	if unreachable {
+		fmt.Fprintln(out, i18n.Tf("cli.destUnreachable", map[string]interface{}{"TTL": s.Destination.TTL, "IP": s.Destination.IP, "Reason": s.Destination.Reason}))
+	}
+	for _, loop := range s.Loops {
+		fmt.Fprintln(out, i18n.Tf("cli.loopSummary", map[string]interface{}{"IP": loop.IP, "TTLs": joinInts(loop.TTLs)}))
+	}
+	if s.DNSCheck.Mismatch() {
+		fmt.Fprintln(out, s.DNSCheck.Message())
+	}
+	for _, v := range s.Violated {
+		if policyViolatedNow(s, v.Policy) {
+			fmt.Fprintln(out, v.Message())
+		} else {
+			fmt.Fprintln(out, i18n.Tf("cli.policyRecovered", map[string]interface{}{"Policy": v.Policy, "Detail": v.Detail}))
+		}
+	}
+	if t := s.TCPRTT; t != nil {
+		fmt.Fprintln(out, formatTCPRTT(s, t))
+	}
+	if s.Anycast != nil {
+		fmt.Fprintln(out, i18n.Tf("cli.anycast", map[string]interface{}{"Sites": len(s.Anycast.Sites)}))
+		for _, site := range s.Anycast.Sites {
+			fmt.Fprintln(out, i18n.Tf("cli.anycastSite", map[string]interface{}{
+				"Neighbor": emptyAsDash(site.Neighbor), "TTL": site.DestTTL, "Rounds": site.Rounds, "RTT": emptyAsDash(s.FormatMs(site.AvgRTTMs)),
+			}))
+		}
+	}
+	if pe := s.ProbeErrors; pe.Total() > 0 {
+		fmt.Fprintln(out, i18n.Tf("cli.probeErrors", map[string]interface{}{"Send": pe.Send, "Parse": pe.Parse, "Read": pe.Read}))
+	}
+	if pe := s.ProbeErrors; pe.LastSendError != "" {
+		fmt.Fprintln(out, i18n.Tf("cli.sendFailed", map[string]interface{}{"Count": pe.Send, "Error": pe.LastSendError}))
+	}
+	return nil
+}
+
+// formatTCPRTT 输出 TCP 握手时延与 ICMP 时延的对照；ICMP 明显偏高时提示目标可能降低了 ICMP 优先级。
+func formatTCPRTT(s *mtr.Snapshot, t *mtr.TCPRTT) string {
+	if t.Received == 0 {
+		return i18n.Tf("cli.tcpRTTNone", map[string]interface{}{"Port": t.Port, "Sent": t.Sent})
+	}
+	args := map[string]interface{}{
+		"Port": t.Port, "Received": t.Received, "Sent": t.Sent,
+		"Avg": emptyAsDash(s.FormatMs(t.AvgMs)), "Best": emptyAsDash(s.FormatMs(t.BestMs)), "Worst": emptyAsDash(s.FormatMs(t.WorstMs)),
+		"ICMP": emptyAsDash(s.FormatMs(t.ICMPAvgMs)),
+	}
+	line := i18n.Tf("cli.tcpRTT", args)
+	if t.Deprioritized {
+		line += "\n" + i18n.T("cli.tcpRTTDeprioritized")
+	}
+	return line
+}
+
+// formatGeoMetrics 输出地理位置查询统计（--geoip-stats），用于区分慢在网络还是慢在地理位置后端。
+func formatGeoMetrics(m geoip.Metrics) string {
+	return i18n.Tf("cli.geoipStats", map[string]interface{}{
+		"Source": m.Source, "Lookups": m.Lookups, "HitRate": fmt.Sprintf("%.1f", m.HitRate()*100),
+		"Calls": m.BackendCalls, "Failures": m.Failures,
+		"Avg": fmt.Sprintf("%.1f", m.AvgMs), "Max": fmt.Sprintf("%.1f", m.MaxMs),
+	})
+}
+
+// policyError 在运行期间任意一轮违反过路径策略时返回错误，使退出码非零（即使最后一轮已恢复）。
+func policyError(s *mtr.Snapshot) error {
+	if n := len(s.Violated); n > 0 {
+		return errors.New(i18n.Tf("err.policyViolated", map[string]interface{}{"Count": n}))
+	}
+	return nil
+}
+
+// policyViolatedNow 判断策略在最近一轮是否仍未满足。
+func policyViolatedNow(s *mtr.Snapshot, policy string) bool {
+	for _, v := range s.Violations {
+		if v.Policy == policy {
+			return true
+		}
+	}
+	return false
+}
+
+func formatCompleteness(pc mtr.PathCompleteness) string {
+	return fmt.Sprintf("%.0f%% (%d/%d)", pc.Percent, pc.Responded, pc.Total)
+}
+
+func joinInts(vs []int) string {
+	parts := make([]string, 0, len(vs))
+	for _, v := range vs {
+		parts = append(parts, strconv.Itoa(v))
+	}
+	return strings.Join(parts, ",")
 }
 
 func parseDownloadAnswer(v string) (geoip.DownloadAnswer, error) {
@@ -268,3 +751,46 @@ func emptyAsDash(s string) string {
 	}
 	return s
 }
+
+// formatProtocol 返回协议名；降级为无特权探测时附带实际使用的方式。
+func formatProtocol(s *mtr.Snapshot) string {
+	if s.ProbeMode == "" {
+		return s.Protocol
+	}
+	return fmt.Sprintf("%s (unprivileged: %s)", s.Protocol, s.ProbeMode)
+}
+
+// printEvents 在非 TUI 模式下把目标可达性变化、--hop-rule 的 alert 规则告警与 TTL 疑似被改写的告警输出到 stderr，
+// 持续探测时无需从计数器推断；其余告警已汇总在最终报告中，不在探测过程中重复输出。事件通道关闭后返回。
+func printEvents(w io.Writer, events <-chan mtr.Event) {
+	for ev := range events {
+		if ev.Type == mtr.EventTypeReachability || (ev.Type == mtr.EventTypeWarning && isLiveWarning(ev.Err)) {
+			fmt.Fprintln(w, ev.Message)
+		}
+	}
+}
+
+// isLiveWarning 判断告警是否需要在探测过程中实时输出（最终报告中没有对应汇总）。
+func isLiveWarning(err error) bool {
+	var (
+		rewritten *mtr.TTLRewrittenError
+		alert     *mtr.HookAlertError
+	)
+	return errors.As(err, &rewritten) || errors.As(err, &alert)
+}
+
+// safetyLimits 返回编译进二进制的探测安全上限；指定 --i-know-what-im-doing 时不限制。
+func safetyLimits(cmd *cobra.Command) *mtr.SafetyLimits {
+	if override, _ := cmd.Flags().GetBool("i-know-what-im-doing"); override {
+		return nil
+	}
+	return mtr.CompiledSafetyLimits()
+}
+
+// warnProbeFallback 在因权限不足降级探测方式时提示用户；显式指定 --unprivileged 时不提示。
+func warnProbeFallback(w io.Writer, cfg *mtr.Config) {
+	if cfg.ProbeMode == "" || cfg.Unprivileged {
+		return
+	}
+	fmt.Fprintln(w, i18n.Tf("warn.probeFallback", map[string]interface{}{"Protocol": cfg.Protocol, "Mode": cfg.ProbeMode}))
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestPrintEventsOnlyLiveWarnings(t *testing.T) {
+	rewritten := &mtr.TTLRewrittenError{IP: "198.51.100.7"}
+	alert := &mtr.HookAlertError{TTL: 3, Message: "alert lossy"}
+	ch := make(chan mtr.Event, 5)
+	ch <- mtr.Event{Type: mtr.EventTypeWarning, Message: "loop"}
+	ch <- mtr.Event{Type: mtr.EventTypeWarning, Err: rewritten, Message: rewritten.Error()}
+	ch <- mtr.Event{Type: mtr.EventTypeReachability, Message: "lost"}
+	ch <- mtr.Event{Type: mtr.EventTypeWarning, TTL: 3, Err: alert, Message: alert.Message}
+	ch <- mtr.Event{Type: mtr.EventTypeWarning, Message: "loop"}
+	close(ch)
+
+	var buf bytes.Buffer
+	printEvents(&buf, ch)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || lines[0] != rewritten.Error() || lines[1] != "lost" || lines[2] != "alert lossy" {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestPolicyErrorCountsEarlierViolations(t *testing.T) {
+	i18n.SetLanguage("en")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	v := mtr.PolicyViolation{Policy: "no-transit", Detail: "hop 3 matched"}
+	s := &mtr.Snapshot{Violated: []mtr.PolicyViolation{v}}
+	if err := policyError(s); err == nil {
+		t.Fatal("expected a violation in an earlier round to fail the run")
+	}
+	if err := policyError(&mtr.Snapshot{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := renderText(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "holds in the last round") {
+		t.Fatalf("expected recovered policy in report:\n%s", buf.String())
+	}
+}
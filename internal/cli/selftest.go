@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// selftestTarget 为自检追踪的一个目标；remote 为 true 时目标不可达只记为警告（取决于外部网络）。
+type selftestTarget struct {
+	addr      string
+	ipVersion int
+	remote    bool
+}
+
+func newSelftestCommand() *cobra.Command {
+	var (
+		target   string
+		protocol string
+		count    int
+		timeout  time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:     "selftest",
+		Short:   i18n.T("cmd.selftest.short"),
+		Example: i18n.T("cmd.selftest.example"),
+		Args:    noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			offline, _ := cmd.Flags().GetBool("offline")
+			targets := []selftestTarget{{addr: "127.0.0.1", ipVersion: 4}, {addr: "::1", ipVersion: 6}}
+			if target = strings.TrimSpace(target); target != "" {
+				v := 4
+				if ip := net.ParseIP(target); ip != nil && ip.To4() == nil {
+					v = 6
+				}
+				targets = append(targets, selftestTarget{addr: target, ipVersion: v, remote: true})
+			}
+
+			var results []checkResult
+			for _, t := range targets {
+				cfg := &mtr.Config{
+					Target:    t.addr,
+					MaxHops:   30,
+					Count:     count,
+					Interval:  100 * time.Millisecond,
+					Timeout:   timeout,
+					Protocol:  mtr.Protocol(protocol),
+					IPVersion: t.ipVersion,
+					Offline:   offline,
+					Safety:    safetyLimits(cmd),
+				}
+				results = append(results, runSelftest(ctx, cfg, t)...)
+			}
+			return renderDoctor(cmd.OutOrStdout(), results)
+		},
+	}
+	cmd.Flags().StringVar(&target, "target", "1.1.1.1", i18n.T("cmd.flag.selftestTarget"))
+	cmd.Flags().StringVar(&protocol, "protocol", string(mtr.ProtocolICMP), i18n.T("cmd.flag.protocol"))
+	cmd.Flags().IntVar(&count, "count", 3, i18n.T("cmd.flag.count"))
+	cmd.Flags().DurationVar(&timeout, "timeout", time.Second, i18n.T("cmd.flag.timeout"))
+	return cmd
+}
+
+// runSelftest 对单个目标依次检查：创建套接字、完成追踪并到达目标、统计数据自洽、文本与 JSON 渲染。
+// 前一步失败时后续检查记为跳过。
+func runSelftest(ctx context.Context, cfg *mtr.Config, t selftestTarget) []checkResult {
+	name := func(key string) string {
+		return i18n.Tf(key, map[string]interface{}{"Target": t.addr})
+	}
+	// IPv6 回环与远端目标的失败多半是环境限制（未启用 IPv6、无外网），记为警告
+	soft := checkFail
+	if t.ipVersion == 6 || t.remote {
+		soft = checkWarn
+	}
+	skipped := func(keys ...string) []checkResult {
+		out := make([]checkResult, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, checkResult{name(k), checkSkip, ""})
+		}
+		return out
+	}
+
+	results := make([]checkResult, 0, 4)
+	cfg.ApplyProtocolProfile()
+	prober, err := mtr.NewProberWithFallback(cfg)
+	if err != nil {
+		results = append(results, checkResult{name("selftest.check.socket"), soft, err.Error()})
+		return append(results, skipped("selftest.check.trace", "selftest.check.stats", "selftest.check.render")...)
+	}
+	defer prober.Close()
+	detail := string(cfg.Protocol)
+	if cfg.ProbeMode != "" {
+		detail += " (" + cfg.ProbeMode + ")"
+	}
+	results = append(results, checkResult{name("selftest.check.socket"), checkOK, detail})
+
+	controller, err := mtr.NewController(cfg, prober, nil)
+	if err == nil {
+		err = controller.Run(ctx)
+	}
+	if err != nil {
+		results = append(results, checkResult{name("selftest.check.trace"), soft, err.Error()})
+		return append(results, skipped("selftest.check.stats", "selftest.check.render")...)
+	}
+	s := controller.Snapshot()
+	switch d := s.Destination; d.State {
+	case mtr.DestinationStateUnreachable:
+		results = append(results, checkResult{name("selftest.check.trace"), soft, i18n.Tf("cli.destUnreachable", map[string]interface{}{"TTL": d.TTL, "IP": d.IP, "Reason": d.Reason})})
+	case mtr.DestinationStateReached:
+		results = append(results, checkResult{name("selftest.check.trace"), checkOK, fmt.Sprintf("TTL %d, %s", d.TTL, d.IP)})
+	default:
+		results = append(results, checkResult{name("selftest.check.trace"), soft, i18n.Tf("selftest.notReached", map[string]interface{}{"Hops": cfg.MaxHops})})
+	}
+
+	if err := checkSnapshotStats(s); err != "" {
+		results = append(results, checkResult{name("selftest.check.stats"), checkFail, err})
+	} else {
+		results = append(results, checkResult{name("selftest.check.stats"), checkOK, ""})
+	}
+
+	var buf bytes.Buffer
+	if err := renderText(&buf, s); err != nil {
+		results = append(results, checkResult{name("selftest.check.render"), checkFail, err.Error()})
+	} else if _, err := json.Marshal(s); err != nil {
+		results = append(results, checkResult{name("selftest.check.render"), checkFail, err.Error()})
+	} else if !strings.Contains(buf.String(), s.TargetIP) {
+		results = append(results, checkResult{name("selftest.check.render"), checkFail, i18n.T("selftest.renderMissingTarget")})
+	} else {
+		results = append(results, checkResult{name("selftest.check.render"), checkOK, ""})
+	}
+	return results
+}
+
+// checkSnapshotStats 校验快照中每一跳的统计是否自洽，返回第一个不一致之处（无问题时为空）。
+func checkSnapshotStats(s *mtr.Snapshot) string {
+	for _, h := range s.Hops {
+		st := h.Stats
+		if st.Received > st.Sent {
+			return fmt.Sprintf("TTL %d: received %d > sent %d", h.TTL, st.Received, st.Sent)
+		}
+		if st.Sent > 0 {
+			want := (1 - float64(st.Received)/float64(st.Sent)) * 100
+			if math.Abs(want-st.Loss) > 0.01 {
+				return fmt.Sprintf("TTL %d: loss %.2f%%, expected %.2f%%", h.TTL, st.Loss, want)
+			}
+		}
+		if st.Received > 0 && (st.BestMs > st.AvgMs || st.AvgMs > st.WorstMs) {
+			return fmt.Sprintf("TTL %d: best/avg/worst %d/%d/%d ms out of order", h.TTL, st.BestMs, st.AvgMs, st.WorstMs)
+		}
+	}
+	return ""
+}
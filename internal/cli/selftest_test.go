@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestCheckSnapshotStats(t *testing.T) {
+	ok := &mtr.Snapshot{Hops: []mtr.SnapshotHop{
+		{TTL: 1, Stats: mtr.SnapshotHopSta{Sent: 4, Received: 3, Loss: 25, BestMs: 1, AvgMs: 2, WorstMs: 4}},
+		{TTL: 2, Stats: mtr.SnapshotHopSta{Sent: 4, Loss: 100}},
+	}}
+	if msg := checkSnapshotStats(ok); msg != "" {
+		t.Fatalf("unexpected failure: %s", msg)
+	}
+
+	for _, st := range []mtr.SnapshotHopSta{
+		{Sent: 2, Received: 3},
+		{Sent: 4, Received: 3, Loss: 0},
+		{Sent: 1, Received: 1, BestMs: 5, AvgMs: 2, WorstMs: 4},
+	} {
+		s := &mtr.Snapshot{Hops: []mtr.SnapshotHop{{TTL: 1, Stats: st}}}
+		if msg := checkSnapshotStats(s); msg == "" {
+			t.Fatalf("expected inconsistency for %+v", st)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// outputSink 为一种输出方式；多个 sink 可同时启用（包括与 TUI 并存），均由 Controller 事件驱动。
+type outputSink interface {
+	// Event 在探测过程中接收事件，调用方保证串行。
+	Event(e mtr.Event)
+	// Finish 在探测结束后以最终快照调用一次。
+	Finish(s *mtr.Snapshot) error
+}
+
+// outputSinks 为 --output 支持的输出方式，键为 kind。
+var outputSinks = map[string]func(w io.Writer, fields []string) outputSink{
+	"text":  func(w io.Writer, _ []string) outputSink { return &textSink{w: w} },
+	"json":  func(w io.Writer, fields []string) outputSink { return &jsonSink{w: w, fields: fields} },
+	"jsonl": func(w io.Writer, _ []string) outputSink { return &jsonlSink{enc: json.NewEncoder(w)} },
+}
+
+// textSink 在结束时输出文本报告（与默认的非 TUI 输出相同）。
+type textSink struct{ w io.Writer }
+
+func (t *textSink) Event(mtr.Event) {}
+
+func (t *textSink) Finish(s *mtr.Snapshot) error { return renderText(t.w, s) }
+
+// jsonSink 在结束时输出完整快照（与 --json 相同，遵循 --fields）。
+type jsonSink struct {
+	w      io.Writer
+	fields []string
+}
+
+func (j *jsonSink) Event(mtr.Event) {}
+
+func (j *jsonSink) Finish(s *mtr.Snapshot) error { return writeSnapshotJSON(j.w, s, j.fields) }
+
+// jsonlSink 将事件逐条写为 JSONL，便于边探测边被其他程序消费。
+type jsonlSink struct {
+	enc *json.Encoder
+	err error
+}
+
+// jsonlEvent 为 JSONL 事件流中的一行。
+type jsonlEvent struct {
+	Type         string                  `json:"type"`
+	Time         time.Time               `json:"time"`
+	TTL          int                     `json:"ttl,omitempty"`
+	Round        int                     `json:"round,omitempty"`
+	Message      string                  `json:"message,omitempty"`
+	Error        string                  `json:"error,omitempty"`
+	Hop          *mtr.SnapshotHop        `json:"hop,omitempty"`
+	Reachability *mtr.ReachabilityChange `json:"reachability,omitempty"`
+}
+
+func (j *jsonlSink) Event(e mtr.Event) {
+	if j.err != nil {
+		return
+	}
+	line := jsonlEvent{
+		Type:         e.Type.String(),
+		Time:         time.Now(),
+		TTL:          e.TTL,
+		Round:        e.Round,
+		Message:      e.Message,
+		Hop:          e.Hop,
+		Reachability: e.Reachability,
+	}
+	if e.Err != nil {
+		line.Error = e.Err.Error()
+	}
+	j.err = j.enc.Encode(line)
+}
+
+func (j *jsonlSink) Finish(*mtr.Snapshot) error { return j.err }
+
+// sinkSet 为同时启用的一组 sink，负责串行分发事件与关闭输出文件。
+type sinkSet struct {
+	mu      sync.Mutex
+	sinks   []outputSink
+	closers []io.Closer
+}
+
+// openSinks 按 --output 的 kind[:path] 列表创建 sink；path 省略或为 "-" 时写到 stdout，
+// jsonl 追加写入文件，其余覆盖写入；path 以 .gz 结尾时以 gzip 压缩写入。
+func openSinks(specs []string, fields []string) (*sinkSet, error) {
+	if err := validateOutputs(specs); err != nil {
+		return nil, err
+	}
+	set := &sinkSet{}
+	for _, spec := range specs {
+		kind, path, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		kind = strings.ToLower(kind)
+		factory := outputSinks[kind]
+		var w io.Writer = os.Stdout
+		if path != "" && path != "-" {
+			flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if kind == "jsonl" {
+				flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			f, err := os.OpenFile(path, flag, 0o644)
+			if err != nil {
+				set.Close()
+				return nil, err
+			}
+			w = f
+			if strings.HasSuffix(strings.ToLower(path), ".gz") {
+				// 追加写入时每次运行形成一个新的 gzip member，多 member 文件可被 gzip -d/zcat 直接读取
+				gz := gzip.NewWriter(f)
+				set.closers = append(set.closers, gz)
+				w = &flushWriter{gz: gz}
+			}
+			set.closers = append(set.closers, f)
+		}
+		set.sinks = append(set.sinks, factory(w, fields))
+	}
+	return set, nil
+}
+
+// flushWriter 在每次写入后刷新 gzip 缓冲，使 JSONL 事件逐条落盘，中途退出时已写出的记录仍可解压读取。
+type flushWriter struct{ gz *gzip.Writer }
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.gz.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.gz.Flush()
+}
+
+// validateOutputs 检查 --output 的 kind 是否受支持，便于在开始探测前报错。
+func validateOutputs(specs []string) error {
+	for _, spec := range specs {
+		kind, _, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if _, ok := outputSinks[strings.ToLower(kind)]; !ok {
+			return errors.New(i18n.Tf("err.outputInvalid", map[string]interface{}{"Value": spec, "Kinds": strings.Join(outputKinds(), ", ")}))
+		}
+	}
+	return nil
+}
+
+// hasJSONOutput 报告 --output 中是否包含 json 输出（--fields 需要 JSON 输出）。
+func hasJSONOutput(specs []string) bool {
+	for _, spec := range specs {
+		kind, _, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if strings.EqualFold(kind, "json") {
+			return true
+		}
+	}
+	return false
+}
+
+// outputsUseStdout 报告 --output 中是否有写到 stdout 的 sink；此时不启用 TUI，避免输出混入全屏画面。
+func outputsUseStdout(specs []string) bool {
+	for _, spec := range specs {
+		_, path, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if path == "" || path == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+func outputKinds() []string {
+	kinds := make([]string, 0, len(outputSinks))
+	for k := range outputSinks {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// add 追加一个 sink（如未指定 --output 时的默认输出）。
+func (s *sinkSet) add(sink outputSink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// observe 作为 Controller 的事件观察者；事件可能来自多个协程，这里串行分发。
+func (s *sinkSet) observe(e mtr.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sink := range s.sinks {
+		sink.Event(e)
+	}
+}
+
+// finish 以最终快照结束所有 sink，返回第一个错误。
+func (s *sinkSet) finish(snap *mtr.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first error
+	for _, sink := range s.sinks {
+		if err := sink.Finish(snap); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *sinkSet) Close() error {
+	var first error
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// writeSnapshotJSON 输出缩进的快照 JSON；fields 非空时只保留指定的 hop 字段。
+func writeSnapshotJSON(w io.Writer, s *mtr.Snapshot, fields []string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(fields) > 0 {
+		filtered, err := filterHopFields(s, fields)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(filtered)
+	}
+	return enc.Encode(s)
+}
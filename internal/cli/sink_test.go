@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestOpenSinksFanOut(t *testing.T) {
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.jsonl")
+	snap := filepath.Join(dir, "snap.json")
+	set, err := openSinks([]string{"jsonl:" + events, "JSON:" + snap}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text bytes.Buffer
+	set.add(&textSink{w: &text})
+
+	set.observe(mtr.Event{Type: mtr.EventTypeHopUpdated, TTL: 1, Hop: &mtr.SnapshotHop{TTL: 1, IP: "192.0.2.1"}})
+	set.observe(mtr.Event{Type: mtr.EventTypeError, Err: errors.New("boom")})
+	if err := set.finish(&mtr.Snapshot{Target: "example.com", Hops: []mtr.SnapshotHop{{TTL: 1, IP: "192.0.2.1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 event lines, got %d:\n%s", len(lines), data)
+	}
+	var first, second jsonlEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.Type != "hop" || first.Hop == nil || first.Hop.IP != "192.0.2.1" || second.Type != "error" || second.Error != "boom" {
+		t.Fatalf("unexpected events: %+v %+v", first, second)
+	}
+
+	data, err = os.ReadFile(snap)
+	if err != nil || !strings.Contains(string(data), `"target": "example.com"`) {
+		t.Fatalf("unexpected snapshot output %q err=%v", data, err)
+	}
+	if !strings.Contains(text.String(), "192.0.2.1") {
+		t.Fatalf("text sink missing hop:\n%s", text.String())
+	}
+}
+
+func TestOpenSinksGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl.gz")
+	for i := 0; i < 2; i++ {
+		set, err := openSinks([]string{"jsonl:" + path}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set.observe(mtr.Event{Type: mtr.EventTypeRoundCompleted, Round: i + 1})
+		if err := set.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两次运行追加的 gzip member 依次解压为两行
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[1], `"round":2`) {
+		t.Fatalf("unexpected decompressed events:\n%s", data)
+	}
+}
+
+func TestValidateOutputs(t *testing.T) {
+	if err := validateOutputs([]string{"text", "jsonl:-"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateOutputs([]string{"xml:out.xml"}); err == nil {
+		t.Fatal("expected unknown kind to be rejected")
+	}
+	if !hasJSONOutput([]string{"text", "json:out.json"}) || hasJSONOutput([]string{"jsonl"}) {
+		t.Fatal("unexpected hasJSONOutput result")
+	}
+	if outputsUseStdout([]string{"json:out.json", "jsonl:events.jsonl"}) {
+		t.Fatal("file sinks should not claim stdout")
+	}
+	if !outputsUseStdout([]string{"json:out.json", "jsonl"}) || !outputsUseStdout([]string{"text:-"}) {
+		t.Fatal("stdout sinks not detected")
+	}
+}
+
+func TestOutputsRejectedInMultiRun(t *testing.T) {
+	for _, args := range [][]string{
+		{"--compare-dscp", "ef,cs1", "--output", "json:out.json", "192.0.2.1"},
+		{"--ecmp-flows", "4", "--json-file", "out.json", "192.0.2.1"},
+	} {
+		cmd := NewRootCommand()
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil || err.Error() != i18n.T("err.outputsMultiRun") {
+			t.Fatalf("%v: expected multi-run output error, got %v", args, err)
+		}
+	}
+}
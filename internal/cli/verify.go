@@ -0,0 +1,275 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// 逐跳对比结论。
+const (
+	verifyMatch     = "match"
+	verifyDiffers   = "differs"
+	verifyOnlyOurs  = "mymtr-only"
+	verifyOnlyRef   = "reference-only"
+	verifyNoReplies = "-"
+)
+
+type verifyRow struct {
+	ttl    int
+	ours   string
+	ref    []string
+	result string
+}
+
+func newVerifyCommand() *cobra.Command {
+	var (
+		protocol  string
+		maxHops   int
+		count     int
+		ipVersion int
+		timeout   time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:     "verify <target>",
+		Short:   i18n.T("cmd.verify.short"),
+		Example: i18n.T("cmd.verify.example"),
+		Args:    exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			offline, _ := cmd.Flags().GetBool("offline")
+			cfg := &mtr.Config{
+				Target:    args[0],
+				MaxHops:   maxHops,
+				Count:     count,
+				Timeout:   timeout,
+				Protocol:  mtr.Protocol(protocol),
+				IPVersion: ipVersion,
+				Safety:    safetyLimits(cmd),
+				Offline:   offline,
+			}
+			cfg.ApplyProtocolProfile()
+
+			// 系统 traceroute 不可用时仍执行 mymtr 的追踪，只是不做对比
+			refName, refArgs, refErr := referenceCommand(cfg)
+
+			prober, err := mtr.NewProberWithFallback(cfg)
+			if err != nil {
+				return err
+			}
+			defer prober.Close()
+			warnProbeFallback(cmd.ErrOrStderr(), cfg)
+			controller, err := mtr.NewController(cfg, prober, nil)
+			if err != nil {
+				return err
+			}
+			if err := controller.Run(ctx); err != nil {
+				return err
+			}
+			snapshot := controller.Snapshot()
+			if refErr != nil {
+				return renderVerifyOurs(cmd.OutOrStdout(), snapshot, refErr)
+			}
+
+			// 参考实现使用 mymtr 已解析出的 IP，避免两边解析到不同地址
+			refArgs = append(refArgs, snapshot.TargetIP)
+			out, err := exec.CommandContext(ctx, refName, refArgs...).Output()
+			if err != nil && len(out) == 0 {
+				return renderVerifyOurs(cmd.OutOrStdout(), snapshot, errors.New(i18n.Tf("cli.verify.referenceFailed", map[string]interface{}{"Command": refName, "Error": err.Error()})))
+			}
+
+			rows := compareWithReference(snapshot, parseTraceroute(string(out)))
+			return renderVerify(cmd.OutOrStdout(), snapshot, filepath.Base(refName), rows)
+		},
+	}
+	cmd.Flags().StringVar(&protocol, "protocol", string(mtr.ProtocolICMP), i18n.T("cmd.flag.protocol"))
+	cmd.Flags().IntVar(&maxHops, "max-hops", 30, i18n.T("cmd.flag.maxHops"))
+	cmd.Flags().IntVar(&count, "count", 3, i18n.T("cmd.flag.count"))
+	cmd.Flags().IntVar(&ipVersion, "ip-version", 4, i18n.T("cmd.flag.ipVersion"))
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, i18n.T("cmd.flag.timeout"))
+	return cmd
+}
+
+// referenceCommand 返回与 cfg 协议对应的系统 traceroute 命令（不含目标参数）。
+func referenceCommand(cfg *mtr.Config) (string, []string, error) {
+	hops := strconv.Itoa(cfg.MaxHops)
+	wait := strconv.Itoa(int((cfg.Timeout + time.Second - 1) / time.Second))
+	if runtime.GOOS == "windows" {
+		// tracert 只支持 ICMP
+		if cfg.Protocol != mtr.ProtocolICMP {
+			return "", nil, errors.New(i18n.Tf("cli.verify.protocolUnsupported", map[string]interface{}{"Command": "tracert", "Protocol": cfg.Protocol}))
+		}
+		w := strconv.Itoa(int(cfg.Timeout / time.Millisecond))
+		return lookReference("tracert", "-d", "-h", hops, "-w", w, "-"+strconv.Itoa(cfg.IPVersion))
+	}
+
+	name := "traceroute"
+	args := []string{"-n", "-q", "1", "-m", hops, "-w", wait}
+	if runtime.GOOS == "linux" {
+		args = append(args, "-"+strconv.Itoa(cfg.IPVersion))
+	} else if cfg.IPVersion == 6 {
+		// BSD/macOS 的 IPv6 版本为独立的 traceroute6
+		name = "traceroute6"
+	}
+	switch cfg.Protocol {
+	case mtr.ProtocolICMP:
+		args = append(args, "-I")
+	case mtr.ProtocolUDP:
+	default:
+		return "", nil, errors.New(i18n.Tf("cli.verify.protocolUnsupported", map[string]interface{}{"Command": name, "Protocol": cfg.Protocol}))
+	}
+	return lookReference(name, args...)
+}
+
+func lookReference(name string, args ...string) (string, []string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", nil, errors.New(i18n.Tf("cli.verify.referenceMissing", map[string]interface{}{"Command": name}))
+	}
+	return path, args, nil
+}
+
+// parseTraceroute 从 traceroute/tracert 的输出中提取每个 TTL 的响应地址。
+// 以数字开头的行视为跳点行，其中所有可解析为 IP 的字段都作为该跳的响应地址。
+func parseTraceroute(out string) map[int][]string {
+	hops := make(map[int][]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		ttl, err := strconv.Atoi(fields[0])
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, f := range fields[1:] {
+			f = strings.Trim(f, "()[]")
+			ip := net.ParseIP(f)
+			if ip == nil || seen[ip.String()] {
+				continue
+			}
+			seen[ip.String()] = true
+			hops[ttl] = append(hops[ttl], ip.String())
+		}
+		if _, ok := hops[ttl]; !ok {
+			hops[ttl] = nil
+		}
+	}
+	return hops
+}
+
+// compareWithReference 按 TTL 对齐 mymtr 快照与参考实现的结果。
+// 同一 TTL 两边都有响应但地址不同，多为负载均衡导致，记为 differs 而非错误。
+func compareWithReference(s *mtr.Snapshot, ref map[int][]string) []verifyRow {
+	maxTTL := 0
+	ours := make(map[int]string)
+	for _, hop := range s.Hops {
+		ours[hop.TTL] = hop.IP
+		if hop.TTL > maxTTL {
+			maxTTL = hop.TTL
+		}
+	}
+	for ttl := range ref {
+		if ttl > maxTTL {
+			maxTTL = ttl
+		}
+	}
+
+	rows := make([]verifyRow, 0, maxTTL)
+	for ttl := 1; ttl <= maxTTL; ttl++ {
+		row := verifyRow{ttl: ttl, ours: ours[ttl], ref: ref[ttl]}
+		switch {
+		case row.ours == "" && len(row.ref) == 0:
+			row.result = verifyNoReplies
+		case len(row.ref) == 0:
+			row.result = verifyOnlyOurs
+		case row.ours == "":
+			row.result = verifyOnlyRef
+		default:
+			row.result = verifyDiffers
+			for _, ip := range row.ref {
+				if ip == row.ours {
+					row.result = verifyMatch
+					break
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func renderVerify(out io.Writer, s *mtr.Snapshot, refName string, rows []verifyRow) error {
+	fmt.Fprintln(out, i18n.Tf("cli.verify.header", map[string]interface{}{"Target": s.Target, "IP": s.TargetIP, "Protocol": formatProtocol(s), "Command": refName}))
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TTL\tmymtr\tReference\tResult")
+	mismatches := 0
+	for _, r := range rows {
+		if r.result != verifyMatch && r.result != verifyNoReplies {
+			mismatches++
+		}
+		ref := "*"
+		if len(r.ref) > 0 {
+			ref = strings.Join(r.ref, ",")
+		}
+		ours := r.ours
+		if ours == "" {
+			ours = "*"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.ttl, ours, ref, r.result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	if mismatches == 0 {
+		fmt.Fprintln(out, i18n.T("cli.verify.consistent"))
+	} else {
+		fmt.Fprintln(out, i18n.Tf("cli.verify.discrepancies", map[string]interface{}{"Count": mismatches}))
+	}
+	return nil
+}
+
+// renderVerifyOurs 在系统 traceroute 不可用或运行失败时只输出 mymtr 的追踪结果，并说明无法对比的原因。
+func renderVerifyOurs(out io.Writer, s *mtr.Snapshot, reason error) error {
+	fmt.Fprintln(out, i18n.Tf("cli.verify.headerOurs", map[string]interface{}{"Target": s.Target, "IP": s.TargetIP, "Protocol": formatProtocol(s)}))
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TTL\tmymtr")
+	for _, hop := range s.Hops {
+		ip := hop.IP
+		if ip == "" {
+			ip = "*"
+		}
+		fmt.Fprintf(w, "%d\t%s\n", hop.TTL, ip)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, i18n.Tf("cli.verify.referenceUnavailable", map[string]interface{}{"Reason": reason.Error()}))
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestParseTraceroute(t *testing.T) {
+	linux := `traceroute to 198.51.100.9 (198.51.100.9), 30 hops max, 60 byte packets
+ 1  192.0.2.1  0.412 ms
+ 2  *
+ 3  203.0.113.5  4.120 ms 203.0.113.6  4.300 ms
+ 4  198.51.100.9  9.871 ms
+`
+	want := map[int][]string{
+		1: {"192.0.2.1"},
+		2: nil,
+		3: {"203.0.113.5", "203.0.113.6"},
+		4: {"198.51.100.9"},
+	}
+	if got := parseTraceroute(linux); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected linux parse: %#v", got)
+	}
+
+	tracert := "Tracing route to 198.51.100.9 over a maximum of 30 hops\r\n\r\n" +
+		"  1    <1 ms    <1 ms    <1 ms  192.0.2.1\r\n" +
+		"  2     *        *        *     Request timed out.\r\n" +
+		"  3     9 ms     9 ms     9 ms  [2001:db8::1]\r\n\r\nTrace complete.\r\n"
+	got := parseTraceroute(tracert)
+	if !reflect.DeepEqual(got[1], []string{"192.0.2.1"}) || got[2] != nil || !reflect.DeepEqual(got[3], []string{"2001:db8::1"}) {
+		t.Fatalf("unexpected tracert parse: %#v", got)
+	}
+}
+
+func TestCompareWithReference(t *testing.T) {
+	s := &mtr.Snapshot{Hops: []mtr.SnapshotHop{
+		{TTL: 1, IP: "192.0.2.1"},
+		{TTL: 2, IP: "192.0.2.2"},
+		{TTL: 3},
+		{TTL: 4, IP: "203.0.113.6"},
+		{TTL: 5},
+	}}
+	ref := map[int][]string{
+		1: {"192.0.2.1"},
+		2: nil,
+		3: {"192.0.2.3"},
+		4: {"203.0.113.5", "203.0.113.7"},
+		5: nil,
+	}
+	var results []string
+	for _, r := range compareWithReference(s, ref) {
+		results = append(results, r.result)
+	}
+	want := []string{verifyMatch, verifyOnlyOurs, verifyOnlyRef, verifyDiffers, verifyNoReplies}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("expected %v, got %v", want, results)
+	}
+}
+
+func TestRenderVerifyWithoutReference(t *testing.T) {
+	i18n.SetLanguage("en")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	s := &mtr.Snapshot{Target: "example.com", TargetIP: "192.0.2.9", Protocol: "icmp", Hops: []mtr.SnapshotHop{
+		{TTL: 1, IP: "192.0.2.1"},
+		{TTL: 2},
+	}}
+	var buf bytes.Buffer
+	if err := renderVerifyOurs(&buf, s, errors.New(`reference implementation "traceroute" not found in PATH`)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"192.0.2.1", "2    *", "Reference traceroute unavailable", "not found in PATH"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// Version 为发布版本号，由构建时的 -ldflags "-X .../internal/cli.Version=..." 注入。
+var Version = "dev"
+
+// latestReleaseURL 为查询最新发布的 GitHub API 地址。
+var latestReleaseURL = "https://api.github.com/repos/hyqhyq3/mymtr/releases/latest"
+
+// maxChangelogLines 为 --check 输出的更新说明要点条数。
+const maxChangelogLines = 5
+
+type releaseInfo struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
+}
+
+func newVersionCommand() *cobra.Command {
+	var check bool
+	cmd := &cobra.Command{
+		Use:     "version",
+		Short:   i18n.T("cmd.version.short"),
+		Example: i18n.T("cmd.version.example"),
+		Args:    noArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			current := currentVersion()
+			fmt.Fprintf(out, "mymtr %s (%s %s/%s)\n", current, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+			if !check {
+				return nil
+			}
+			// 只有显式 --check 时才访问网络，--offline 下直接拒绝
+			if offline, _ := cmd.Flags().GetBool("offline"); offline {
+				return errors.New(i18n.T("err.versionCheckOffline"))
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			rel, err := fetchLatestRelease(ctx, latestReleaseURL)
+			if err != nil {
+				return errors.New(i18n.Tf("err.versionCheck", map[string]interface{}{"Error": err.Error()}))
+			}
+			renderVersionCheck(out, current, rel)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, i18n.T("cmd.flag.versionCheck"))
+	return cmd
+}
+
+// currentVersion 返回构建时注入的版本；未注入时使用 go install 记录的模块版本
+// （未打 tag 的提交为伪版本，仍视为 dev 构建）。
+func currentVersion() string {
+	if Version != "dev" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" && !pseudoVersionRe.MatchString(info.Main.Version) {
+		return info.Main.Version
+	}
+	return Version
+}
+
+// pseudoVersionRe 匹配 Go 模块伪版本中的“时间戳-提交哈希”部分，如 v0.0.0-20240102150405-abcdef123456。
+var pseudoVersionRe = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+func fetchLatestRelease(ctx context.Context, url string) (*releaseInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "mymtr/"+currentVersion())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var rel releaseInfo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&rel); err != nil {
+		return nil, err
+	}
+	if rel.TagName == "" {
+		return nil, fmt.Errorf("%s: missing tag_name", url)
+	}
+	return &rel, nil
+}
+
+func renderVersionCheck(w io.Writer, current string, rel *releaseInfo) {
+	newer, comparable := newerVersion(current, rel.TagName)
+	switch {
+	case !comparable:
+		fmt.Fprintln(w, i18n.Tf("cli.versionLatest", map[string]interface{}{"Latest": rel.TagName, "URL": rel.HTMLURL}))
+	case !newer:
+		fmt.Fprintln(w, i18n.Tf("cli.versionUpToDate", map[string]interface{}{"Latest": rel.TagName}))
+		return
+	default:
+		fmt.Fprintln(w, i18n.Tf("cli.versionNewer", map[string]interface{}{"Latest": rel.TagName, "URL": rel.HTMLURL}))
+	}
+	for _, line := range changelogHighlights(rel.Body, maxChangelogLines) {
+		fmt.Fprintln(w, "  "+line)
+	}
+}
+
+// newerVersion 判断 latest 是否比 current 新；任一版本号无法解析（如 dev 构建）时 comparable 为 false。
+func newerVersion(current, latest string) (newer, comparable bool) {
+	c, ok1 := parseVersion(current)
+	l, ok2 := parseVersion(latest)
+	if !ok1 || !ok2 {
+		return false, false
+	}
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i], true
+		}
+	}
+	return false, true
+}
+
+// parseVersion 解析 v1.2.3 形式的版本号，忽略预发布与构建后缀。
+func parseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// changelogHighlights 取发布说明中的前 n 条列表项。
+func changelogHighlights(body string, n int) []string {
+	var out []string
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+			continue
+		}
+		out = append(out, "- "+strings.TrimSpace(line[2:]))
+		if len(out) == n {
+			break
+		}
+	}
+	return out
+}
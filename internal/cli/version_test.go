@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewerVersion(t *testing.T) {
+	cases := []struct {
+		current, latest   string
+		newer, comparable bool
+	}{
+		{"v1.2.3", "v1.3.0", true, true},
+		{"1.2.3", "v1.2.3", false, true},
+		{"v1.10.0", "v1.9.9", false, true},
+		{"v1.2.3-rc1", "v1.2.4", true, true},
+		{"dev", "v1.2.3", false, false},
+	}
+	for _, tc := range cases {
+		newer, comparable := newerVersion(tc.current, tc.latest)
+		if newer != tc.newer || comparable != tc.comparable {
+			t.Fatalf("%s -> %s: newer=%v comparable=%v", tc.current, tc.latest, newer, comparable)
+		}
+	}
+}
+
+func TestVersionCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name":"v9.0.0","html_url":"https://example.com/r/v9.0.0","body":"## Changes\n* Faster probing\n- Fix IPv6 parsing\nnot a bullet"}`))
+	}))
+	defer srv.Close()
+
+	rel, err := fetchLatestRelease(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	renderVersionCheck(&out, "v1.0.0", rel)
+	got := out.String()
+	for _, want := range []string{"v9.0.0", "https://example.com/r/v9.0.0", "- Faster probing", "- Fix IPv6 parsing"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "not a bullet") {
+		t.Fatalf("unexpected non-list line in highlights:\n%s", got)
+	}
+}
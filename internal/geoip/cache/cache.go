@@ -0,0 +1,127 @@
+// Package cache 为在线地理位置接口提供进程内共享的查询缓存：按（IP, 来源）区分条目，
+// 每个条目带有由调用方决定的过期时间，超出容量时先清理过期条目，再按近似 LRU 淘汰。
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Key 标识一条缓存；Source 区分不同的数据源（如 cip.cc 与自建接口），避免结果互相覆盖。
+type Key struct {
+	IP     string
+	Source string
+}
+
+// Stats 为缓存的命中与容量统计。
+type Stats struct {
+	Entries   int    `json:"entries"`
+	MaxSize   int    `json:"max_size"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+type entry[V any] struct {
+	value    V
+	expires  time.Time
+	lastUsed time.Time
+}
+
+// Store 为并发安全的带过期时间缓存。
+type Store[V any] struct {
+	mu      sync.Mutex
+	entries map[Key]entry[V]
+	maxSize int
+
+	hits, misses, evictions uint64
+}
+
+// New 创建最多保存 maxSize 条的缓存（maxSize<=0 时不限制）。
+func New[V any](maxSize int) *Store[V] {
+	return &Store[V]{entries: make(map[Key]entry[V]), maxSize: maxSize}
+}
+
+// Get 返回未过期的缓存值；过期条目会被删除。
+func (s *Store[V]) Get(now time.Time, key Key) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ent, ok := s.entries[key]
+	if ok && now.After(ent.expires) {
+		delete(s.entries, key)
+		ok = false
+	}
+	if !ok {
+		s.misses++
+		var zero V
+		return zero, false
+	}
+	s.hits++
+	ent.lastUsed = now
+	s.entries[key] = ent
+	return ent.value, true
+}
+
+// Set 写入缓存，ttl 由调用方按来源与结果（成功/失败）决定。
+func (s *Store[V]) Set(now time.Time, key Key, value V, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && s.maxSize > 0 && len(s.entries) >= s.maxSize {
+		s.evict(now)
+	}
+	s.entries[key] = entry[V]{value: value, expires: now.Add(ttl), lastUsed: now}
+}
+
+// Stats 返回当前的统计信息。
+func (s *Store[V]) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{
+		Entries:   len(s.entries),
+		MaxSize:   s.maxSize,
+		Hits:      s.hits,
+		Misses:    s.misses,
+		Evictions: s.evictions,
+	}
+}
+
+func (s *Store[V]) evict(now time.Time) {
+	// 先清理过期，再按近似 LRU 删除一批
+	for k, ent := range s.entries {
+		if now.After(ent.expires) {
+			delete(s.entries, k)
+			s.evictions++
+		}
+	}
+	if len(s.entries) < s.maxSize {
+		return
+	}
+
+	type kv struct {
+		k Key
+		t time.Time
+	}
+	items := make([]kv, 0, len(s.entries))
+	for k, ent := range s.entries {
+		items = append(items, kv{k: k, t: ent.lastUsed})
+	}
+	// 删除最老的 10%
+	n := len(items) / 10
+	if n < 1 {
+		n = 1
+	}
+	// 选择 n 个最小 lastUsed
+	for i := 0; i < n; i++ {
+		min := i
+		for j := i + 1; j < len(items); j++ {
+			if items[j].t.Before(items[min].t) {
+				min = j
+			}
+		}
+		items[i], items[min] = items[min], items[i]
+		delete(s.entries, items[i].k)
+		s.evictions++
+	}
+}
@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreExpiryAndSources(t *testing.T) {
+	s := New[string](10)
+	now := time.Now()
+	a := Key{IP: "192.0.2.1", Source: "cip.cc"}
+	b := Key{IP: "192.0.2.1", Source: "https://geo.example"}
+
+	s.Set(now, a, "cn", time.Minute)
+	s.Set(now, b, "us", time.Second)
+
+	if v, ok := s.Get(now, a); !ok || v != "cn" {
+		t.Fatalf("unexpected value for a: %q %v", v, ok)
+	}
+	if v, ok := s.Get(now, b); !ok || v != "us" {
+		t.Fatalf("sources must not share entries: %q %v", v, ok)
+	}
+	if _, ok := s.Get(now.Add(2*time.Second), b); ok {
+		t.Fatal("expected b to expire")
+	}
+
+	st := s.Stats()
+	if st.Entries != 1 || st.Hits != 2 || st.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := New[int](3)
+	now := time.Now()
+	for i, ip := range []string{"a", "b", "c"} {
+		s.Set(now.Add(time.Duration(i)*time.Second), Key{IP: ip}, i, time.Hour)
+	}
+	// 访问 a 使 b 成为最久未使用
+	s.Get(now.Add(5*time.Second), Key{IP: "a"})
+	s.Set(now.Add(6*time.Second), Key{IP: "d"}, 3, time.Hour)
+
+	if _, ok := s.Get(now.Add(7*time.Second), Key{IP: "b"}); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, ip := range []string{"a", "c", "d"} {
+		if _, ok := s.Get(now.Add(7*time.Second), Key{IP: ip}); !ok {
+			t.Fatalf("expected %s to be kept", ip)
+		}
+	}
+	if st := s.Stats(); st.Evictions != 1 || st.Entries != 3 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
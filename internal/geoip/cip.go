@@ -9,139 +9,139 @@ import (
 	"net"
 	"net/http"
 	"strings"
-	"sync"
 	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip/cache"
+)
+
+const (
+	cipDefaultBaseURL = "https://cip.cc"
+	cipDefaultQPS     = 2
+	cipDefaultBurst   = 4
+	cipDefaultMaxWait = 500 * time.Millisecond
 )
 
+// cipLimiter 为进程内所有使用默认 cip.cc 接口的 CIPResolver 共享的全局限速器，避免新跳突增时被 cip.cc 封禁；
+// 指向自建接口（--geoip-http-url）的 resolver 不受此限速，由接口方自行控制。
+var cipLimiter = newRateLimiter(cipDefaultQPS, cipDefaultBurst, cipDefaultMaxWait)
+
+// sharedCache 为进程内所有在线 resolver 共享的查询缓存，按（IP, 来源）区分条目。
+var sharedCache = cache.New[*GeoLocation](5000)
+
 type CIPResolver struct {
 	baseURL string
 	client  *http.Client
+	headers map[string]string
+	limiter *rateLimiter
+	flight  flightGroup
+
+	// ctx 在 Shutdown 时取消，用于中止进行中的请求
+	ctx      context.Context
+	cancel   context.CancelFunc
+	inflight inflightGroup
 
-	mu    sync.Mutex
-	cache map[string]cacheEntry
+	cache *cache.Store[*GeoLocation]
+	// cacheTag 区分同一接口地址下不同请求头/证书设置的 resolver（见 HTTPOptions.cacheTag）
+	cacheTag string
 
 	ttlSuccess time.Duration
 	ttlFailure time.Duration
-	maxSize    int
-}
 
-type cacheEntry struct {
-	loc      *GeoLocation
-	expires  time.Time
-	lastUsed time.Time
+	counters resolveCounters
 }
 
 func NewCIPResolver() *CIPResolver {
+	r, _ := NewCIPResolverWithOptions(HTTPOptions{})
+	return r
+}
+
+// NewCIPResolverWithOptions 使用自定义 HTTP 设置（接口地址、请求头、证书校验、超时）创建 CIPResolver。
+func NewCIPResolverWithOptions(opts HTTPOptions) (*CIPResolver, error) {
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = cipDefaultBaseURL
+	}
+	var limiter *rateLimiter
+	if baseURL == cipDefaultBaseURL {
+		limiter = cipLimiter
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &CIPResolver{
-		baseURL: "https://cip.cc",
-		client: &http.Client{
-			Timeout: 2 * time.Second,
-		},
-		cache:      make(map[string]cacheEntry, 2048),
+		ctx:        ctx,
+		cancel:     cancel,
+		baseURL:    baseURL,
+		client:     client,
+		headers:    opts.Headers,
+		limiter:    limiter,
+		cache:      sharedCache,
+		cacheTag:   opts.cacheTag(),
 		ttlSuccess: 24 * time.Hour,
 		ttlFailure: 5 * time.Minute,
-		maxSize:    5000,
-	}
+	}, nil
 }
 
 func (r *CIPResolver) Source() string { return "cip.cc" }
 
-func (r *CIPResolver) Close() error { return nil }
+func (r *CIPResolver) Close() error { return r.Shutdown(context.Background()) }
+
+// Shutdown 拒绝新的查询、中止进行中的请求，并等待其返回（最多到 ctx 结束）。
+func (r *CIPResolver) Shutdown(ctx context.Context) error {
+	done := r.inflight.close()
+	r.cancel()
+	return waitContext(ctx, done)
+}
 
 func (r *CIPResolver) Resolve(ip net.IP) *GeoLocation {
-	if ip == nil {
+	if ip == nil || !r.inflight.begin() {
 		return nil
 	}
-	key := ip.String()
+	defer r.inflight.end()
+	key := cache.Key{IP: ip.String(), Source: r.baseURL + "#" + r.cacheTag}
 
-	now := time.Now()
-	if loc, ok := r.getCached(now, key); ok {
+	if loc, ok := r.cache.Get(time.Now(), key); ok {
+		r.counters.hits.Add(1)
 		return loc
 	}
 
-	loc := r.fetchAndParse(context.Background(), key)
-	r.setCached(now, key, loc)
-	return loc
-}
-
-func (r *CIPResolver) getCached(now time.Time, key string) (*GeoLocation, bool) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	ent, ok := r.cache[key]
-	if !ok {
-		return nil, false
-	}
-	if now.After(ent.expires) {
-		delete(r.cache, key)
-		return nil, false
-	}
-	ent.lastUsed = now
-	r.cache[key] = ent
-	return ent.loc, true
-}
-
-func (r *CIPResolver) setCached(now time.Time, key string, loc *GeoLocation) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	if len(r.cache) >= r.maxSize {
-		r.evict(now)
-	}
-	ttl := r.ttlSuccess
-	if loc == nil {
-		ttl = r.ttlFailure
-	}
-	r.cache[key] = cacheEntry{
-		loc:      loc,
-		expires:  now.Add(ttl),
-		lastUsed: now,
-	}
-}
-
-func (r *CIPResolver) evict(now time.Time) {
-	// 先清理过期，再按近似 LRU 删除一批
-	for k, ent := range r.cache {
-		if now.After(ent.expires) {
-			delete(r.cache, k)
+	// 同一 IP 的并发查询只发起一次 HTTP 请求
+	loc, _ := r.flight.Do(key.IP, func() (*GeoLocation, bool) {
+		now := time.Now()
+		if loc, ok := r.cache.Get(now, key); ok {
+			r.counters.hits.Add(1)
+			return loc, true
 		}
-	}
-	if len(r.cache) < r.maxSize {
-		return
-	}
-
-	type kv struct {
-		k string
-		t time.Time
-	}
-	items := make([]kv, 0, len(r.cache))
-	for k, ent := range r.cache {
-		items = append(items, kv{k: k, t: ent.lastUsed})
-	}
-	// 删除最老的 10%
-	n := len(items) / 10
-	if n < 1 {
-		n = 1
-	}
-	// 选择 n 个最小 lastUsed
-	for i := 0; i < n; i++ {
-		min := i
-		for j := i + 1; j < len(items); j++ {
-			if items[j].t.Before(items[min].t) {
-				min = j
-			}
+		if !r.limiter.Acquire() {
+			// 超出限速：不写缓存，下一轮再尝试
+			return nil, false
 		}
-		items[i], items[min] = items[min], items[i]
-		delete(r.cache, items[i].k)
-	}
+		r.counters.calls.Add(1)
+		loc := r.fetchAndParse(r.ctx, key.IP)
+		if r.ctx.Err() != nil {
+			// 因关闭而中止的请求不代表查询失败，不写缓存
+			return nil, false
+		}
+		ttl := r.ttlSuccess
+		if loc == nil {
+			ttl = r.ttlFailure
+		}
+		r.cache.Set(now, key, loc, ttl)
+		return loc, true
+	})
+	return loc
 }
 
+func (r *CIPResolver) backendCounts() (uint64, uint64) { return r.counters.backendCounts() }
+
 func (r *CIPResolver) fetchAndParse(ctx context.Context, ip string) *GeoLocation {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", r.baseURL, ip), nil)
 	if err != nil {
 		return nil
 	}
-	req.Header.Set("User-Agent", "mymtr/1.0")
+	setRequestHeaders(req, r.headers)
 	resp, err := r.client.Do(req)
 	if err != nil {
 		return nil
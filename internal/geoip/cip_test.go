@@ -1,6 +1,15 @@
 package geoip
 
-import "testing"
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestParseCIP_US(t *testing.T) {
 	in := "IP\t: 8.8.8.8\n地址\t: 美国 加利福尼亚州 圣克拉拉\n\n数据二\t: 美国加利福尼亚州圣克拉拉 | 谷歌公司DNS服务器\n"
@@ -40,3 +49,61 @@ func TestParseCIP_CN(t *testing.T) {
 		t.Fatalf("unexpected string: %q", got)
 	}
 }
+
+func TestCIPResolver_SingleFlight(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		fmt.Fprint(w, "IP\t: 1.1.1.1\n地址\t: 澳大利亚\n")
+	}))
+	defer srv.Close()
+
+	r := NewCIPResolver()
+	r.baseURL = srv.URL
+	r.limiter = nil
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if loc := r.Resolve(net.ParseIP("1.1.1.1")); loc == nil || loc.Country != "澳大利亚" {
+				t.Errorf("unexpected location: %#v", loc)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected 1 backend request, got=%d", got)
+	}
+}
+
+func TestRateLimiter_MaxWait(t *testing.T) {
+	l := newRateLimiter(1, 1, 100*time.Millisecond)
+	l.sleep = func(time.Duration) {}
+
+	if !l.Acquire() {
+		t.Fatalf("expected first acquire to succeed")
+	}
+	if l.Acquire() {
+		t.Fatalf("expected second acquire to exceed max wait")
+	}
+}
+
+func TestCIPLimiterOnlyForDefaultEndpoint(t *testing.T) {
+	if r := NewCIPResolver(); r.limiter != cipLimiter {
+		t.Fatal("expected the default cip.cc endpoint to use the shared limiter")
+	}
+	r, err := NewCIPResolverWithOptions(HTTPOptions{BaseURL: "https://geo.internal.example/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.limiter != nil {
+		t.Fatal("expected a self-hosted endpoint not to be throttled by the cip.cc limiter")
+	}
+}
@@ -0,0 +1,78 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// IP2RegionDBInfo 描述本地 ip2region 数据库文件（geoip status）。
+type IP2RegionDBInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Family 为数据库地址族（IPv4/IPv6）
+	Family string
+}
+
+// HumanSize 返回便于阅读的文件大小。
+func (i IP2RegionDBInfo) HumanSize() string { return humanBytes(i.Size) }
+
+// StatIP2RegionDB 读取并校验本地数据库；文件缺失或校验失败时返回与 CheckIP2RegionDB 相同的错误。
+func StatIP2RegionDB(dbPath string) (IP2RegionDBInfo, error) {
+	info := IP2RegionDBInfo{Path: dbPath}
+	if err := CheckIP2RegionDB(dbPath); err != nil {
+		return info, err
+	}
+	st, err := os.Stat(dbPath)
+	if err != nil {
+		return info, err
+	}
+	version, err := detectIPVersion(dbPath)
+	if err != nil {
+		return info, err
+	}
+	info.Size = st.Size()
+	info.ModTime = st.ModTime()
+	info.Family = version.Name
+	return info, nil
+}
+
+// DownloadIP2RegionDB 下载数据库到 dbPath（geoip download）。新文件先写入旁路位置并通过校验后才替换，
+// 下载中断或内容损坏时保留原有数据库。
+func DownloadIP2RegionDB(ctx context.Context, dbPath, customURL string, ipVersion int) (IP2RegionDBInfo, error) {
+	if dbPath == "" {
+		return IP2RegionDBInfo{Path: dbPath}, errors.New(i18n.T("geoip.ip2region.pathEmpty"))
+	}
+	staging := dbPath + ".new"
+	defer os.Remove(staging)
+	if err := downloadIP2RegionDB(ctx, staging, customURL, ipVersion); err != nil {
+		return IP2RegionDBInfo{Path: dbPath}, err
+	}
+	if _, err := detectIPVersion(staging); err != nil {
+		return IP2RegionDBInfo{Path: dbPath}, err
+	}
+	if err := os.Rename(staging, dbPath); err != nil {
+		return IP2RegionDBInfo{Path: dbPath}, err
+	}
+	return StatIP2RegionDB(dbPath)
+}
+
+// RemoveIP2RegionDB 删除数据库及下载残留（geoip db remove），返回实际删除的文件。
+func RemoveIP2RegionDB(dbPath string) ([]string, error) {
+	var removed []string
+	for _, p := range []string{dbPath, dbPath + ".new", dbPath + ".download", dbPath + ".new.download"} {
+		err := os.Remove(p)
+		if err == nil {
+			removed = append(removed, p)
+			continue
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
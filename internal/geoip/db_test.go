@@ -0,0 +1,57 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadIP2RegionDBKeepsExistingOnInvalidPayload(t *testing.T) {
+	origWriter := progressOutput
+	progressOutput = io.Discard
+	t.Cleanup(func() { progressOutput = origWriter })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "not an xdb file")
+	}))
+	t.Cleanup(srv.Close)
+
+	target := filepath.Join(t.TempDir(), "ip2region.xdb")
+	if err := os.WriteFile(target, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DownloadIP2RegionDB(context.Background(), target, srv.URL, 0); err == nil {
+		t.Fatal("expected invalid database to be rejected")
+	}
+	got, err := os.ReadFile(target)
+	if err != nil || string(got) != "previous" {
+		t.Fatalf("existing database should be kept, got %q err=%v", got, err)
+	}
+	if _, err := os.Stat(target + ".new"); !os.IsNotExist(err) {
+		t.Fatalf("staging file should be removed, stat err=%v", err)
+	}
+}
+
+func TestRemoveIP2RegionDB(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "ip2region.xdb")
+	for _, p := range []string{target, target + ".download"} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, err := RemoveIP2RegionDB(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("unexpected removed files: %v", removed)
+	}
+	if removed, err := RemoveIP2RegionDB(target); err != nil || len(removed) != 0 {
+		t.Fatalf("second clear should be a no-op, got %v err=%v", removed, err)
+	}
+}
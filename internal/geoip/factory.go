@@ -1,25 +1,43 @@
 package geoip
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
 )
 
 type Options struct {
 	IP2RegionDB  string
 	IP2RegionURL string
 	Download     DownloadOption
+	// HTTP 用于在线接口（cip）。
+	HTTP HTTPOptions
+	// Offline 为 true 时禁止任何地理位置相关的网络访问：拒绝在线数据源，本地数据库缺失时也不下载。
+	Offline bool
 }
 
 func NewResolver(source string, opts Options) (GeoResolver, error) {
+	return NewResolverContext(context.Background(), source, opts)
+}
+
+// NewResolverContext 与 NewResolver 相同，ctx 用于中止创建过程中的数据库下载。
+func NewResolverContext(ctx context.Context, source string, opts Options) (GeoResolver, error) {
 	switch strings.ToLower(strings.TrimSpace(source)) {
 	case "", "none", "noop", "off":
 		return NewNoopResolver(), nil
 	case "cip", "cip.cc":
-		return NewCIPResolver(), nil
+		if opts.Offline {
+			return nil, errors.New(i18n.Tf("geoip.offlineSource", map[string]interface{}{"Source": source}))
+		}
+		return NewCIPResolverWithOptions(opts.HTTP)
 	case "ip2region":
-		return NewIP2RegionResolver(opts.IP2RegionDB, opts.IP2RegionURL, opts.Download)
+		if opts.Offline {
+			opts.Download.Offline = true
+		}
+		return NewIP2RegionResolverContext(ctx, opts.IP2RegionDB, opts.IP2RegionURL, opts.Download)
 	default:
-		return nil, fmt.Errorf("未知 geoip source：%s", source)
+		return nil, errors.New(i18n.Tf("geoip.unknownSource", map[string]interface{}{"Source": source}))
 	}
 }
@@ -0,0 +1,45 @@
+package geoip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// CheckIP2RegionDB 检查 ip2region 数据库是否存在且可通过校验。
+func CheckIP2RegionDB(dbPath string) error {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New(i18n.Tf("geoip.ip2region.notExist", map[string]interface{}{"Path": dbPath}))
+		}
+		return err
+	}
+	if info.IsDir() {
+		return errors.New(i18n.Tf("geoip.ip2region.pathIsDir", map[string]interface{}{"Path": dbPath}))
+	}
+	_, err = detectIPVersion(dbPath)
+	return err
+}
+
+// Ping 检查在线接口是否可达（不写入缓存、不占用限速配额）。
+func (r *CIPResolver) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, r.baseURL, nil)
+	if err != nil {
+		return err
+	}
+	setRequestHeaders(req, r.headers)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package geoip
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+const defaultHTTPTimeout = 2 * time.Second
+
+// HTTPOptions 为在线地理位置接口的 HTTP 设置，用于自建接口的鉴权与证书校验。
+type HTTPOptions struct {
+	// BaseURL 覆盖默认接口地址（需兼容对应后端的响应格式）；自建接口不受 cip.cc 的全局限速约束。
+	BaseURL string
+	// Headers 附加到每个请求上（如 Authorization）。
+	Headers map[string]string
+	// CAFile 为 PEM 格式的根证书文件，非空时替代系统根证书。
+	CAFile string
+	// PinSHA256 为允许的服务端证书公钥（SPKI）SHA-256 指纹，base64 编码，可带 "sha256/" 前缀。
+	// 非空时证书链中至少一张证书需命中其中之一。
+	PinSHA256 []string
+	// Timeout 为单次请求超时，<=0 时使用默认值。
+	Timeout time.Duration
+}
+
+// ParseHeader 解析 "Name: value" 形式的请求头。
+func ParseHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", "", errors.New(i18n.Tf("geoip.http.headerInvalid", map[string]interface{}{"Header": s}))
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+func newHTTPClient(opts HTTPOptions) (*http.Client, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	if opts.CAFile == "" && len(opts.PinSHA256) == 0 {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, errors.New(i18n.Tf("geoip.http.caReadFailed", map[string]interface{}{"Path": opts.CAFile, "Error": err.Error()}))
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New(i18n.Tf("geoip.http.caInvalid", map[string]interface{}{"Path": opts.CAFile}))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(opts.PinSHA256) > 0 {
+		pins := make(map[string]bool, len(opts.PinSHA256))
+		for _, p := range opts.PinSHA256 {
+			pins[strings.TrimPrefix(strings.TrimSpace(p), "sha256/")] = true
+		}
+		// 在常规证书链校验之后额外比对公钥指纹
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				if pins[spkiFingerprint(cert)] {
+					return nil
+				}
+			}
+			return errors.New(i18n.T("geoip.http.pinMismatch"))
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return client, nil
+}
+
+// spkiFingerprint 返回证书公钥（SubjectPublicKeyInfo）的 SHA-256 base64 指纹，与 HPKP/curl --pinnedpubkey 的格式一致。
+func spkiFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// cacheTag 返回请求头与证书校验设置的摘要，与接口地址一起作为缓存来源：
+// 接口地址相同但这些设置不同的 resolver 可能得到不同结果（或本应拒绝该服务端），因此不共享缓存条目。
+func (o HTTPOptions) cacheTag() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "ca=%s\n", o.CAFile)
+	pins := append([]string(nil), o.PinSHA256...)
+	sort.Strings(pins)
+	for _, p := range pins {
+		fmt.Fprintf(h, "pin=%s\n", p)
+	}
+	names := make([]string, 0, len(o.Headers))
+	for k := range o.Headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(h, "header=%s:%s\n", k, o.Headers[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func setRequestHeaders(req *http.Request, headers map[string]string) {
+	req.Header.Set("User-Agent", "mymtr/1.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+}
@@ -0,0 +1,63 @@
+package geoip
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCIPResolver_HTTPOptions(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "IP\t: 1.1.1.1\n地址\t: 澳大利亚\n")
+	}))
+	defer srv.Close()
+
+	cert := srv.Certificate()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	name, value, err := ParseHeader("Authorization: Bearer secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := HTTPOptions{BaseURL: srv.URL, CAFile: caFile, Headers: map[string]string{name: value}}
+
+	for _, tc := range []struct {
+		name string
+		pins []string
+		ok   bool
+	}{
+		{name: "ca", ok: true},
+		{name: "pinned", pins: []string{"sha256/" + spkiFingerprint(cert)}, ok: true},
+		{name: "pin mismatch", pins: []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := base
+			opts.PinSHA256 = tc.pins
+			r, err := NewCIPResolverWithOptions(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.limiter = nil
+			loc := r.Resolve(net.ParseIP("1.1.1.1"))
+			if (loc != nil) != tc.ok {
+				t.Fatalf("unexpected result: %#v", loc)
+			}
+		})
+	}
+
+	if _, _, err := ParseHeader("no-colon"); err == nil {
+		t.Fatal("expected invalid header error")
+	}
+}
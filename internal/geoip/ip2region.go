@@ -47,6 +47,33 @@ type DownloadPrompt func(message string) (bool, error)
 type DownloadOption struct {
 	Answer DownloadAnswer
 	Prompt DownloadPrompt
+	// IPVersion 强制下载时使用的地址族（4/6），0 表示由系统决定。
+	IPVersion int
+	// Offline 为 true 时数据库缺失直接报错，不下载也不询问。
+	Offline bool
+}
+
+// newDownloadClient 返回数据库下载使用的 HTTP 客户端；指定 ipVersion 时拨号只走对应地址族，
+// 避免某一地址族故障的网络中请求一直挂起。
+func newDownloadClient(ipVersion int) (*http.Client, error) {
+	var network string
+	switch ipVersion {
+	case 0:
+		return ip2RegionHTTPClient, nil
+	case 4:
+		network = "tcp4"
+	case 6:
+		network = "tcp6"
+	default:
+		return nil, errors.New(i18n.Tf("geoip.download.ipVersionInvalid", map[string]interface{}{"Version": ipVersion}))
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Transport: transport}, nil
 }
 
 // DefaultIP2RegionDBPath 返回用户缓存目录下的默认 ip2region.xdb 存放路径；若无法获取缓存目录，退回到系统临时目录。
@@ -60,7 +87,10 @@ func DefaultIP2RegionDBPath() string {
 }
 
 type IP2RegionResolver struct {
-	dbPath   string
+	dbPath string
+
+	// searcher 基于文件句柄顺序读，不能并发使用
+	mu       sync.Mutex
 	searcher *xdb.Searcher
 }
 
@@ -82,12 +112,17 @@ func decideIP2RegionDownload(opt DownloadOption, dbPath string) (bool, error) {
 }
 
 func NewIP2RegionResolver(dbPath string, customURL string, downloadOpt DownloadOption) (*IP2RegionResolver, error) {
+	return NewIP2RegionResolverContext(context.Background(), dbPath, customURL, downloadOpt)
+}
+
+// NewIP2RegionResolverContext 与 NewIP2RegionResolver 相同，但需要下载数据库时 ctx 结束会中止下载。
+func NewIP2RegionResolverContext(ctx context.Context, dbPath string, customURL string, downloadOpt DownloadOption) (*IP2RegionResolver, error) {
 	dbPath = strings.TrimSpace(dbPath)
 	if dbPath == "" {
 		return nil, errors.New(i18n.T("geoip.ip2region.pathEmpty"))
 	}
 
-	if err := ensureIP2RegionDB(dbPath, customURL, downloadOpt); err != nil {
+	if err := ensureIP2RegionDB(ctx, dbPath, customURL, downloadOpt); err != nil {
 		return nil, err
 	}
 
@@ -106,6 +141,8 @@ func NewIP2RegionResolver(dbPath string, customURL string, downloadOpt DownloadO
 func (r *IP2RegionResolver) Source() string { return "ip2region" }
 
 func (r *IP2RegionResolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.searcher == nil {
 		return nil
 	}
@@ -114,8 +151,18 @@ func (r *IP2RegionResolver) Close() error {
 	return nil
 }
 
+// Shutdown 关闭数据库文件；查询期间持有锁，最多等待到 ctx 结束。
+func (r *IP2RegionResolver) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Close()
+	}()
+	return waitContext(ctx, done)
+}
+
 func (r *IP2RegionResolver) Resolve(ip net.IP) *GeoLocation {
-	if ip == nil || r.searcher == nil {
+	if ip == nil {
 		return nil
 	}
 	// ip2region v2 仅支持 IPv4
@@ -123,7 +170,13 @@ func (r *IP2RegionResolver) Resolve(ip net.IP) *GeoLocation {
 		return nil
 	}
 
+	r.mu.Lock()
+	if r.searcher == nil {
+		r.mu.Unlock()
+		return nil
+	}
 	region, err := r.searcher.SearchByStr(ip.String())
+	r.mu.Unlock()
 	if err != nil || strings.TrimSpace(region) == "" {
 		return nil
 	}
@@ -137,7 +190,7 @@ func (r *IP2RegionResolver) Resolve(ip net.IP) *GeoLocation {
 	return loc
 }
 
-func ensureIP2RegionDB(dbPath string, customURL string, opt DownloadOption) error {
+func ensureIP2RegionDB(ctx context.Context, dbPath string, customURL string, opt DownloadOption) error {
 	info, err := os.Stat(dbPath)
 	if err == nil {
 		if info.IsDir() {
@@ -148,6 +201,9 @@ func ensureIP2RegionDB(dbPath string, customURL string, opt DownloadOption) erro
 	if !errors.Is(err, os.ErrNotExist) {
 		return errors.New(i18n.Tf("geoip.ip2region.unavailable", map[string]interface{}{"Error": err.Error()}))
 	}
+	if opt.Offline {
+		return errors.New(i18n.Tf("geoip.ip2region.offlineMissing", map[string]interface{}{"Path": dbPath}))
+	}
 	allowed, decideErr := decideIP2RegionDownload(opt, dbPath)
 	if decideErr != nil {
 		return decideErr
@@ -155,13 +211,18 @@ func ensureIP2RegionDB(dbPath string, customURL string, opt DownloadOption) erro
 	if !allowed {
 		return errors.New(i18n.T("geoip.ip2region.downloadDeclined"))
 	}
-	if err := downloadIP2RegionDB(dbPath, customURL); err != nil {
+	if err := downloadIP2RegionDB(ctx, dbPath, customURL, opt.IPVersion); err != nil {
 		return errors.New(i18n.Tf("geoip.ip2region.downloadFailed", map[string]interface{}{"Error": err.Error()}))
 	}
 	return nil
 }
 
-func downloadIP2RegionDB(dbPath, customURL string) error {
+func downloadIP2RegionDB(ctx context.Context, dbPath, customURL string, ipVersion int) error {
+	client, err := newDownloadClient(ipVersion)
+	if err != nil {
+		return err
+	}
+
 	dir := filepath.Dir(dbPath)
 	if dir != "" && dir != "." {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -173,17 +234,21 @@ func downloadIP2RegionDB(dbPath, customURL string) error {
 	sources := selectIP2RegionSources(customURL)
 	var errs []error
 
-	baseCtx := context.Background()
 	for _, src := range sources {
 		if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
 			return err
 		}
 
-		err := downloadFromSource(baseCtx, src, tmp, dbPath)
+		err := downloadFromSource(ctx, client, src, tmp, dbPath)
 
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			// 被调用方取消时不再尝试其余镜像
+			os.Remove(tmp)
+			return ctx.Err()
+		}
 		errs = append(errs, fmt.Errorf("%s: %w", src, err))
 	}
 
@@ -204,7 +269,7 @@ func selectIP2RegionSources(customURL string) []string {
 	return ip2RegionDownloadSources
 }
 
-func downloadFromSource(parent context.Context, src, tmp, target string) error {
+func downloadFromSource(parent context.Context, client *http.Client, src, tmp, target string) error {
 	ctx, cancel := context.WithCancel(parent)
 	defer cancel()
 
@@ -214,7 +279,7 @@ func downloadFromSource(parent context.Context, src, tmp, target string) error {
 	}
 	req.Header.Set("User-Agent", ip2RegionDefaultUserAgent)
 
-	resp, err := ip2RegionHTTPClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package geoip
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
 )
 
 func TestParseIP2Region(t *testing.T) {
@@ -44,7 +47,7 @@ func TestDownloadIP2RegionDBWithCustomURL(t *testing.T) {
 
 	tmpDir := t.TempDir()
 	target := filepath.Join(tmpDir, "ip2region.xdb")
-	if err := downloadIP2RegionDB(target, srv.URL); err != nil {
+	if err := downloadIP2RegionDB(context.Background(), target, srv.URL, 0); err != nil {
 		t.Fatalf("download failed: %v", err)
 	}
 
@@ -80,7 +83,7 @@ func TestDownloadIP2RegionDBFallback(t *testing.T) {
 
 	tmpDir := t.TempDir()
 	target := filepath.Join(tmpDir, "ip2region.xdb")
-	if err := downloadIP2RegionDB(target, ""); err != nil {
+	if err := downloadIP2RegionDB(context.Background(), target, "", 0); err != nil {
 		t.Fatalf("download with fallback failed: %v", err)
 	}
 
@@ -92,3 +95,57 @@ func TestDownloadIP2RegionDBFallback(t *testing.T) {
 		t.Fatalf("unexpected file content: %s", data)
 	}
 }
+
+func TestDownloadIP2RegionDBForcedIPv4(t *testing.T) {
+	t.Parallel()
+
+	origWriter := progressOutput
+	progressOutput = io.Discard
+	t.Cleanup(func() { progressOutput = origWriter })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "v4-only")
+	}))
+	t.Cleanup(srv.Close)
+
+	target := filepath.Join(t.TempDir(), "ip2region.xdb")
+	if err := downloadIP2RegionDB(context.Background(), target, srv.URL, 4); err != nil {
+		t.Fatalf("download over ipv4 failed: %v", err)
+	}
+	if err := downloadIP2RegionDB(context.Background(), target, srv.URL, 5); err == nil {
+		t.Fatalf("expected invalid ip version to fail")
+	}
+}
+
+func TestNewResolverUnknownSource(t *testing.T) {
+	i18n.SetLanguage("en")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+	if _, err := NewResolver("bogus", Options{}); err == nil || err.Error() != "unknown geoip source: bogus" {
+		t.Fatalf("expected localized unknown source error, got=%v", err)
+	}
+}
+
+func TestNewResolverOffline(t *testing.T) {
+	if _, err := NewResolver("cip", Options{Offline: true}); err == nil {
+		t.Fatal("expected online source to be rejected in offline mode")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected download request in offline mode: %s", r.URL)
+	}))
+	t.Cleanup(srv.Close)
+
+	target := filepath.Join(t.TempDir(), "ip2region.xdb")
+	_, err := NewResolver("ip2region", Options{
+		IP2RegionDB:  target,
+		IP2RegionURL: srv.URL,
+		Download:     DownloadOption{Answer: DownloadYes},
+		Offline:      true,
+	})
+	if err == nil {
+		t.Fatal("expected missing database to fail in offline mode")
+	}
+	if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+		t.Fatalf("database should not be created, stat err=%v", statErr)
+	}
+}
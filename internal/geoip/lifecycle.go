@@ -0,0 +1,63 @@
+package geoip
+
+import (
+	"context"
+	"sync"
+)
+
+// inflightGroup 跟踪进行中的查询，供 Shutdown 在关闭后等待其结束。
+// 与 sync.WaitGroup 不同，关闭后不再接受新的查询，因此 Wait 与 Add 之间没有竞争。
+type inflightGroup struct {
+	mu      sync.Mutex
+	closed  bool
+	active  int
+	drained chan struct{}
+}
+
+// begin 登记一次查询；已关闭时返回 false，调用方应直接放弃查询。
+func (g *inflightGroup) begin() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.active++
+	return true
+}
+
+func (g *inflightGroup) end() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	if g.closed && g.active == 0 && g.drained != nil {
+		close(g.drained)
+		g.drained = nil
+	}
+}
+
+// close 拒绝新的查询，并返回在所有进行中的查询结束后关闭的通道。
+func (g *inflightGroup) close() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.drained != nil {
+		return g.drained
+	}
+	g.closed = true
+	done := make(chan struct{})
+	if g.active == 0 {
+		close(done)
+		return done
+	}
+	g.drained = done
+	return done
+}
+
+// waitContext 等待 done 关闭，最多等到 ctx 结束。
+func waitContext(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
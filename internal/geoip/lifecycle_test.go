@@ -0,0 +1,62 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCIPResolverShutdownAbortsInflight(t *testing.T) {
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	r, err := NewCIPResolverWithOptions(HTTPOptions{BaseURL: srv.URL, Timeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.limiter = nil
+
+	resolved := make(chan *GeoLocation)
+	go func() { resolved <- r.Resolve(net.ParseIP("192.0.2.1")) }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if loc := <-resolved; loc != nil {
+		t.Fatalf("expected aborted lookup to return nil, got=%#v", loc)
+	}
+	if loc := r.Resolve(net.ParseIP("192.0.2.2")); loc != nil {
+		t.Fatalf("expected lookups after shutdown to be rejected, got=%#v", loc)
+	}
+}
+
+func TestInflightGroupWaitBounded(t *testing.T) {
+	var g inflightGroup
+	if !g.begin() {
+		t.Fatal("expected begin to succeed before close")
+	}
+	done := g.close()
+	if g.begin() {
+		t.Fatal("expected begin to fail after close")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := waitContext(ctx, done); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got=%v", err)
+	}
+	g.end()
+	if err := waitContext(context.Background(), g.close()); err != nil {
+		t.Fatal(err)
+	}
+}
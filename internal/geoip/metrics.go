@@ -0,0 +1,130 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBucketsMs 为查询耗时直方图的上界（毫秒，累计计数，与 Prometheus 的 le 语义一致）。
+var latencyBucketsMs = []float64{1, 5, 25, 100, 500, 2500}
+
+// LatencyBucket 为耗时不超过 LeMs 的查询数；最后一个桶的 LeMs 为 0，表示 +Inf。
+type LatencyBucket struct {
+	LeMs  float64 `json:"le_ms,omitempty"`
+	Count uint64  `json:"count"`
+}
+
+// Metrics 为一个 resolver 的查询统计，用于判断追踪变慢是网络还是地理位置后端导致的。
+type Metrics struct {
+	Source string `json:"source"`
+	// Lookups 为 Resolve 调用次数，Failures 为其中返回 nil（无位置信息）的次数。
+	Lookups  uint64 `json:"lookups"`
+	Failures uint64 `json:"failures"`
+	// CacheHits 为命中缓存、未访问后端的次数；BackendCalls 为实际访问后端（在线接口或本地数据库）的次数。
+	CacheHits    uint64          `json:"cache_hits"`
+	BackendCalls uint64          `json:"backend_calls"`
+	AvgMs        float64         `json:"avg_ms"`
+	MaxMs        float64         `json:"max_ms"`
+	Latency      []LatencyBucket `json:"latency"`
+}
+
+// HitRate 返回缓存命中率（0-1）。
+func (m Metrics) HitRate() float64 {
+	if m.Lookups == 0 {
+		return 0
+	}
+	return float64(m.CacheHits) / float64(m.Lookups)
+}
+
+// backendCounter 由带缓存的 resolver 实现，报告缓存命中与后端访问次数；
+// 未实现时每次查询都视为一次后端访问。
+type backendCounter interface {
+	backendCounts() (cacheHits, backendCalls uint64)
+}
+
+// resolveCounters 为带缓存的 resolver 记录缓存命中与后端访问次数。
+type resolveCounters struct {
+	hits, calls atomic.Uint64
+}
+
+func (c *resolveCounters) backendCounts() (uint64, uint64) {
+	return c.hits.Load(), c.calls.Load()
+}
+
+// MetricsResolver 包装任意 GeoResolver，统计查询次数、失败次数与耗时分布。
+type MetricsResolver struct {
+	GeoResolver
+
+	mu       sync.Mutex
+	lookups  uint64
+	failures uint64
+	total    time.Duration
+	max      time.Duration
+	buckets  []uint64
+}
+
+// WithMetrics 返回带统计的 resolver。
+func WithMetrics(r GeoResolver) *MetricsResolver {
+	return &MetricsResolver{GeoResolver: r, buckets: make([]uint64, len(latencyBucketsMs)+1)}
+}
+
+func (r *MetricsResolver) Resolve(ip net.IP) *GeoLocation {
+	start := time.Now()
+	loc := r.GeoResolver.Resolve(ip)
+	r.observe(time.Since(start), loc == nil)
+	return loc
+}
+
+func (r *MetricsResolver) Shutdown(ctx context.Context) error { return r.GeoResolver.Shutdown(ctx) }
+
+func (r *MetricsResolver) observe(d time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	if failed {
+		r.failures++
+	}
+	r.total += d
+	if d > r.max {
+		r.max = d
+	}
+	ms := float64(d) / float64(time.Millisecond)
+	i := 0
+	for i < len(latencyBucketsMs) && ms > latencyBucketsMs[i] {
+		i++
+	}
+	r.buckets[i]++
+}
+
+// Metrics 返回当前统计。
+func (r *MetricsResolver) Metrics() Metrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := Metrics{
+		Source:       r.Source(),
+		Lookups:      r.lookups,
+		Failures:     r.failures,
+		BackendCalls: r.lookups,
+		MaxMs:        float64(r.max) / float64(time.Millisecond),
+		Latency:      make([]LatencyBucket, 0, len(r.buckets)),
+	}
+	if bc, ok := r.GeoResolver.(backendCounter); ok {
+		m.CacheHits, m.BackendCalls = bc.backendCounts()
+	}
+	if r.lookups > 0 {
+		m.AvgMs = float64(r.total) / float64(r.lookups) / float64(time.Millisecond)
+	}
+	var cum uint64
+	for i, n := range r.buckets {
+		cum += n
+		b := LatencyBucket{Count: cum}
+		if i < len(latencyBucketsMs) {
+			b.LeMs = latencyBucketsMs[i]
+		}
+		m.Latency = append(m.Latency, b)
+	}
+	return m
+}
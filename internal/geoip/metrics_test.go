@@ -0,0 +1,73 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip/cache"
+)
+
+type slowResolver struct {
+	NoopResolver
+	delay time.Duration
+}
+
+func (r *slowResolver) Resolve(ip net.IP) *GeoLocation {
+	time.Sleep(r.delay)
+	if ip.To4() == nil {
+		return nil
+	}
+	return &GeoLocation{Country: "AU"}
+}
+
+func (r *slowResolver) Source() string { return "slow" }
+
+func TestMetricsResolverCountsAndLatency(t *testing.T) {
+	r := WithMetrics(&slowResolver{delay: 2 * time.Millisecond})
+	r.Resolve(net.ParseIP("192.0.2.1"))
+	r.Resolve(net.ParseIP("192.0.2.2"))
+	r.Resolve(net.ParseIP("2001:db8::1"))
+
+	m := r.Metrics()
+	if m.Source != "slow" || m.Lookups != 3 || m.Failures != 1 {
+		t.Fatalf("unexpected counters: %+v", m)
+	}
+	if m.AvgMs < 2 || m.MaxMs < 2 {
+		t.Fatalf("latency not recorded: avg=%.2f max=%.2f", m.AvgMs, m.MaxMs)
+	}
+	// 每次查询至少 2ms：不会落入 le=1 的桶，+Inf 桶为总数
+	if m.Latency[0].LeMs != 1 || m.Latency[0].Count != 0 {
+		t.Fatalf("unexpected first bucket: %+v", m.Latency[0])
+	}
+	if last := m.Latency[len(m.Latency)-1]; last.LeMs != 0 || last.Count != 3 {
+		t.Fatalf("unexpected +Inf bucket: %+v", last)
+	}
+}
+
+func TestMetricsResolverCIPCacheHits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "IP\t: 1.1.1.1\n地址\t: 澳大利亚\n")
+	}))
+	defer srv.Close()
+
+	cip := NewCIPResolver()
+	cip.baseURL = srv.URL
+	cip.limiter = nil
+	cip.cache = cache.New[*GeoLocation](10)
+
+	r := WithMetrics(cip)
+	for i := 0; i < 3; i++ {
+		r.Resolve(net.ParseIP("1.1.1.1"))
+	}
+	m := r.Metrics()
+	if m.Lookups != 3 || m.BackendCalls != 1 || m.CacheHits != 2 {
+		t.Fatalf("unexpected counters: %+v", m)
+	}
+	if rate := m.HitRate(); rate < 0.66 || rate > 0.67 {
+		t.Fatalf("hit rate = %.3f", rate)
+	}
+}
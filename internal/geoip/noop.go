@@ -1,6 +1,9 @@
 package geoip
 
-import "net"
+import (
+	"context"
+	"net"
+)
 
 type NoopResolver struct{}
 
@@ -11,3 +14,7 @@ func (r *NoopResolver) Resolve(ip net.IP) *GeoLocation { return nil }
 func (r *NoopResolver) Source() string { return "noop" }
 
 func (r *NoopResolver) Close() error { return nil }
+
+func (r *NoopResolver) Shutdown(context.Context) error { return nil }
+
+func (r *NoopResolver) backendCounts() (uint64, uint64) { return 0, 0 }
@@ -0,0 +1,106 @@
+package geoip
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter 以固定间隔发放请求配额（令牌桶，容量 burst）。
+// 等待时间超过 maxWait 时直接放弃，由调用方按“本次无位置信息”处理，
+// 避免在线接口限流时阻塞探测主流程。
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+	maxWait  time.Duration
+	sleep    func(time.Duration)
+}
+
+func newRateLimiter(qps float64, burst int, maxWait time.Duration) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	interval := time.Duration(0)
+	if qps > 0 {
+		interval = time.Duration(float64(time.Second) / qps)
+	}
+	return &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+		maxWait:  maxWait,
+		sleep:    time.Sleep,
+	}
+}
+
+// Acquire 获取一个配额；返回 false 表示需要等待的时间超过 maxWait，本次请求应放弃。
+func (l *rateLimiter) Acquire() bool {
+	if l == nil || l.interval <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return true
+	}
+	wait := time.Duration((1 - l.tokens) * float64(l.interval))
+	if wait > l.maxWait {
+		l.mu.Unlock()
+		return false
+	}
+	// 预占配额：令牌数允许为负，后续请求会相应顺延
+	l.tokens--
+	l.mu.Unlock()
+
+	l.sleep(wait)
+	return true
+}
+
+// flightGroup 合并同一 key 的并发调用（singleflight），只执行一次 fn。
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	wg  sync.WaitGroup
+	loc *GeoLocation
+	ok  bool
+}
+
+func (g *flightGroup) Do(key string, fn func() (*GeoLocation, bool)) (*GeoLocation, bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.loc, c.ok
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.loc, c.ok = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	return c.loc, c.ok
+}
@@ -1,6 +1,7 @@
 package geoip
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
@@ -12,6 +13,9 @@ type GeoResolver interface {
 	Resolve(ip net.IP) *GeoLocation
 	Source() string
 	Close() error
+	// Shutdown 与 Close 相同，但会中止进行中的在线查询，且最多等待到 ctx 结束（此时返回 ctx.Err()），
+	// 便于嵌入方确定性地限制退出耗时。
+	Shutdown(ctx context.Context) error
 }
 
 type GeoLocation struct {
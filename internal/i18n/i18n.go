@@ -30,22 +30,30 @@ func Init(lang string) {
 		bundle.LoadMessageFileFS(localeFS, "locales/en.toml")
 		bundle.LoadMessageFileFS(localeFS, "locales/zh.toml")
 
-		// Determine language to use
-		langs := []string{}
-		if lang != "" {
-			langs = append(langs, lang)
-		} else {
-			// Auto-detect system locale using POSIX standard order:
-			// LANGUAGE > LC_ALL > LC_MESSAGES > LANG
-			if detected, err := locale.Detect(); err == nil {
-				langs = append(langs, detected.String())
-			}
-		}
-
-		localizer = i18n.NewLocalizer(bundle, langs...)
+		localizer = i18n.NewLocalizer(bundle, languages(lang)...)
 	})
 }
 
+// SetLanguage switches the active language after initialization (e.g. in tests).
+// An empty lang falls back to the detected system locale.
+func SetLanguage(lang string) {
+	Init(lang)
+	localizer = i18n.NewLocalizer(bundle, languages(lang)...)
+}
+
+// languages returns the language preference list for lang.
+func languages(lang string) []string {
+	if lang != "" {
+		return []string{lang}
+	}
+	// Auto-detect system locale using POSIX standard order:
+	// LANGUAGE > LC_ALL > LC_MESSAGES > LANG
+	if detected, err := locale.Detect(); err == nil {
+		return []string{detected.String()}
+	}
+	return nil
+}
+
 // T returns the translated string for the given message ID.
 func T(messageID string) string {
 	Init("") // Ensure initialized
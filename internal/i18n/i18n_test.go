@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TestLocalesParseAndMatch 确保翻译文件可解析，且中英文的消息 ID 一一对应。
+func TestLocalesParseAndMatch(t *testing.T) {
+	keys := func(name string) map[string]bool {
+		data, err := localeFS.ReadFile("locales/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw map[string]interface{}
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		out := make(map[string]bool)
+		flattenKeys("", raw, out)
+		return out
+	}
+
+	en, zh := keys("en.toml"), keys("zh.toml")
+	var missing []string
+	for k := range en {
+		if !zh[k] {
+			missing = append(missing, "zh:"+k)
+		}
+	}
+	for k := range zh {
+		if !en[k] {
+			missing = append(missing, "en:"+k)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Fatalf("untranslated message IDs: %v", missing)
+	}
+}
+
+func flattenKeys(prefix string, m map[string]interface{}, out map[string]bool) {
+	for k, v := range m {
+		id := k
+		if prefix != "" {
+			id = prefix + "." + k
+		}
+		if k == "other" {
+			out[prefix] = true
+			continue
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenKeys(id, sub, out)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package mtr
+
+import (
+	"math"
+	"sort"
+)
+
+// 判定“疑似 anycast”的阈值：各站点到达目标的平均时延差异需同时超过比例与绝对值。
+const (
+	anycastRTTRatio  = 1.5
+	anycastRTTMinGap = 5.0 // ms
+)
+
+// AnycastSite 为到达目标前的一个终端站点：以目标前最近一个有响应的 hop（邻居）与目标所在 TTL 区分。
+type AnycastSite struct {
+	Neighbor string  `json:"neighbor"`
+	DestTTL  int     `json:"dest_ttl"`
+	Rounds   int     `json:"rounds"`
+	AvgRTTMs float64 `json:"avg_rtt_ms"`
+}
+
+// AnycastReport 汇总各轮到达目标时经过的终端站点。
+type AnycastReport struct {
+	Sites []AnycastSite `json:"sites"`
+	// Probable 表示各站点的目标跳数或时延差异明显，目标很可能是 anycast 地址
+	// （仅邻居不同而跳数、时延相近时更可能是目标前的 ECMP，不计入）。
+	Probable bool `json:"probable"`
+}
+
+// DetectAnycast 根据逐轮结果检测同一目标 IP 是否被不同的终端站点响应；到达目标的轮次不足两轮时返回 nil。
+func DetectAnycast(rounds []RoundSnapshot) *AnycastReport {
+	type siteKey struct {
+		neighbor string
+		ttl      int
+	}
+	type acc struct {
+		rounds int
+		rttSum float64
+	}
+	sites := make(map[siteKey]*acc)
+	reached := 0
+	for _, r := range rounds {
+		if r.Dest.State != DestinationStateReached {
+			continue
+		}
+		reached++
+		k := siteKey{ttl: r.Dest.TTL}
+		var rtt float64
+		for _, h := range r.Hops {
+			if h.TTL < r.Dest.TTL && h.IP != "" {
+				k.neighbor = h.IP
+			}
+			if h.TTL == r.Dest.TTL {
+				rtt = h.RTTMs
+			}
+		}
+		a := sites[k]
+		if a == nil {
+			a = &acc{}
+			sites[k] = a
+		}
+		a.rounds++
+		a.rttSum += rtt
+	}
+	if reached < 2 {
+		return nil
+	}
+
+	report := &AnycastReport{Sites: make([]AnycastSite, 0, len(sites))}
+	ttls := make(map[int]bool)
+	minRTT, maxRTT := math.Inf(1), math.Inf(-1)
+	for k, a := range sites {
+		avg := a.rttSum / float64(a.rounds)
+		report.Sites = append(report.Sites, AnycastSite{Neighbor: k.neighbor, DestTTL: k.ttl, Rounds: a.rounds, AvgRTTMs: avg})
+		ttls[k.ttl] = true
+		minRTT, maxRTT = math.Min(minRTT, avg), math.Max(maxRTT, avg)
+	}
+	sort.Slice(report.Sites, func(i, j int) bool {
+		a, b := report.Sites[i], report.Sites[j]
+		if a.Rounds != b.Rounds {
+			return a.Rounds > b.Rounds
+		}
+		if a.DestTTL != b.DestTTL {
+			return a.DestTTL < b.DestTTL
+		}
+		return a.Neighbor < b.Neighbor
+	})
+	if len(report.Sites) > 1 {
+		rttApart := maxRTT-minRTT >= anycastRTTMinGap && maxRTT >= minRTT*anycastRTTRatio
+		report.Probable = len(ttls) > 1 || rttApart
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package mtr
+
+import (
+	"testing"
+)
+
+func anycastRound(round int, neighbor string, destTTL int, rtt float64) RoundSnapshot {
+	hops := []RoundHop{{TTL: 1, IP: "10.0.0.1"}}
+	if neighbor != "" {
+		hops = append(hops, RoundHop{TTL: destTTL - 1, IP: neighbor})
+	}
+	hops = append(hops, RoundHop{TTL: destTTL, IP: "192.0.2.53", RTTMs: rtt})
+	return RoundSnapshot{Round: round, Hops: hops, Dest: DestinationStatus{State: DestinationStateReached, TTL: destTTL, IP: "192.0.2.53"}}
+}
+
+func TestDetectAnycast(t *testing.T) {
+	if r := DetectAnycast([]RoundSnapshot{anycastRound(0, "10.1.0.1", 4, 10)}); r != nil {
+		t.Fatalf("expected nil with a single reached round, got=%+v", r)
+	}
+
+	// 邻居不同但跳数、时延相近：更像目标前的 ECMP，不判为 anycast
+	ecmp := DetectAnycast([]RoundSnapshot{
+		anycastRound(0, "10.1.0.1", 4, 10),
+		anycastRound(1, "10.1.0.2", 4, 11),
+	})
+	if ecmp == nil || len(ecmp.Sites) != 2 || ecmp.Probable {
+		t.Fatalf("unexpected report for ecmp: %+v", ecmp)
+	}
+
+	// 目标所在跳数不同
+	hops := DetectAnycast([]RoundSnapshot{
+		anycastRound(0, "10.1.0.1", 4, 10),
+		anycastRound(1, "10.2.0.1", 6, 10),
+		anycastRound(2, "10.1.0.1", 4, 12),
+		{Round: 3, Dest: DestinationStatus{State: DestinationStateUnreachable}},
+	})
+	if hops == nil || !hops.Probable || len(hops.Sites) != 2 {
+		t.Fatalf("unexpected report: %+v", hops)
+	}
+	if s := hops.Sites[0]; s.Neighbor != "10.1.0.1" || s.DestTTL != 4 || s.Rounds != 2 || s.AvgRTTMs != 11 {
+		t.Fatalf("unexpected primary site: %+v", s)
+	}
+
+	// 跳数相同但时延差异明显
+	rtt := DetectAnycast([]RoundSnapshot{
+		anycastRound(0, "10.1.0.1", 4, 10),
+		anycastRound(1, "10.2.0.1", 4, 80),
+	})
+	if rtt == nil || !rtt.Probable {
+		t.Fatalf("expected rtt-based anycast detection, got=%+v", rtt)
+	}
+}
+
+func TestControllerPinFlowRequiresFlowProber(t *testing.T) {
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 3, Protocol: ProtocolICMP, IPVersion: 4, PinFlow: true}
+	if _, err := NewController(cfg, &scriptedProber{}, nil); err == nil {
+		t.Fatal("expected error for prober without flow support")
+	}
+}
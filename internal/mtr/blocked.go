@@ -0,0 +1,138 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+)
+
+// 阻断判定结论（mymtr blocked）。
+const (
+	// BlockNone 到达目标，且端口可连接（或未检查端口）
+	BlockNone = "not_blocked"
+	// BlockProhibited 某一跳返回了管理性禁止的目标不可达（防火墙/ACL 明确拒绝）
+	BlockProhibited = "prohibited"
+	// BlockUnreachable 某一跳返回了其他目标不可达（无路由、主机不可达等）
+	BlockUnreachable = "unreachable"
+	// BlockDropped 路径在某一跳之后静默中断，后续各跳与目标均无响应
+	BlockDropped = "dropped"
+	// BlockPortFiltered 目标可达，但端口握手超时（通常为目标处或附近的防火墙丢弃）
+	BlockPortFiltered = "port_filtered"
+	// BlockPortClosed 目标可达，端口以 RST 拒绝连接：服务未监听，并非网络阻断
+	BlockPortClosed = "port_closed"
+)
+
+// TCP 端口检查结果（见 CheckTCPPort）。
+const (
+	PortOpen     = "open"
+	PortClosed   = "closed"
+	PortFiltered = "filtered"
+)
+
+// BlockVerdict 为阻断判定结果。TTL/IP/Hostname/Location 指向阻断点：
+// 返回不可达的 hop，或静默中断前最后一个有响应的 hop（TTL 为 0 表示第一跳起即无响应）。
+type BlockVerdict struct {
+	Target    string             `json:"target"`
+	TargetIP  string             `json:"target_ip"`
+	State     string             `json:"state"`
+	TTL       int                `json:"ttl,omitempty"`
+	IP        string             `json:"ip,omitempty"`
+	Hostname  string             `json:"hostname,omitempty"`
+	Location  *geoip.GeoLocation `json:"location,omitempty"`
+	Reason    string             `json:"reason,omitempty"`
+	Port      int                `json:"port,omitempty"`
+	PortState string             `json:"port_state,omitempty"`
+}
+
+// Blocked 报告判定是否为阻断（端口拒绝连接不视为阻断）。
+func (v BlockVerdict) Blocked() bool {
+	return v.State != BlockNone && v.State != BlockPortClosed
+}
+
+// AnalyzeBlock 根据追踪快照与可选的端口检查结果判断目标是否被阻断、阻断在哪一跳：
+// 端口可连接时直接判定为未阻断（目标丢弃 ICMP 但开放 TCP 端口很常见）；
+// 否则优先采用管理性禁止的不可达报文，其次是其他不可达，再次是响应中断点；到达目标时再看端口状态。
+func AnalyzeBlock(s *Snapshot, port int, portState string) BlockVerdict {
+	v := BlockVerdict{Target: s.Target, TargetIP: s.TargetIP, Port: port, PortState: portState}
+	if portState == PortOpen {
+		v.State = BlockNone
+		return v
+	}
+	for _, hop := range s.Hops {
+		kinds := make([]string, 0, len(hop.Stats.Responses))
+		for kind := range hop.Stats.Responses {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			if strings.HasSuffix(kind, "_prohibited") {
+				v.State = BlockProhibited
+				v.Reason = kind
+				v.at(hop)
+				return v
+			}
+		}
+	}
+	if s.Destination.State == DestinationStateUnreachable {
+		v.State = BlockUnreachable
+		v.Reason = s.Destination.Reason
+		v.TTL = s.Destination.TTL
+		v.IP = s.Destination.IP
+		for _, hop := range s.Hops {
+			if hop.TTL == s.Destination.TTL {
+				v.at(hop)
+			}
+		}
+		return v
+	}
+	if !s.Completeness.DestinationReached {
+		v.State = BlockDropped
+		for _, hop := range s.Hops {
+			if hop.Stats.Received > 0 {
+				v.at(hop)
+			}
+		}
+		return v
+	}
+	switch portState {
+	case PortFiltered:
+		v.State = BlockPortFiltered
+	case PortClosed:
+		v.State = BlockPortClosed
+	default:
+		v.State = BlockNone
+	}
+	return v
+}
+
+func (v *BlockVerdict) at(hop SnapshotHop) {
+	v.TTL = hop.TTL
+	v.IP = hop.IP
+	v.Hostname = hop.Hostname
+	v.Location = hop.Location
+}
+
+// CheckTCPPort 向目标端口发起至多 attempts 次 TCP 握手：任意一次成功即为 open，
+// 被 RST 拒绝为 closed，全部超时或无响应为 filtered。
+func CheckTCPPort(ctx context.Context, ip net.IP, port, attempts int, timeout time.Duration) string {
+	state := PortFiltered
+	for i := 0; i < attempts && ctx.Err() == nil; i++ {
+		d := net.Dialer{Timeout: timeout}
+		conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err == nil {
+			conn.Close()
+			return PortOpen
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			state = PortClosed
+		}
+	}
+	return state
+}
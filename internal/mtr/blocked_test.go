@@ -0,0 +1,59 @@
+package mtr
+
+import "testing"
+
+func TestAnalyzeBlock(t *testing.T) {
+	hop := func(ttl int, ip string, received int, kinds ...string) SnapshotHop {
+		h := SnapshotHop{TTL: ttl, IP: ip, Stats: SnapshotHopSta{Sent: 3, Received: received, Responses: map[string]int{}}}
+		for _, k := range kinds {
+			h.Stats.Responses[k]++
+		}
+		return h
+	}
+
+	prohibited := &Snapshot{
+		Target: "example.com",
+		Hops:   []SnapshotHop{hop(1, "192.0.2.1", 3, "time_exceeded"), hop(2, "198.51.100.1", 3, "dest_unreach/admin_prohibited")},
+		Destination: DestinationStatus{
+			State: DestinationStateUnreachable, TTL: 2, IP: "198.51.100.1", Reason: "dest_unreach/admin_prohibited",
+		},
+	}
+	if v := AnalyzeBlock(prohibited, 0, ""); v.State != BlockProhibited || v.TTL != 2 || v.Reason != "dest_unreach/admin_prohibited" || !v.Blocked() {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+
+	unreachable := &Snapshot{
+		Hops:        []SnapshotHop{hop(1, "192.0.2.1", 3, "time_exceeded"), hop(2, "198.51.100.1", 3, "dest_unreach/host_unreach")},
+		Destination: DestinationStatus{State: DestinationStateUnreachable, TTL: 2, IP: "198.51.100.1", Reason: "dest_unreach/host_unreach"},
+	}
+	if v := AnalyzeBlock(unreachable, 0, ""); v.State != BlockUnreachable || v.TTL != 2 {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+
+	dropped := &Snapshot{Hops: []SnapshotHop{hop(1, "192.0.2.1", 3), hop(2, "192.0.2.2", 2), hop(3, "", 0), hop(4, "", 0)}}
+	if v := AnalyzeBlock(dropped, 0, ""); v.State != BlockDropped || v.TTL != 2 || v.IP != "192.0.2.2" {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+	if v := AnalyzeBlock(&Snapshot{Hops: []SnapshotHop{hop(1, "", 0)}}, 0, ""); v.State != BlockDropped || v.TTL != 0 {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+
+	// 目标丢弃 ICMP、路径静默中断，但 TCP 端口可连接：不应判为阻断
+	if v := AnalyzeBlock(dropped, 443, PortOpen); v.State != BlockNone || v.Blocked() {
+		t.Fatalf("unexpected verdict for open port behind dropped path: %+v", v)
+	}
+	if v := AnalyzeBlock(prohibited, 443, PortOpen); v.State != BlockNone || v.Blocked() {
+		t.Fatalf("unexpected verdict for open port behind prohibited hop: %+v", v)
+	}
+
+	reached := &Snapshot{Hops: []SnapshotHop{hop(1, "192.0.2.9", 3)}, Completeness: PathCompleteness{DestinationReached: true}}
+	for portState, want := range map[string]string{"": BlockNone, PortOpen: BlockNone, PortClosed: BlockPortClosed, PortFiltered: BlockPortFiltered} {
+		v := AnalyzeBlock(reached, 443, portState)
+		if v.State != want {
+			t.Fatalf("port %q: got %s, want %s", portState, v.State, want)
+		}
+		if v.Blocked() != (want == BlockPortFiltered) {
+			t.Fatalf("port %q: unexpected Blocked()=%v", portState, v.Blocked())
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package mtr
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// CheckRawICMP 检查当前进程能否创建原始 ICMP 套接字（ICMP/UDP 探测均依赖它接收回包）。
+func CheckRawICMP(ipVersion int) error {
+	network, addr := "ip4:icmp", "0.0.0.0"
+	if ipVersion == 6 {
+		network, addr = "ip6:ipv6-icmp", "::"
+	}
+	conn, err := icmp.ListenPacket(network, addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// CheckUnprivilegedProbe 检查无原始套接字权限时使用的降级探测方式（见 NewProberWithFallback）能否打开套接字，
+// 返回该方式的名称（如 ProbeModeUDPRecvErr）。不会发送任何报文。
+func CheckUnprivilegedProbe(ipVersion int) (string, error) {
+	return checkUnprivilegedProbe(ipVersion)
+}
+
+// CheckUDPTTL 检查能否在 UDP 套接字上设置 TTL/HopLimit。
+// 仅 connect 到文档保留地址，不会发送任何报文。
+func CheckUDPTTL(ipVersion int) error {
+	network, raddr := "udp4", "192.0.2.1:33434"
+	if ipVersion == 6 {
+		network, raddr = "udp6", "[2001:db8::1]:33434"
+	}
+	conn, err := net.Dial(network, raddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		return fmt.Errorf("unexpected conn type %T", conn)
+	}
+	if ipVersion == 6 {
+		return ipv6.NewPacketConn(udpConn).SetHopLimit(1)
+	}
+	return ipv4.NewPacketConn(udpConn).SetTTL(1)
+}
+
+// CheckIPv6Route 检查本机是否存在 IPv6 默认路由（通过 UDP connect 选路，不发送报文）。
+func CheckIPv6Route() (net.IP, error) {
+	conn, err := net.Dial("udp6", "[2001:4860:4860::8888]:53")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if la, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+		return la.IP, nil
+	}
+	return nil, nil
+}
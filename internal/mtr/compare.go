@@ -0,0 +1,63 @@
+package mtr
+
+// PathComparison 为多条路径（不同源地址、目标地址等）按 TTL 对齐后的对比结果。
+type PathComparison struct {
+	Labels    []string        `json:"labels"`
+	Rows      []ComparisonRow `json:"rows"`
+	Divergent []int           `json:"divergent_ttls,omitempty"`
+	// CommonPrefix 为所有路径一致的最后一个 TTL（首个分叉 TTL 之前），其后为各自分叉的尾部。
+	CommonPrefix int         `json:"common_prefix_ttl"`
+	Paths        []*Snapshot `json:"paths"`
+}
+
+type ComparisonRow struct {
+	TTL       int            `json:"ttl"`
+	Hops      []*SnapshotHop `json:"hops"`
+	Divergent bool           `json:"divergent"`
+}
+
+// ComparePaths 按 TTL 对齐多份快照；同一 TTL 上有多个不同的响应 IP 时视为分叉。
+// 未响应（*）的 hop 不参与判断，避免丢包被误判为路径差异。
+func ComparePaths(labels []string, snaps []*Snapshot) *PathComparison {
+	maxTTL := 0
+	for _, s := range snaps {
+		if s == nil {
+			continue
+		}
+		for _, hop := range s.Hops {
+			if hop.TTL > maxTTL {
+				maxTTL = hop.TTL
+			}
+		}
+	}
+
+	cmp := &PathComparison{Labels: labels, Paths: snaps}
+	for ttl := 1; ttl <= maxTTL; ttl++ {
+		row := ComparisonRow{TTL: ttl, Hops: make([]*SnapshotHop, len(snaps))}
+		seen := make(map[string]bool)
+		for i, s := range snaps {
+			if s == nil {
+				continue
+			}
+			for j := range s.Hops {
+				if s.Hops[j].TTL == ttl {
+					row.Hops[i] = &s.Hops[j]
+					if s.Hops[j].IP != "" {
+						seen[s.Hops[j].IP] = true
+					}
+					break
+				}
+			}
+		}
+		row.Divergent = len(seen) > 1
+		if row.Divergent {
+			cmp.Divergent = append(cmp.Divergent, ttl)
+		}
+		cmp.Rows = append(cmp.Rows, row)
+	}
+	cmp.CommonPrefix = maxTTL
+	if len(cmp.Divergent) > 0 {
+		cmp.CommonPrefix = cmp.Divergent[0] - 1
+	}
+	return cmp
+}
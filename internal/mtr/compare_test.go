@@ -0,0 +1,34 @@
+package mtr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComparePaths(t *testing.T) {
+	a := &Snapshot{Hops: []SnapshotHop{
+		{TTL: 1, IP: "10.0.0.1"},
+		{TTL: 2, IP: "10.1.0.1"},
+		{TTL: 3},
+		{TTL: 4, IP: "8.8.8.8"},
+	}}
+	b := &Snapshot{Hops: []SnapshotHop{
+		{TTL: 1, IP: "10.0.0.1"},
+		{TTL: 2, IP: "10.2.0.1"},
+		{TTL: 3, IP: "10.2.0.2"},
+	}}
+
+	cmp := ComparePaths([]string{"a", "b"}, []*Snapshot{a, b})
+	if len(cmp.Rows) != 4 {
+		t.Fatalf("expected 4 rows, got=%d", len(cmp.Rows))
+	}
+	if !reflect.DeepEqual(cmp.Divergent, []int{2}) {
+		t.Fatalf("unexpected divergent ttls: %v", cmp.Divergent)
+	}
+	if cmp.CommonPrefix != 1 {
+		t.Fatalf("expected common prefix up to TTL 1, got=%d", cmp.CommonPrefix)
+	}
+	if cmp.Rows[3].Hops[1] != nil {
+		t.Fatalf("expected missing hop for shorter path")
+	}
+}
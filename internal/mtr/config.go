@@ -12,11 +12,110 @@ type Config struct {
 	Protocol  Protocol
 	IPVersion int
 	EnableDNS bool
+	// HopTimeouts 按 TTL 覆盖 Timeout（见 TimeoutFor），用于近端快、远端跨洲的路径。
+	HopTimeouts HopTimeouts
+	// Source 为探测绑定的本机源地址（为空时由系统选路）。
+	Source string
+	// ContinueOnUnreachable 为 true 时，收到终止性的目标不可达后仍继续探测到 MaxHops。
+	ContinueOnUnreachable bool
+	// DurationFormat 决定快照中耗时字符串的单位与精度。
+	DurationFormat DurationFormat
+	// Gentle 为 true 时以慢启动方式探测：从较长的轮间隔开始，丢包低时逐步加快到 Interval。
+	Gentle bool
+	// Offline 为 true 时除探测包外不产生任何网络流量：目标必须是 IP 字面量，且不做反向解析。
+	Offline bool
+	// DSCP 为探测包的 DSCP 标记（0~63），0 表示不设置。
+	DSCP int
+	// StrictMatch 为 true 时要求回包通过完整校验（引用报文的目标地址与负载），见 ProberOptions.StrictMatch。
+	StrictMatch bool
+	// RoundHistory 为 Controller 保留的逐轮原始结果数（见 Controller.RoundSnapshot），<=0 时使用默认值。
+	RoundHistory int
+	// CheckDNS 为 true 时解析目标后校验其 PTR 是否回到同一域名（见 DNSCheck），离线模式与 IP 字面量目标下不生效。
+	CheckDNS bool
+	// DarkHopAfter 大于 0 时，连续这么多次探测都未应答的 hop 只每 DarkHopEvery 轮探测一次（见 Controller.skipDarkHop）。
+	DarkHopAfter int
+	// DarkHopEvery 为降频后的探测间隔轮数，<=0 时使用默认值。
+	DarkHopEvery int
+	// HostnameRules 为主机名的显示缩短规则，只影响表格/TUI 渲染。
+	HostnameRules HostnameRules
+	// TCPRTTPort 大于 0 时每轮结束后向目标该端口做一次 TCP 握手，作为独立的目标时延参照（见 TCPRTT）。
+	TCPRTTPort int
+	// PinFlow 为 true 时所有探测使用同一流标识（五元组固定），使测量稳定落在同一条 ECMP 路径/同一 anycast 站点上；prober 需实现 FlowProber。
+	PinFlow bool
+	// Safety 为探测的安全上限（见 SafetyLimits），nil 表示不限制。
+	Safety *SafetyLimits
+
+	// Unprivileged 为 true 时直接使用无特权的探测方式（Linux 上为 IP_RECVERR 的 UDP 探测），不尝试原始套接字。
+	Unprivileged bool
+	// ProbeMode 非空时表示使用了无特权的探测方式（见 ProbeModeICMPDgram 等），由 NewProberWithFallback 填写。
+	ProbeMode string
+
+	// Profile 记录 Interval/Timeout 中由协议默认档位填充的来源（为空表示全部由用户指定）。
+	Profile string
 }
 
 type Protocol string
 
 const (
-	ProtocolICMP Protocol = "icmp"
-	ProtocolUDP  Protocol = "udp"
+	ProtocolICMP          Protocol = "icmp"
+	ProtocolICMPTimestamp Protocol = "icmp-ts"
+	ProtocolUDP           Protocol = "udp"
 )
+
+// ProtocolProfile 为各协议提供未显式指定时使用的默认探测节奏。
+type ProtocolProfile struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+var protocolProfiles = map[Protocol]ProtocolProfile{
+	// ICMP 回显开销小、响应快，可以探测得更密集
+	ProtocolICMP:          {Interval: 500 * time.Millisecond, Timeout: time.Second},
+	ProtocolICMPTimestamp: {Interval: 500 * time.Millisecond, Timeout: time.Second},
+	// UDP 的端口不可达常被限速，放慢节奏并给更长的等待时间
+	ProtocolUDP: {Interval: time.Second, Timeout: 2 * time.Second},
+}
+
+var defaultProfile = ProtocolProfile{Interval: time.Second, Timeout: time.Second}
+
+// DefaultProfile 返回协议对应的默认档位；未知协议返回通用默认值。
+func DefaultProfile(p Protocol) ProtocolProfile {
+	if profile, ok := protocolProfiles[p]; ok {
+		return profile
+	}
+	return defaultProfile
+}
+
+// Supported 判断协议是否有对应的探测器实现。
+func (p Protocol) Supported() bool {
+	_, ok := protocolProfiles[p]
+	return ok
+}
+
+// ApplyProtocolProfile 为未设置（<=0）的 Interval/Timeout 填充协议默认值，并记录到 Profile。
+func (c *Config) ApplyProtocolProfile() {
+	if c == nil {
+		return
+	}
+	if c.Protocol == "" {
+		c.Protocol = ProtocolICMP
+	}
+	profile := DefaultProfile(c.Protocol)
+	applied := false
+	if c.Interval <= 0 {
+		c.Interval = profile.Interval
+		applied = true
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = profile.Timeout
+		applied = true
+	}
+	if applied {
+		c.Profile = string(c.Protocol)
+	}
+}
+
+// reverseDNSEnabled 判断是否对跳点做反向解析；离线模式下始终关闭。
+func (c *Config) reverseDNSEnabled() bool {
+	return c.EnableDNS && !c.Offline
+}
@@ -0,0 +1,26 @@
+package mtr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_ApplyProtocolProfile(t *testing.T) {
+	cfg := &Config{Protocol: ProtocolUDP}
+	cfg.ApplyProtocolProfile()
+	if cfg.Interval != time.Second || cfg.Timeout != 2*time.Second {
+		t.Fatalf("unexpected udp defaults: interval=%v timeout=%v", cfg.Interval, cfg.Timeout)
+	}
+	if cfg.Profile != "udp" {
+		t.Fatalf("expected profile=udp, got=%q", cfg.Profile)
+	}
+
+	explicit := &Config{Protocol: ProtocolICMP, Interval: 3 * time.Second, Timeout: 5 * time.Second}
+	explicit.ApplyProtocolProfile()
+	if explicit.Interval != 3*time.Second || explicit.Timeout != 5*time.Second {
+		t.Fatalf("explicit values overwritten: interval=%v timeout=%v", explicit.Interval, explicit.Timeout)
+	}
+	if explicit.Profile != "" {
+		t.Fatalf("expected empty profile, got=%q", explicit.Profile)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net"
+	"net/netip"
 	"sort"
 	"strings"
 	"sync"
@@ -18,9 +19,48 @@ type Controller struct {
 	prober   Prober
 	resolver geoip.GeoResolver
 
-	mu     sync.RWMutex
-	hops   map[int]*Hop
+	mu   sync.RWMutex
+	hops map[int]*Hop
+	// loops 为运行期间在同一轮内发现的环路：响应 IP -> 出现过的 TTL（升序）
+	loops  map[string][]int
 	events chan Event
+	runErr error
+	done   chan struct{}
+	hook   HopHook
+	// recorder 非空时逐条记录探测结果（见 SetProbeRecorder）
+	recorder *ProbeRecorder
+	// dest 为最近一轮结束时的目标状态
+	dest DestinationStatus
+	// round 为正在进行的轮次（从 0 开始），markers 为用户插入的时间标记
+	round   int
+	markers []Marker
+	// warming 跟踪首轮后的位置/主机名补全，Run 返回前等待其结束再关闭事件通道
+	warming sync.WaitGroup
+	// sendWarned 表示已提示过发送失败，避免每个探测包重复告警
+	sendWarned bool
+	// progress 为当前轮次的探测进度（见 Progress）
+	progress RoundProgress
+	// rounds 为最近若干轮的原始结果（见 RoundSnapshot）
+	rounds []RoundSnapshot
+	// reach 跟踪目标可达性的变化
+	reach reachabilityTracker
+	// ttlWarned 表示已提示过 TTL 疑似被改写（见 checkTTLRewrite）
+	ttlWarned bool
+	// policy 为路径策略，violations 为最近一轮结束时未满足的策略，
+	// violated 为运行期间违反过的策略（每条策略只记录首次违规，也用于只告警一次）
+	policy     PathPolicy
+	violations []PolicyViolation
+	violated   []PolicyViolation
+	// seq 为探测序号分配器，仅在 Run 的探测循环中使用
+	seq seqAllocator
+	// tcpRTT 累计每轮一次的 TCP 握手时延（仅 Config.TCPRTTPort 大于 0 时）
+	tcpRTT tcpRTTStats
+	// dnsCheck 为目标的往返解析校验结果（仅 Config.CheckDNS 时填充）
+	dnsCheck *DNSCheck
+	// trigger 用于跳过当前轮间等待、立即开始下一轮（见 Trigger）
+	trigger chan struct{}
+	// observers 为同步接收全部事件的观察者（见 AddEventObserver）
+	observers []func(Event)
 }
 
 func NewController(cfg *Config, prober Prober, resolver geoip.GeoResolver) (*Controller, error) {
@@ -36,17 +76,20 @@ func NewController(cfg *Config, prober Prober, resolver geoip.GeoResolver) (*Con
 	if cfg.MaxHops <= 0 {
 		cfg.MaxHops = 30
 	}
-	if cfg.Timeout <= 0 {
-		cfg.Timeout = time.Second
-	}
-	if cfg.Interval <= 0 {
-		cfg.Interval = time.Second
-	}
 	if cfg.IPVersion != 4 && cfg.IPVersion != 6 {
 		return nil, errors.New(i18n.Tf("err.ipVersionInvalid", map[string]interface{}{"Version": cfg.IPVersion}))
 	}
-	if cfg.Protocol == "" {
-		cfg.Protocol = ProtocolICMP
+	if cfg.PinFlow {
+		if _, ok := prober.(FlowProber); !ok {
+			return nil, errors.New(i18n.Tf("err.pinFlowUnsupported", map[string]interface{}{"Protocol": cfg.Protocol}))
+		}
+	}
+	if cfg.TCPRTTPort < 0 || cfg.TCPRTTPort > 65535 {
+		return nil, errors.New(i18n.Tf("err.tcpRTTPortInvalid", map[string]interface{}{"Port": cfg.TCPRTTPort}))
+	}
+	cfg.ApplyProtocolProfile()
+	if err := cfg.Safety.checkInterval(cfg.Interval); err != nil {
+		return nil, err
 	}
 
 	return &Controller{
@@ -54,31 +97,91 @@ func NewController(cfg *Config, prober Prober, resolver geoip.GeoResolver) (*Con
 		prober:   prober,
 		resolver: resolver,
 		hops:     make(map[int]*Hop, cfg.MaxHops),
+		loops:    make(map[string][]int),
 		events:   make(chan Event, 256),
+		done:     make(chan struct{}),
+		dest:     DestinationStatus{State: DestinationStateUnknown},
+		trigger:  make(chan struct{}, 1),
 	}, nil
 }
 
+// Target 返回用户指定的探测目标（域名或 IP）。
+func (c *Controller) Target() string {
+	return c.config.Target
+}
+
+// Config 返回当前配置的副本（含解析出的 TargetIP 与协议默认档位），可用于以新参数重建 Controller。
+func (c *Controller) Config() Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return *c.config
+}
+
+// Trigger 请求立即开始下一轮：跳过当前（或下一次）轮间等待；正在进行的轮次不受影响。
+func (c *Controller) Trigger() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// SetInterval 在运行中调整轮间隔，从下一次轮间等待开始生效；d<=0 时忽略。
+func (c *Controller) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if s := c.config.Safety; s != nil && d < s.MinInterval {
+		d = s.MinInterval
+	}
+	c.mu.Lock()
+	c.config.Interval = d
+	c.mu.Unlock()
+}
+
+// AddEventObserver 注册事件观察者，需在 Run 之前调用。观察者在产生事件的协程中同步调用（可能并发），
+// 与 Events 通道不同，不会因消费不及时而丢弃事件；实现不应阻塞。
+func (c *Controller) AddEventObserver(fn func(Event)) {
+	c.observers = append(c.observers, fn)
+}
+
 func (c *Controller) Events() <-chan Event {
 	return c.events
 }
 
-func (c *Controller) Run(ctx context.Context) error {
+func (c *Controller) Run(ctx context.Context) (err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 	defer func() {
+		c.warming.Wait()
+		c.mu.Lock()
+		c.runErr = err
+		c.mu.Unlock()
 		if c.events != nil {
 			close(c.events)
 		}
+		close(c.done)
 	}()
 
-	targetIP, err := resolveTargetIP(ctx, c.config.Target, c.config.IPVersion)
+	if err := c.config.Safety.acquire(); err != nil {
+		c.emit(Event{Type: EventTypeError, Err: err})
+		return err
+	}
+	defer c.config.Safety.release()
+
+	targetIP, err := resolveTargetIP(ctx, c.config.Target, c.config.IPVersion, c.config.Offline)
 	if err != nil {
 		c.emit(Event{Type: EventTypeError, Err: err})
 		return err
 	}
+	var dnsCheck *DNSCheck
+	if c.config.CheckDNS && !c.config.Offline && net.ParseIP(c.config.Target) == nil {
+		dnsCheck = checkTargetDNS(ctx, c.config.Target, targetIP)
+	}
+	// 校验结果随快照输出（文本报告末尾、TUI 状态栏与 JSON），不再单独发出告警事件，避免同一提示重复出现
 	c.mu.Lock()
 	c.config.TargetIP = targetIP.String()
+	c.dnsCheck = dnsCheck
 	c.mu.Unlock()
 	if err := c.prober.SetTarget(targetIP); err != nil {
 		c.emit(Event{Type: EventTypeError, Err: err})
@@ -90,33 +193,115 @@ func (c *Controller) Run(ctx context.Context) error {
 		rounds = -1
 	}
 
+	var pacer *gentlePacer
+	if c.config.Gentle {
+		pacer = newGentlePacer(c.config.Interval)
+	}
+
 	for round := 0; rounds < 0 || round < rounds; round++ {
 		if err := ctx.Err(); err != nil {
 			c.emit(Event{Type: EventTypeError, Err: err})
 			return err
 		}
 
+		c.mu.Lock()
+		c.round = round
+		c.progress = RoundProgress{Round: round, Started: time.Now()}
+		c.mu.Unlock()
+
+		dest := DestinationStatus{State: DestinationStateUnknown}
+		cur := RoundSnapshot{Round: round, StartedAt: time.Now()}
 		for ttl := 1; ttl <= c.config.MaxHops; ttl++ {
-			seq := round*c.config.MaxHops + ttl
-			res, probeErr := c.prober.Probe(ctx, ttl, seq)
+			if c.skipDarkHop(round, ttl) {
+				continue
+			}
+			seq := c.seq.next()
+			answeredBefore := c.hopAnswered(ttl)
+			c.setProbing(ttl, 1)
+			sentAt := time.Now()
+			res, probeErr := c.probe(ctx, ttl, seq)
+			c.setProbing(ttl, 0)
 			if probeErr != nil {
 				c.emit(Event{Type: EventTypeError, Err: probeErr})
 				return probeErr
 			}
+			if pacer != nil {
+				pacer.record(answeredBefore, res)
+			}
 			c.applyResult(ctx, ttl, res)
-			c.emit(Event{Type: EventTypeHopUpdated, TTL: ttl, Round: round})
+			cur.Hops = append(cur.Hops, newRoundHop(ttl, res))
+			var warnings []string
+			if msg := c.recordProbe(round, ttl, seq, sentAt, res); msg != "" {
+				warnings = append(warnings, msg)
+			}
+			if msg := c.checkSendFailure(ttl, res); msg != "" {
+				warnings = append(warnings, msg)
+			}
+			if msg := c.checkLoop(cur.Hops); msg != "" {
+				warnings = append(warnings, msg)
+			}
+			alerts := c.applyHook(ttl)
+			c.emit(Event{Type: EventTypeHopUpdated, TTL: ttl, Round: round, Hop: c.hopSnapshot(ttl)})
+			for _, msg := range warnings {
+				c.emit(Event{Type: EventTypeWarning, TTL: ttl, Round: round, Message: msg})
+			}
+			for _, msg := range alerts {
+				c.emit(Event{Type: EventTypeWarning, TTL: ttl, Round: round, Err: &HookAlertError{TTL: ttl, Message: msg}, Message: msg})
+			}
+			if err := c.checkTTLRewrite(ttl, res); err != nil {
+				c.emit(Event{Type: EventTypeWarning, TTL: ttl, Round: round, Err: err, Message: err.Error()})
+			}
 			if res != nil && res.Type == ResponseTypeEchoReply {
+				dest = DestinationStatus{State: DestinationStateReached, TTL: ttl, IP: res.IP.String()}
 				break
 			}
+			if res != nil && res.Type == ResponseTypeDestUnreach && isTerminalUnreach(res.Kind) {
+				if dest.State == DestinationStateUnknown {
+					dest = DestinationStatus{State: DestinationStateUnreachable, TTL: ttl, IP: res.IP.String(), Reason: res.Kind}
+				}
+				if !c.config.ContinueOnUnreachable {
+					break
+				}
+			}
 		}
+		c.mu.Lock()
+		c.dest = dest
+		c.progress.TTL = 0
+		c.mu.Unlock()
+		c.measureTCPRTT(ctx, targetIP)
+		cur.FinishedAt = time.Now()
+		cur.Dest = dest
+		c.recordRound(cur)
 
+		for _, v := range c.checkPolicy() {
+			c.emit(Event{Type: EventTypeWarning, Round: round, Message: v.Message()})
+		}
 		c.emit(Event{Type: EventTypeRoundCompleted, Round: round})
+		if change := c.reach.update(dest.State == DestinationStateReached, cur.FinishedAt); change != nil {
+			c.emit(Event{Type: EventTypeReachability, Round: round, Message: change.Message(), Reachability: change})
+		}
+		if round == 0 && (rounds < 0 || rounds > 1) {
+			// 首轮已发现完整路径，后台并行补全位置与主机名，不阻塞后续探测
+			c.warming.Add(1)
+			go func() {
+				defer c.warming.Done()
+				c.warmUp(ctx, round)
+			}()
+		}
 		if rounds < 0 || round != rounds-1 {
+			c.mu.RLock()
+			interval := c.config.Interval
+			c.mu.RUnlock()
+			if pacer != nil {
+				pacer.setTarget(interval)
+				interval = pacer.next()
+			}
 			select {
 			case <-ctx.Done():
 				c.emit(Event{Type: EventTypeError, Err: ctx.Err()})
 				return ctx.Err()
-			case <-time.After(c.config.Interval):
+			case <-c.trigger:
+			case <-time.After(interval):
 			}
 		}
 	}
@@ -125,6 +310,29 @@ func (c *Controller) Run(ctx context.Context) error {
 	return nil
 }
 
+// Done 在 Run 返回后关闭。
+func (c *Controller) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err 返回 Run 的最终错误；Run 尚未结束或被用户取消时返回 nil。
+func (c *Controller) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if errors.Is(c.runErr, context.Canceled) {
+		return nil
+	}
+	return c.runErr
+}
+
+// hopAnswered 判断 ttl 对应的跳点此前是否有过应答。
+func (c *Controller) hopAnswered(ttl int) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hop := c.hops[ttl]
+	return hop != nil && hop.Stats.Received > 0
+}
+
 func (c *Controller) applyResult(ctx context.Context, ttl int, res *ProbeResult) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -136,20 +344,40 @@ func (c *Controller) applyResult(ctx context.Context, ttl int, res *ProbeResult)
 	}
 
 	hop.Stats.Sent++
+	if res != nil {
+		hop.Stats.Suspicious += res.Suspicious
+	}
+	hop.SendFailed = res != nil && res.Type == ResponseTypeSendFailed
+	if hop.SendFailed {
+		hop.Lost = true
+		hop.Stats.Responses[ResponseTypeSendFailed.String()]++
+		hop.Stats.UpdateLoss()
+		return
+	}
 	if res == nil || res.Type == ResponseTypeTimeout || res.IP == nil {
 		hop.Lost = true
+		hop.Stats.Responses[ResponseTypeTimeout.String()]++
 		hop.Stats.UpdateLoss()
 		return
 	}
 
+	kind := res.Kind
+	if kind == "" {
+		kind = res.Type.String()
+	}
+	hop.Stats.Responses[kind]++
+
 	hop.Lost = false
 	ipChanged := hop.IP == nil || !hop.IP.Equal(res.IP)
 	hop.IP = res.IP
 	hop.Stats.Received++
 	hop.Stats.AddRTT(res.RTT)
 	hop.Stats.UpdateLoss()
+	if res.ICMPTimestamp != nil {
+		hop.ICMPTimestamp = res.ICMPTimestamp
+	}
 
-	if c.config.EnableDNS {
+	if c.config.reverseDNSEnabled() {
 		if hop.Hostname == "" || ipChanged {
 			hop.Hostname = reverseDNS(ctx, res.IP)
 		}
@@ -163,33 +391,150 @@ func (c *Controller) applyResult(ctx context.Context, ttl int, res *ProbeResult)
 	}
 }
 
+// probe 发送单个探测；开启 PinFlow 时固定使用 flow 0，使每轮的五元组保持一致。
+// 配置了按跳超时时，通过 ctx 截止时间施加本跳的超时（prober 以最长超时创建）。
+func (c *Controller) probe(ctx context.Context, ttl, seq int) (*ProbeResult, error) {
+	if err := c.config.Safety.wait(ctx); err != nil {
+		return nil, err
+	}
+	if len(c.config.HopTimeouts) > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.TimeoutFor(ttl))
+		defer cancel()
+	}
+	if c.config.PinFlow {
+		if fp, ok := c.prober.(FlowProber); ok {
+			return fp.ProbeFlow(ctx, ttl, seq, 0)
+		}
+	}
+	return c.prober.Probe(ctx, ttl, seq)
+}
+
+// anycast 返回疑似 anycast 的检测结果，调用方需持有 c.mu。
+func (c *Controller) anycast() *AnycastReport {
+	if r := DetectAnycast(c.rounds); r != nil && r.Probable {
+		return r
+	}
+	return nil
+}
+
+// checkSendFailure 在首次出现发送失败时返回告警，提示问题出在本机网络而非路径上。
+func (c *Controller) checkSendFailure(ttl int, res *ProbeResult) string {
+	if res == nil || res.Type != ResponseTypeSendFailed {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sendWarned {
+		return ""
+	}
+	c.sendWarned = true
+	reason := ""
+	if r, ok := c.prober.(probeErrorReporter); ok {
+		reason = r.ProbeErrors().LastSendError
+	}
+	return i18n.Tf("warn.sendFailed", map[string]interface{}{"TTL": ttl, "Error": reason})
+}
+
+// checkLoop 检查本轮最新一跳（hops 的最后一个）的响应 IP 是否已在本轮更小的 TTL 上出现；
+// 发现的环路累计到 c.loops，每个 IP 只告警一次。
+func (c *Controller) checkLoop(hops []RoundHop) string {
+	if len(hops) == 0 {
+		return ""
+	}
+	last := hops[len(hops)-1]
+	first := roundLoopTTL(hops[:len(hops)-1], last, c.config.TargetIP)
+	if first == 0 {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttls, warned := c.loops[last.IP]
+	c.loops[last.IP] = addLoopTTLs(ttls, first, last.TTL)
+	if warned {
+		return ""
+	}
+	return i18n.Tf("warn.routingLoop", map[string]interface{}{"IP": last.IP, "First": first, "Second": last.TTL})
+}
+
+// loopList 返回运行期间发现的环路，按首次出现的 TTL 排序；需持有读锁。
+func (c *Controller) loopList() []RoutingLoop {
+	if len(c.loops) == 0 {
+		return nil
+	}
+	out := make([]RoutingLoop, 0, len(c.loops))
+	for ip, ttls := range c.loops {
+		out = append(out, RoutingLoop{IP: ip, TTLs: append([]int(nil), ttls...)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TTLs[0] != out[j].TTLs[0] {
+			return out[i].TTLs[0] < out[j].TTLs[0]
+		}
+		return out[i].IP < out[j].IP
+	})
+	return out
+}
+
 func (c *Controller) Snapshot() *Snapshot {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	hops := make([]*Hop, 0, len(c.hops))
+	out := make([]SnapshotHop, 0, len(c.hops))
 	for _, hop := range c.hops {
-		hops = append(hops, hop)
+		out = append(out, hop.toSnapshot(c.config.DurationFormat))
 	}
-	sort.Slice(hops, func(i, j int) bool { return hops[i].TTL < hops[j].TTL })
+	sort.Slice(out, func(i, j int) bool { return out[i].TTL < out[j].TTL })
 
-	out := make([]SnapshotHop, 0, len(hops))
-	for _, hop := range hops {
-		out = append(out, hop.ToSnapshot())
+	var probeErrors ProbeErrorCounts
+	if r, ok := c.prober.(probeErrorReporter); ok {
+		probeErrors = r.ProbeErrors()
 	}
 
-	return &Snapshot{
-		SchemaVersion: 1,
+	s := &Snapshot{
+		SchemaVersion: CurrentSchemaVersion,
 		Target:        c.config.Target,
 		TargetIP:      c.config.TargetIP,
 		Protocol:      string(c.config.Protocol),
 		MaxHops:       c.config.MaxHops,
 		Count:         c.config.Count,
+		IntervalMs:    durationMs(c.config.Interval),
+		TimeoutMs:     durationMs(c.config.Timeout),
+		Profile:       c.config.Profile,
+		ProbeMode:     c.config.ProbeMode,
 		Hops:          out,
+		Loops:         c.loopList(),
+		ProbeErrors:   probeErrors,
+		Destination:   c.dest,
+		Markers:       append([]Marker(nil), c.markers...),
+		Anycast:       c.anycast(),
+		DNSCheck:      c.dnsCheck,
+		Violations:    append([]PolicyViolation(nil), c.violations...),
+		Violated:      append([]PolicyViolation(nil), c.violated...),
+		TCPRTT:        c.tcpRTTSnapshot(),
+
+		durationFormat: c.config.DurationFormat,
+		hostnameRules:  c.config.HostnameRules,
+	}
+	s.refreshPath()
+	return s
+}
+
+// hopSnapshot 返回单个 hop 的快照，用于 HopUpdated 事件的增量更新。
+func (c *Controller) hopSnapshot(ttl int) *SnapshotHop {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hop := c.hops[ttl]
+	if hop == nil {
+		return nil
 	}
+	out := hop.toSnapshot(c.config.DurationFormat)
+	return &out
 }
 
 func (c *Controller) emit(e Event) {
+	for _, fn := range c.observers {
+		fn(e)
+	}
 	if c.events == nil {
 		return
 	}
@@ -199,19 +544,67 @@ func (c *Controller) emit(e Event) {
 	}
 }
 
-func resolveTargetIP(ctx context.Context, target string, ipVersion int) (net.IP, error) {
-	ipAddr, err := net.DefaultResolver.LookupIPAddr(ctx, target)
+// resolveTargetIP 解析目标地址；offline 为 true 时只接受 IP 字面量，避免产生 DNS 查询。
+func resolveTargetIP(ctx context.Context, target string, ipVersion int, offline bool) (net.IP, error) {
+	ipAddr, err := lookupTarget(ctx, target, offline)
 	if err != nil {
-		return nil, errors.New(i18n.Tf("err.resolveTarget", map[string]interface{}{"Error": err.Error()}))
+		return nil, err
 	}
 	for _, a := range ipAddr {
-		if (ipVersion == 4 && a.IP.To4() != nil) || (ipVersion == 6 && a.IP.To4() == nil && a.IP.To16() != nil) {
+		if matchesIPVersion(a.IP, ipVersion) {
 			return a.IP, nil
 		}
 	}
 	return nil, errors.New(i18n.Tf("err.ipNotFound", map[string]interface{}{"Version": ipVersion, "Target": target}))
 }
 
+// ResolveTargetIPs 返回目标在指定地址族下解析到的全部地址（去重，保持解析顺序），供 --all-ips 逐一追踪。
+func ResolveTargetIPs(ctx context.Context, target string, ipVersion int, offline bool) ([]netip.Addr, error) {
+	ipAddr, err := lookupTarget(ctx, target, offline)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	seen := make(map[netip.Addr]bool, len(ipAddr))
+	for _, a := range ipAddr {
+		if !matchesIPVersion(a.IP, ipVersion) {
+			continue
+		}
+		addr, ok := netip.AddrFromSlice(a.IP)
+		if !ok {
+			continue
+		}
+		addr = addr.Unmap()
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New(i18n.Tf("err.ipNotFound", map[string]interface{}{"Version": ipVersion, "Target": target}))
+	}
+	return addrs, nil
+}
+
+func lookupTarget(ctx context.Context, target string, offline bool) ([]net.IPAddr, error) {
+	if offline {
+		ip := net.ParseIP(target)
+		if ip == nil {
+			return nil, errors.New(i18n.Tf("err.offlineTarget", map[string]interface{}{"Target": target}))
+		}
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+	ipAddr, err := net.DefaultResolver.LookupIPAddr(ctx, target)
+	if err != nil {
+		return nil, errors.New(i18n.Tf("err.resolveTarget", map[string]interface{}{"Error": err.Error()}))
+	}
+	return ipAddr, nil
+}
+
+func matchesIPVersion(ip net.IP, ipVersion int) bool {
+	return (ipVersion == 4 && ip.To4() != nil) || (ipVersion == 6 && ip.To4() == nil && ip.To16() != nil)
+}
+
 func reverseDNS(ctx context.Context, ip net.IP) string {
 	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
 	defer cancel()
@@ -0,0 +1,287 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// scriptedProber 按 TTL 返回预设的响应，未预设的 TTL 视为超时。
+type scriptedProber struct {
+	replies map[int]*ProbeResult
+	probed  []int
+}
+
+func (p *scriptedProber) Probe(_ context.Context, ttl, seq int) (*ProbeResult, error) {
+	p.probed = append(p.probed, ttl)
+	if r, ok := p.replies[ttl]; ok {
+		out := *r
+		out.TTL, out.Seq = ttl, seq
+		return &out, nil
+	}
+	return &ProbeResult{TTL: ttl, Seq: seq, Type: ResponseTypeTimeout}, nil
+}
+
+func (p *scriptedProber) SetTarget(net.IP) error { return nil }
+func (p *scriptedProber) Close() error           { return nil }
+
+func TestControllerStopsOnTerminalUnreachable(t *testing.T) {
+	replies := map[int]*ProbeResult{
+		1: {IP: net.ParseIP("10.0.0.1"), Type: ResponseTypeTimeExceeded, Kind: "time_exceeded"},
+		2: {IP: net.ParseIP("10.0.0.2"), Type: ResponseTypeDestUnreach, Kind: "dest_unreach/admin_prohibited"},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		continueOn bool
+		probed     int
+	}{
+		{name: "stop", probed: 2},
+		{name: "continue", continueOn: true, probed: 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			prober := &scriptedProber{replies: replies}
+			cfg := &Config{Target: "127.0.0.1", MaxHops: 5, Count: 1, Protocol: ProtocolICMP, IPVersion: 4, ContinueOnUnreachable: tc.continueOn}
+			c, err := NewController(cfg, prober, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if len(prober.probed) != tc.probed {
+				t.Fatalf("expected %d probes, got=%v", tc.probed, prober.probed)
+			}
+
+			want := DestinationStatus{State: DestinationStateUnreachable, TTL: 2, IP: "10.0.0.2", Reason: "dest_unreach/admin_prohibited"}
+			if got := c.Snapshot().Destination; got != want {
+				t.Fatalf("unexpected destination: %#v", got)
+			}
+		})
+	}
+}
+
+func TestIsTerminalUnreach(t *testing.T) {
+	if !isTerminalUnreach("dest_unreach/host") || isTerminalUnreach("dest_unreach/frag_needed") || isTerminalUnreach("time_exceeded") {
+		t.Fatal("unexpected terminal classification")
+	}
+}
+
+func TestResolveTargetIPOffline(t *testing.T) {
+	ip, err := resolveTargetIP(context.Background(), "192.0.2.1", 4, true)
+	if err != nil || !ip.Equal(net.ParseIP("192.0.2.1")) {
+		t.Fatalf("expected literal to pass through, got ip=%v err=%v", ip, err)
+	}
+	if _, err := resolveTargetIP(context.Background(), "example.com", 4, true); err == nil {
+		t.Fatal("expected hostname to be rejected in offline mode")
+	}
+	if _, err := resolveTargetIP(context.Background(), "2001:db8::1", 4, true); err == nil {
+		t.Fatal("expected IPv6 literal to be rejected for --ip-version 4")
+	}
+}
+
+func TestResolveTargetIPsOffline(t *testing.T) {
+	addrs, err := ResolveTargetIPs(context.Background(), "192.0.2.1", 4, true)
+	if err != nil || len(addrs) != 1 || addrs[0].String() != "192.0.2.1" {
+		t.Fatalf("expected literal to pass through, got %v err=%v", addrs, err)
+	}
+	if _, err := ResolveTargetIPs(context.Background(), "2001:db8::1", 4, true); err == nil {
+		t.Fatal("expected IPv6 literal to be rejected for --ip-version 4")
+	}
+}
+
+func TestControllerContinuesAfterSendFailure(t *testing.T) {
+	prober := &scriptedProber{replies: map[int]*ProbeResult{
+		1: {Type: ResponseTypeSendFailed},
+		2: {Type: ResponseTypeSendFailed},
+		3: {IP: net.ParseIP("127.0.0.1"), Type: ResponseTypeEchoReply, Kind: "echo_reply"},
+	}}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 5, Count: 1, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := 0
+	for e := range c.Events() {
+		if e.Type == EventTypeWarning {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Fatalf("expected a single send failure warning, got=%d", warnings)
+	}
+
+	s := c.Snapshot()
+	if len(s.Hops) != 3 {
+		t.Fatalf("expected 3 hops, got=%d", len(s.Hops))
+	}
+	hop := s.Hops[0]
+	if !hop.SendFailed || !hop.Lost || hop.Stats.Responses["send_failed"] != 1 || hop.Stats.Responses["timeout"] != 0 {
+		t.Fatalf("unexpected hop state: %+v", hop)
+	}
+	if s.Destination.State != DestinationStateReached {
+		t.Fatalf("expected destination reached, got=%v", s.Destination.State)
+	}
+}
+
+func TestProbeErrorCounterSendFailed(t *testing.T) {
+	var c probeErrorCounter
+	res, err := c.sendFailed(3, 7, &net.OpError{Op: "write", Err: errors.New("network is unreachable")})
+	if err != nil || res == nil || res.Type != ResponseTypeSendFailed || res.TTL != 3 || res.Seq != 7 {
+		t.Fatalf("unexpected result: %+v, %v", res, err)
+	}
+	if _, err := c.sendFailed(4, 8, net.ErrClosed); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("expected closed socket to be fatal, got=%v", err)
+	}
+	pe := c.ProbeErrors()
+	if pe.Send != 2 || pe.LastSendError != "write: network is unreachable" {
+		t.Fatalf("unexpected counts: %+v", pe)
+	}
+}
+
+// progressProber 在探测时记录 Controller 报告的进度。
+type progressProber struct {
+	c    *Controller
+	seen []RoundProgress
+}
+
+func (p *progressProber) Probe(_ context.Context, ttl, seq int) (*ProbeResult, error) {
+	p.seen = append(p.seen, p.c.Progress())
+	return &ProbeResult{TTL: ttl, Seq: seq, Type: ResponseTypeTimeout}, nil
+}
+
+func (p *progressProber) SetTarget(net.IP) error { return nil }
+func (p *progressProber) Close() error           { return nil }
+
+func TestControllerProgress(t *testing.T) {
+	prober := &progressProber{}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 3, Count: 1, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prober.c = c
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prober.seen) != 3 {
+		t.Fatalf("expected 3 probes, got=%d", len(prober.seen))
+	}
+	for i, p := range prober.seen {
+		if p.TTL != i+1 || p.InFlight != 1 || p.Round != 0 || p.Started.IsZero() {
+			t.Fatalf("unexpected progress at ttl %d: %+v", i+1, p)
+		}
+	}
+	if p := c.Progress(); p.TTL != 0 || p.InFlight != 0 {
+		t.Fatalf("expected idle progress after run, got=%+v", p)
+	}
+}
+
+func TestControllerTriggerSkipsInterval(t *testing.T) {
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 1, Count: 2, Interval: time.Hour, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &scriptedProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetInterval(time.Minute)
+	if got := c.Config().Interval; got != time.Minute {
+		t.Fatalf("expected interval to be updated, got=%v", got)
+	}
+	c.Trigger()
+	c.Trigger() // 重复请求不阻塞
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Run(ctx); err != nil {
+		t.Fatalf("expected second round to start without waiting, got=%v", err)
+	}
+}
+
+// staticPolicy 在 fail 为 true 时报告一条违规。
+type staticPolicy struct{ fail *bool }
+
+func (p staticPolicy) Evaluate(*Snapshot) []PolicyViolation {
+	if *p.fail {
+		return []PolicyViolation{{Policy: "p", Detail: "d"}}
+	}
+	return nil
+}
+
+func TestControllerPathPolicy(t *testing.T) {
+	fail := true
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 1, Count: 3, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &scriptedProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetPathPolicy(staticPolicy{fail: &fail})
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	warnings := 0
+	for ev := range c.Events() {
+		if ev.Type == EventTypeWarning {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Fatalf("expected a single alert for a persistent violation, got=%d", warnings)
+	}
+	if v := c.Snapshot().Violations; len(v) != 1 || v[0].Policy != "p" {
+		t.Fatalf("unexpected violations in snapshot: %+v", v)
+	}
+
+	fail = false
+	c.checkPolicy()
+	if v := c.Snapshot().Violations; len(v) != 0 {
+		t.Fatalf("expected violations cleared, got=%+v", v)
+	}
+	// 运行期间的违规保留在累计结果中，决定退出码
+	if v := c.Snapshot().Violated; len(v) != 1 || v[0].Policy != "p" {
+		t.Fatalf("expected cumulative violation kept, got=%+v", v)
+	}
+
+	// 路径来回变化时同一策略不重复告警
+	fail = true
+	if fresh := c.checkPolicy(); len(fresh) != 0 {
+		t.Fatalf("expected no repeated alert for a flapping policy, got=%+v", fresh)
+	}
+	if v := c.Snapshot().Violations; len(v) != 1 {
+		t.Fatalf("expected violation recorded again, got=%+v", v)
+	}
+}
+
+type alertHook struct{}
+
+func (alertHook) Evaluate(hop SnapshotHop) HookResult {
+	return HookResult{Alerts: []string{"alert"}}
+}
+
+func TestControllerHookAlertTyped(t *testing.T) {
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 1, Count: 1, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &scriptedProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetHopHook(alertHook{})
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	alerts := 0
+	for ev := range c.Events() {
+		var alert *HookAlertError
+		if ev.Type == EventTypeWarning && errors.As(ev.Err, &alert) && alert.TTL == 1 && ev.Message == "alert" {
+			alerts++
+		}
+	}
+	if alerts != 1 {
+		t.Fatalf("expected one typed hook alert, got=%d", alerts)
+	}
+}
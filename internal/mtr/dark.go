@@ -0,0 +1,26 @@
+package mtr
+
+// defaultDarkHopEvery 为 Config.DarkHopEvery 未设置时，对从未应答的 hop 的探测间隔轮数。
+const defaultDarkHopEvery = 5
+
+// skipDarkHop 判断本轮是否跳过对 ttl 的探测：连续 DarkHopAfter 次探测都未应答的 hop
+// 只在每 DarkHopEvery 轮中探测一次，把探测预算留给有应答的 hop；一旦应答即恢复每轮探测。
+func (c *Controller) skipDarkHop(round, ttl int) bool {
+	after := c.config.DarkHopAfter
+	if after <= 0 {
+		return false
+	}
+	every := c.config.DarkHopEvery
+	if every <= 0 {
+		every = defaultDarkHopEvery
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hop := c.hops[ttl]
+	if hop == nil {
+		return false
+	}
+	hop.Throttled = hop.Stats.Received == 0 && hop.Stats.Sent >= after
+	return hop.Throttled && round%every != 0
+}
@@ -0,0 +1,53 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestControllerThrottlesDarkHops(t *testing.T) {
+	prober := &scriptedProber{replies: map[int]*ProbeResult{
+		1: {IP: net.ParseIP("10.0.0.1"), Type: ResponseTypeTimeExceeded, Kind: "time_exceeded"},
+		3: {IP: net.ParseIP("127.0.0.1"), Type: ResponseTypeEchoReply, Kind: "echo_reply"},
+	}}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 3, Count: 9, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4, DarkHopAfter: 2, DarkHopEvery: 4}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[int]int)
+	for _, ttl := range prober.probed {
+		counts[ttl]++
+	}
+	// TTL 2 在第 0、1 轮后降频，之后只在第 4、8 轮探测
+	if counts[1] != 9 || counts[3] != 9 || counts[2] != 4 {
+		t.Fatalf("unexpected probe counts: %v", counts)
+	}
+	s := c.Snapshot()
+	if !s.Hops[1].Throttled || s.Hops[0].Throttled || s.Hops[1].Stats.Sent != 4 {
+		t.Fatalf("unexpected hop state: %+v / %+v", s.Hops[0], s.Hops[1])
+	}
+}
+
+func TestControllerDarkHopWakesUp(t *testing.T) {
+	prober := &scriptedProber{replies: map[int]*ProbeResult{}}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 1, Count: 1, Protocol: ProtocolICMP, IPVersion: 4, DarkHopAfter: 1, DarkHopEvery: 2}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.applyResult(context.Background(), 1, &ProbeResult{TTL: 1, Type: ResponseTypeTimeout})
+	if !c.skipDarkHop(1, 1) {
+		t.Fatal("expected dark hop to be skipped")
+	}
+	c.applyResult(context.Background(), 1, &ProbeResult{TTL: 1, IP: net.ParseIP("10.0.0.1"), Type: ResponseTypeTimeExceeded})
+	if c.skipDarkHop(1, 1) || c.Snapshot().Hops[0].Throttled {
+		t.Fatal("expected hop to be probed every round after answering")
+	}
+}
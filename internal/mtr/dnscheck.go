@@ -0,0 +1,63 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// DNSCheck 为目标域名的往返解析校验结果：A/AAAA 解析出的 IP 的 PTR 是否回到同一域名。
+type DNSCheck struct {
+	Name string   `json:"name"`
+	IP   string   `json:"ip"`
+	PTR  []string `json:"ptr,omitempty"`
+	// Match 表示至少一条 PTR 与目标属于同一注册域（eTLD+1）；没有 PTR 记录时为 false。
+	Match bool `json:"match"`
+}
+
+// Mismatch 判断是否需要提示：存在 PTR 记录但都不在目标所在的域内。
+// 没有 PTR 记录很常见，不视为配置错误。
+func (d *DNSCheck) Mismatch() bool {
+	return d != nil && len(d.PTR) > 0 && !d.Match
+}
+
+// Message 返回面向用户的不一致提示。
+func (d *DNSCheck) Message() string {
+	return i18n.Tf("warn.dnsMismatch", map[string]interface{}{"Name": d.Name, "IP": d.IP, "PTR": strings.Join(d.PTR, ", ")})
+}
+
+// checkTargetDNS 对目标 IP 做反向解析并与目标域名比较。
+func checkTargetDNS(ctx context.Context, name string, ip net.IP) *DNSCheck {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	names, _ := net.DefaultResolver.LookupAddr(ctx, ip.String())
+	return newDNSCheck(name, ip, names)
+}
+
+func newDNSCheck(name string, ip net.IP, ptrs []string) *DNSCheck {
+	d := &DNSCheck{Name: name, IP: ip.String()}
+	want := registrableDomain(name)
+	for _, p := range ptrs {
+		p = strings.TrimSuffix(p, ".")
+		d.PTR = append(d.PTR, p)
+		if want != "" && registrableDomain(p) == want {
+			d.Match = true
+		}
+	}
+	return d
+}
+
+// registrableDomain 返回域名的注册域（如 www.example.co.uk -> example.co.uk），无法判断时返回空串。
+func registrableDomain(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	d, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return ""
+	}
+	return d
+}
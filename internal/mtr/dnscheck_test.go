@@ -0,0 +1,27 @@
+package mtr
+
+import (
+	"net"
+	"testing"
+)
+
+func TestNewDNSCheck(t *testing.T) {
+	ip := net.ParseIP("192.0.2.10")
+	cases := []struct {
+		name     string
+		ptrs     []string
+		match    bool
+		mismatch bool
+	}{
+		{name: "www.example.co.uk", ptrs: []string{"edge-1.Example.co.uk."}, match: true},
+		{name: "www.example.com", ptrs: []string{"host.cdn.net.", "web.example.com."}, match: true},
+		{name: "www.example.com", ptrs: []string{"192-0-2-10.isp.example.net."}, mismatch: true},
+		{name: "www.example.com"},
+	}
+	for _, tc := range cases {
+		d := newDNSCheck(tc.name, ip, tc.ptrs)
+		if d.Match != tc.match || d.Mismatch() != tc.mismatch {
+			t.Fatalf("%s %v: match=%v mismatch=%v", tc.name, tc.ptrs, d.Match, d.Mismatch())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package mtr
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// ParseDSCP 解析 DSCP 取值：支持 0~63 的数字，以及 EF、BE/DF、CS0~CS7、AF11~AF43 等标准名称（不区分大小写）。
+func ParseDSCP(s string) (int, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	invalid := errors.New(i18n.Tf("err.dscpInvalid", map[string]interface{}{"Value": s}))
+	switch {
+	case name == "EF":
+		return 46, nil
+	case name == "BE" || name == "DF":
+		return 0, nil
+	case len(name) == 3 && strings.HasPrefix(name, "CS"):
+		n := int(name[2] - '0')
+		if n < 0 || n > 7 {
+			return 0, invalid
+		}
+		return n << 3, nil
+	case len(name) == 4 && strings.HasPrefix(name, "AF"):
+		class, drop := int(name[2]-'0'), int(name[3]-'0')
+		if class < 1 || class > 4 || drop < 1 || drop > 3 {
+			return 0, invalid
+		}
+		return class<<3 | drop<<1, nil
+	}
+	n, err := strconv.Atoi(name)
+	if err != nil || n < 0 || n > 63 {
+		return 0, invalid
+	}
+	return n, nil
+}
+
+// setTrafficClass 将 DSCP 写入 IPv4 TOS 或 IPv6 Traffic Class 的高 6 位；dscp 为 0 时保持系统默认。
+func setTrafficClass(p4 *ipv4.PacketConn, p6 *ipv6.PacketConn, dscp int) error {
+	if dscp == 0 {
+		return nil
+	}
+	if p4 != nil {
+		return p4.SetTOS(dscp << 2)
+	}
+	return p6.SetTrafficClass(dscp << 2)
+}
@@ -0,0 +1,19 @@
+package mtr
+
+import "testing"
+
+func TestParseDSCP(t *testing.T) {
+	for in, want := range map[string]int{
+		"EF": 46, "be": 0, "DF": 0, "cs1": 8, "CS7": 56, "AF11": 10, "af41": 34, "AF43": 38, "0": 0, "63": 63, " 26 ": 26,
+	} {
+		got, err := ParseDSCP(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseDSCP(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "XX", "64", "-1", "CS8", "AF51", "AF14"} {
+		if _, err := ParseDSCP(in); err == nil {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
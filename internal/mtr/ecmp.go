@@ -0,0 +1,267 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// FlowProber 支持以固定流标识探测的 prober：同一 flow 的所有探测包五元组相同，
+// 不同 flow 之间只改变流标识，用于枚举 ECMP 并行路径。
+type FlowProber interface {
+	Prober
+	ProbeFlow(ctx context.Context, ttl, seq, flow int) (*ProbeResult, error)
+}
+
+// ECMPGraph 为多条 flow 探测结果合并成的有向无环图。
+// 节点为（TTL, IP），边连接同一 flow 上相邻的两个有响应节点（中间的 * 会被跳过）。
+type ECMPGraph struct {
+	Target             string     `json:"target"`
+	TargetIP           string     `json:"target_ip"`
+	Protocol           string     `json:"protocol"`
+	ProbeMode          string     `json:"probe_mode,omitempty"`
+	Flows              int        `json:"flows"`
+	Nodes              []ECMPNode `json:"nodes"`
+	Edges              []ECMPEdge `json:"edges"`
+	MaxWidth           int        `json:"max_width"`
+	DestinationReached bool       `json:"destination_reached"`
+}
+
+type ECMPNode struct {
+	TTL      int    `json:"ttl"`
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+	Flows    []int  `json:"flows"`
+}
+
+type ECMPEdge struct {
+	FromTTL int    `json:"from_ttl"`
+	From    string `json:"from"`
+	ToTTL   int    `json:"to_ttl"`
+	To      string `json:"to"`
+	Flows   []int  `json:"flows"`
+}
+
+// NodesAt 返回指定 TTL 上的全部节点（按 IP 排序）。
+func (g *ECMPGraph) NodesAt(ttl int) []ECMPNode {
+	var out []ECMPNode
+	for _, n := range g.Nodes {
+		if n.TTL == ttl {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Parents 返回指向指定节点的边。
+func (g *ECMPGraph) Parents(ttl int, ip string) []ECMPEdge {
+	var out []ECMPEdge
+	for _, e := range g.Edges {
+		if e.ToTTL == ttl && e.To == ip {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// DiscoverECMP 对每个 TTL 依次发送 flows 条不同流标识的探测，重复 cfg.Count 轮，
+// 并将所有 flow 观测到的路径合并为 DAG。prober 需实现 FlowProber。
+func DiscoverECMP(ctx context.Context, cfg *Config, prober Prober, flows int) (*ECMPGraph, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fp, ok := prober.(FlowProber)
+	if !ok {
+		return nil, errors.New(i18n.Tf("err.ecmpUnsupported", map[string]interface{}{"Protocol": cfg.Protocol}))
+	}
+	if flows <= 0 {
+		return nil, errors.New(i18n.Tf("err.ecmpFlowsInvalid", map[string]interface{}{"Flows": flows}))
+	}
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = 30
+	}
+	cfg.ApplyProtocolProfile()
+	if err := cfg.Safety.checkInterval(cfg.Interval); err != nil {
+		return nil, err
+	}
+	// 与 Controller.Run 一样计入同时探测的目标数
+	if err := cfg.Safety.acquire(); err != nil {
+		return nil, err
+	}
+	defer cfg.Safety.release()
+
+	targetIP, err := resolveTargetIP(ctx, cfg.Target, cfg.IPVersion, cfg.Offline)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TargetIP = targetIP.String()
+	if err := fp.SetTarget(targetIP); err != nil {
+		return nil, err
+	}
+
+	rounds := cfg.Count
+	if rounds <= 0 {
+		rounds = 1
+	}
+
+	b := newECMPBuilder(cfg.TargetIP)
+	seq := 0
+	for round := 0; round < rounds; round++ {
+		if round > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(cfg.Interval):
+			}
+		}
+
+		b.startRound()
+		reached := make([]bool, flows)
+		for ttl := 1; ttl <= cfg.MaxHops; ttl++ {
+			active := false
+			for flow := 0; flow < flows; flow++ {
+				if reached[flow] {
+					continue
+				}
+				active = true
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				if err := cfg.Safety.wait(ctx); err != nil {
+					return nil, err
+				}
+				seq++
+				res, err := fp.ProbeFlow(ctx, ttl, seq, flow)
+				if err != nil {
+					return nil, err
+				}
+				if res == nil || res.IP == nil {
+					continue
+				}
+				b.add(flow, ttl, res.IP.String())
+				if res.Type == ResponseTypeEchoReply {
+					reached[flow] = true
+				}
+			}
+			if !active {
+				break
+			}
+		}
+	}
+
+	g := b.graph()
+	g.Target = cfg.Target
+	g.Protocol = string(cfg.Protocol)
+	g.ProbeMode = cfg.ProbeMode
+	g.Flows = flows
+	if cfg.reverseDNSEnabled() {
+		for i := range g.Nodes {
+			if ip := net.ParseIP(g.Nodes[i].IP); ip != nil {
+				g.Nodes[i].Hostname = reverseDNS(ctx, ip)
+			}
+		}
+	}
+	return g, nil
+}
+
+type ecmpKey struct {
+	ttl int
+	ip  string
+}
+
+type ecmpBuilder struct {
+	targetIP string
+	nodes    map[ecmpKey]map[int]bool
+	edges    map[[2]ecmpKey]map[int]bool
+	// last 记录每个 flow 在本轮最近一次有响应的节点
+	last map[int]ecmpKey
+}
+
+func newECMPBuilder(targetIP string) *ecmpBuilder {
+	return &ecmpBuilder{
+		targetIP: targetIP,
+		nodes:    make(map[ecmpKey]map[int]bool),
+		edges:    make(map[[2]ecmpKey]map[int]bool),
+		last:     make(map[int]ecmpKey),
+	}
+}
+
+// startRound 在每轮开始时清空 flow 的前驱节点，避免跨轮连边。
+func (b *ecmpBuilder) startRound() {
+	b.last = make(map[int]ecmpKey)
+}
+
+func (b *ecmpBuilder) add(flow, ttl int, ip string) {
+	k := ecmpKey{ttl: ttl, ip: ip}
+	if b.nodes[k] == nil {
+		b.nodes[k] = make(map[int]bool)
+	}
+	b.nodes[k][flow] = true
+
+	if prev, ok := b.last[flow]; ok && prev.ttl < ttl {
+		ek := [2]ecmpKey{prev, k}
+		if b.edges[ek] == nil {
+			b.edges[ek] = make(map[int]bool)
+		}
+		b.edges[ek][flow] = true
+	}
+	b.last[flow] = k
+}
+
+func (b *ecmpBuilder) graph() *ECMPGraph {
+	g := &ECMPGraph{TargetIP: b.targetIP, Nodes: []ECMPNode{}, Edges: []ECMPEdge{}}
+	width := make(map[int]int)
+	for k, flows := range b.nodes {
+		g.Nodes = append(g.Nodes, ECMPNode{TTL: k.ttl, IP: k.ip, Flows: sortedFlows(flows)})
+		width[k.ttl]++
+		if width[k.ttl] > g.MaxWidth {
+			g.MaxWidth = width[k.ttl]
+		}
+		if k.ip == b.targetIP {
+			g.DestinationReached = true
+		}
+	}
+	for k, flows := range b.edges {
+		g.Edges = append(g.Edges, ECMPEdge{
+			FromTTL: k[0].ttl,
+			From:    k[0].ip,
+			ToTTL:   k[1].ttl,
+			To:      k[1].ip,
+			Flows:   sortedFlows(flows),
+		})
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].TTL != g.Nodes[j].TTL {
+			return g.Nodes[i].TTL < g.Nodes[j].TTL
+		}
+		return g.Nodes[i].IP < g.Nodes[j].IP
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, c := g.Edges[i], g.Edges[j]
+		if a.ToTTL != c.ToTTL {
+			return a.ToTTL < c.ToTTL
+		}
+		if a.To != c.To {
+			return a.To < c.To
+		}
+		if a.FromTTL != c.FromTTL {
+			return a.FromTTL < c.FromTTL
+		}
+		return a.From < c.From
+	})
+	return g
+}
+
+func sortedFlows(set map[int]bool) []int {
+	out := make([]int, 0, len(set))
+	for f := range set {
+		out = append(out, f)
+	}
+	sort.Ints(out)
+	return out
+}
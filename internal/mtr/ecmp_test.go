@@ -0,0 +1,53 @@
+package mtr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestECMPBuilderGraph(t *testing.T) {
+	b := newECMPBuilder("10.0.9.9")
+	b.startRound()
+	// flow 0: 10.0.0.1 -> 10.1.0.1 -> 10.0.9.9
+	b.add(0, 1, "10.0.0.1")
+	b.add(0, 2, "10.1.0.1")
+	b.add(0, 3, "10.0.9.9")
+	// flow 1: 10.0.0.1 -> 10.1.0.2 -> * -> 10.0.9.9
+	b.add(1, 1, "10.0.0.1")
+	b.add(1, 2, "10.1.0.2")
+	b.add(1, 4, "10.0.9.9")
+
+	g := b.graph()
+	if !g.DestinationReached || g.MaxWidth != 2 {
+		t.Fatalf("unexpected graph summary: reached=%v width=%d", g.DestinationReached, g.MaxWidth)
+	}
+	if len(g.Nodes) != 5 {
+		t.Fatalf("unexpected nodes: %#v", g.Nodes)
+	}
+	if got := g.NodesAt(1); len(got) != 1 || !reflect.DeepEqual(got[0].Flows, []int{0, 1}) {
+		t.Fatalf("unexpected ttl=1 nodes: %#v", got)
+	}
+	if got := g.NodesAt(2); len(got) != 2 || got[0].IP != "10.1.0.1" || got[1].IP != "10.1.0.2" {
+		t.Fatalf("unexpected ttl=2 nodes: %#v", got)
+	}
+
+	parents := g.Parents(4, "10.0.9.9")
+	want := []ECMPEdge{{FromTTL: 2, From: "10.1.0.2", ToTTL: 4, To: "10.0.9.9", Flows: []int{1}}}
+	if !reflect.DeepEqual(parents, want) {
+		t.Fatalf("unexpected parents: %#v", parents)
+	}
+}
+
+func TestECMPBuilderResetsAcrossRounds(t *testing.T) {
+	b := newECMPBuilder("10.0.9.9")
+	b.startRound()
+	b.add(0, 1, "10.0.0.1")
+	b.add(0, 2, "10.0.9.9")
+	b.startRound()
+	b.add(0, 3, "10.0.0.3")
+
+	g := b.graph()
+	if len(g.Edges) != 1 {
+		t.Fatalf("expected a single edge, got=%#v", g.Edges)
+	}
+}
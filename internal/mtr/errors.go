@@ -0,0 +1,61 @@
+package mtr
+
+import (
+	"fmt"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+var (
+	// ErrTargetNotSet 表示在 SetTarget 之前调用了 Probe。
+	ErrTargetNotSet = localizedError("err.probeTargetNotSet")
+	// ErrTargetNil 表示 SetTarget 传入了空地址。
+	ErrTargetNil = localizedError("err.probeTargetNil")
+	// ErrProtocolNotImplemented 表示协议已定义但尚无对应的探测器实现。
+	ErrProtocolNotImplemented = localizedError("err.protocolNotImplemented")
+)
+
+// localizedError 为按消息 ID 延迟翻译的哨兵错误（翻译发生在 Error() 时，
+// 保证 i18n 初始化之后才确定语言），可直接用于 errors.Is 比较。
+type localizedError string
+
+func (e localizedError) Error() string { return i18n.T(string(e)) }
+
+// PermissionError 表示因权限不足无法创建原始套接字。
+type PermissionError struct {
+	Err error
+}
+
+func (e *PermissionError) Error() string {
+	return i18n.Tf("err.rawSocketPermission", map[string]interface{}{"Error": e.Err.Error()})
+}
+
+func (e *PermissionError) Unwrap() error { return e.Err }
+
+// UnknownProtocolError 表示 Config 中的协议名无法识别。
+type UnknownProtocolError struct {
+	Protocol Protocol
+}
+
+func (e *UnknownProtocolError) Error() string {
+	return i18n.Tf("err.protocolUnknown", map[string]interface{}{"Protocol": fmt.Sprint(e.Protocol)})
+}
+
+// HookAlertError 表示 HopHook 新触发的告警（如 --hop-rule 的 alert 规则），
+// 作为 EventTypeWarning 事件的 Err 发出，非 TUI 模式据此在探测过程中实时输出。
+type HookAlertError struct {
+	TTL     int
+	Message string
+}
+
+func (e *HookAlertError) Error() string { return e.Message }
+
+// TTLRewrittenError 表示 TTL 疑似被 VPN/隧道或中间设备改写（见 checkTTLRewrite），
+// 作为 EventTypeWarning 事件的 Err 发出，便于调用方单独识别这条告警。
+type TTLRewrittenError struct {
+	IP string
+}
+
+func (e *TTLRewrittenError) Error() string {
+	return i18n.Tf("warn.ttlRewritten", map[string]interface{}{"IP": e.IP})
+}
@@ -0,0 +1,35 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+func TestProberErrorsAreTyped(t *testing.T) {
+	i18n.SetLanguage("en")
+	t.Cleanup(func() { i18n.SetLanguage("") })
+
+	p := &UDPProber{}
+	if _, err := p.Probe(context.Background(), 1, 1); !errors.Is(err, ErrTargetNotSet) {
+		t.Fatalf("expected ErrTargetNotSet, got=%v", err)
+	}
+	if err := p.SetTarget(nil); !errors.Is(err, ErrTargetNil) || err.Error() != "target ip cannot be empty" {
+		t.Fatalf("unexpected SetTarget error: %v", err)
+	}
+
+	var err error = &PermissionError{Err: syscall.EPERM}
+	if !errors.Is(err, os.ErrPermission) || !looksLikePermission(syscall.EACCES) {
+		t.Fatalf("expected permission error to unwrap, got=%v", err)
+	}
+
+	_, err = NewProber(&Config{Protocol: "bogus", IPVersion: 4})
+	var unknown *UnknownProtocolError
+	if !errors.As(err, &unknown) || unknown.Protocol != "bogus" {
+		t.Fatalf("expected UnknownProtocolError, got=%v", err)
+	}
+}
@@ -7,11 +7,41 @@ const (
 	EventTypeRoundCompleted
 	EventTypeDone
 	EventTypeError
+	// EventTypeWarning 为非致命告警（如检测到路由环路），Message 为可展示的描述；
+	// 需要单独识别的告警（如 TTLRewrittenError）同时带有 Err。
+	EventTypeWarning
+	// EventTypeReachability 为目标可达性变化（失去/恢复），Reachability 为详情，Message 为可展示的描述。
+	EventTypeReachability
 )
 
+// String 返回事件类型的名称（用于 JSONL 事件流等输出）。
+func (t EventType) String() string {
+	switch t {
+	case EventTypeHopUpdated:
+		return "hop"
+	case EventTypeRoundCompleted:
+		return "round"
+	case EventTypeDone:
+		return "done"
+	case EventTypeError:
+		return "error"
+	case EventTypeWarning:
+		return "warning"
+	case EventTypeReachability:
+		return "reachability"
+	default:
+		return "unknown"
+	}
+}
+
 type Event struct {
-	Type  EventType
-	TTL   int
-	Round int
-	Err   error
+	Type    EventType
+	TTL     int
+	Round   int
+	Err     error
+	Message string
+	// Hop 为 HopUpdated 事件对应 hop 的最新快照，可配合 Snapshot.ApplyHop 增量刷新。
+	Hop *SnapshotHop
+	// Reachability 仅 EventTypeReachability 事件携带。
+	Reachability *ReachabilityChange
 }
@@ -0,0 +1,341 @@
+//go:build linux
+
+package mtr
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// newUnprivilegedProber 返回当前平台可用的无特权探测器。
+// Linux 的数据报 ICMP 套接字不会投递中间跳的错误报文，因此改用 IP_RECVERR 方式的 UDP 探测。
+func newUnprivilegedProber(opts ProberOptions) (Prober, string, error) {
+	return newRecvErrUDPProber(opts), ProbeModeUDPRecvErr, nil
+}
+
+// checkUnprivilegedProbe 按 recvErrUDPProber 的方式打开 UDP 套接字并开启 IP_RECVERR；
+// 仅 connect 到文档保留地址，不会发送任何报文。
+func checkUnprivilegedProbe(ipVersion int) (string, error) {
+	network, raddr := "udp4", "192.0.2.1:33434"
+	level, opt := syscall.SOL_IP, syscall.IP_RECVERR
+	if ipVersion == 6 {
+		network, raddr = "udp6", "[2001:db8::1]:33434"
+		level, opt = syscall.SOL_IPV6, syscall.IPV6_RECVERR
+	}
+	conn, err := net.Dial(network, raddr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	rc, err := conn.(*net.UDPConn).SyscallConn()
+	if err != nil {
+		return "", err
+	}
+	var sockErr error
+	if err := rc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), level, opt, 1)
+	}); err != nil {
+		return "", err
+	}
+	if sockErr != nil {
+		return "", sockErr
+	}
+	return ProbeModeUDPRecvErr, nil
+}
+
+// recvErrUDPProber 通过普通 UDP 套接字发送探测包，并开启 IP_RECVERR，
+// 从套接字错误队列中读取路由器返回的 ICMP 错误及其来源地址（tracepath 的做法），无需原始套接字。
+type recvErrUDPProber struct {
+	ipVersion int
+	timeout   time.Duration
+	target    net.IP
+	basePort  int
+	localAddr net.IP
+	dscp      int
+	// flowPort 为 ProbeFlow 固定使用的源端口，首次探测时由系统分配后保持不变。
+	flowPort int
+
+	errs probeErrorCounter
+}
+
+// sock_extended_err 的 ee_origin 取值。
+const (
+	soEEOriginICMP  = 2
+	soEEOriginICMP6 = 3
+)
+
+func newRecvErrUDPProber(opts ProberOptions) *recvErrUDPProber {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return &recvErrUDPProber{
+		ipVersion: opts.IPVersion,
+		timeout:   timeout,
+		basePort:  33434,
+		localAddr: opts.Source,
+		dscp:      opts.DSCP,
+	}
+}
+
+func (p *recvErrUDPProber) SetTarget(ip net.IP) error {
+	if ip == nil {
+		return ErrTargetNil
+	}
+	p.target = ip
+	return nil
+}
+
+func (p *recvErrUDPProber) ProbeErrors() ProbeErrorCounts { return p.errs.ProbeErrors() }
+
+// Close 无需释放资源：每次探测使用独立的套接字。
+func (p *recvErrUDPProber) Close() error { return nil }
+
+func (p *recvErrUDPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult, error) {
+	return p.probe(ctx, ttl, seq, p.basePort+(seq%10000), false)
+}
+
+// ProbeFlow 与 UDPProber.ProbeFlow 相同：目的端口由 flow 决定，源端口在 prober 生命周期内不变。
+// 每次探测使用独立的套接字，无需像 UDPProber 那样用负载长度区分回包。
+func (p *recvErrUDPProber) ProbeFlow(ctx context.Context, ttl, seq, flow int) (*ProbeResult, error) {
+	return p.probe(ctx, ttl, seq, p.basePort+flow, true)
+}
+
+func (p *recvErrUDPProber) probe(ctx context.Context, ttl, seq, destPort int, pinSource bool) (*ProbeResult, error) {
+	if p.target == nil {
+		return nil, ErrTargetNotSet
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	network := "udp4"
+	if p.ipVersion == 6 {
+		network = "udp6"
+	}
+	var laddr *net.UDPAddr
+	if p.localAddr != nil || (pinSource && p.flowPort != 0) {
+		laddr = &net.UDPAddr{IP: p.localAddr}
+		if pinSource {
+			laddr.Port = p.flowPort
+		}
+	}
+	// 每次探测使用新的套接字，错误队列中只会出现本次探测的回包
+	conn, err := net.DialUDP(network, laddr, &net.UDPAddr{IP: p.target, Port: destPort})
+	if err != nil {
+		return p.errs.sendFailed(ttl, seq, err)
+	}
+	defer conn.Close()
+	if pinSource && p.flowPort == 0 {
+		p.flowPort = conn.LocalAddr().(*net.UDPAddr).Port
+	}
+
+	if p.ipVersion == 4 {
+		pc := ipv4.NewPacketConn(conn)
+		if err = setProbeTTL(pc, nil, ttl); err == nil {
+			err = setTrafficClass(pc, nil, p.dscp)
+		}
+	} else {
+		pc := ipv6.NewPacketConn(conn)
+		if err = setProbeTTL(nil, pc, ttl); err == nil {
+			err = setTrafficClass(nil, pc, p.dscp)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	level, opt := syscall.SOL_IP, syscall.IP_RECVERR
+	if p.ipVersion == 6 {
+		level, opt = syscall.SOL_IPV6, syscall.IPV6_RECVERR
+	}
+	var sockErr error
+	if err := rc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), level, opt, 1)
+	}); err != nil {
+		return nil, err
+	}
+	if sockErr != nil {
+		return nil, sockErr
+	}
+
+	payload := make([]byte, 8)
+	copy(payload[:4], []byte("mymt"))
+	binary.BigEndian.PutUint32(payload[4:], uint32(seq))
+
+	start := time.Now()
+	if _, err := conn.Write(payload); err != nil {
+		return p.errs.sendFailed(ttl, seq, err)
+	}
+
+	deadline := start.Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetReadDeadline(deadline)
+	unblock := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetReadDeadline(time.Now())
+		case <-unblock:
+		}
+	}()
+	defer close(unblock)
+
+	timeout := &ProbeResult{TTL: ttl, Seq: seq, Type: ResponseTypeTimeout, Timestamp: start}
+	buf := make([]byte, 1500)
+	oob := make([]byte, 512)
+	for {
+		var ee *recvErr
+		var gotData bool
+		var readErr error
+		err := rc.Read(func(fd uintptr) bool {
+			_, oobn, _, _, err := syscall.Recvmsg(int(fd), buf, oob, syscall.MSG_ERRQUEUE)
+			if err == nil {
+				ee = parseRecvErr(oob[:oobn])
+				return true
+			}
+			if !errors.Is(err, syscall.EAGAIN) {
+				readErr = err
+				return true
+			}
+			// 错误队列为空时检查普通数据：目标端口上有 UDP 服务时会直接应答
+			if _, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_DONTWAIT); err == nil {
+				gotData = true
+				return true
+			} else if !errors.Is(err, syscall.EAGAIN) && !errors.Is(err, syscall.ECONNREFUSED) && !errors.Is(err, syscall.EHOSTUNREACH) {
+				readErr = err
+				return true
+			}
+			return false
+		})
+		if err != nil {
+			if ctx.Err() != nil || isTimeout(err) {
+				return timeout, nil
+			}
+			return nil, err
+		}
+		if readErr != nil {
+			p.errs.read.Add(1)
+			return timeout, nil
+		}
+
+		if gotData {
+			return &ProbeResult{
+				TTL:       ttl,
+				Seq:       seq,
+				IP:        p.target,
+				RTT:       time.Since(start),
+				Type:      ResponseTypeEchoReply,
+				Timestamp: start,
+			}, nil
+		}
+		if ee == nil {
+			// 本地产生的错误（如 EMSGSIZE）不对应任何跳点
+			p.errs.parse.Add(1)
+			continue
+		}
+
+		typ, rm, ok := p.classify(ee)
+		if !ok {
+			continue
+		}
+		return &ProbeResult{
+			TTL:       ttl,
+			Seq:       seq,
+			IP:        ee.offender,
+			RTT:       time.Since(start),
+			Type:      typ,
+			Timestamp: start,
+			Kind:      responseKind(p.ipVersion, typ, rm),
+		}, nil
+	}
+}
+
+// classify 将错误队列中的 ICMP 类型映射为响应类型，并构造等价的 icmp.Message 以复用响应分类逻辑。
+func (p *recvErrUDPProber) classify(ee *recvErr) (ResponseType, *icmp.Message, bool) {
+	rm := &icmp.Message{Code: ee.code}
+	if p.ipVersion == 6 {
+		rm.Type = ipv6.ICMPType(ee.typ)
+	} else {
+		rm.Type = ipv4.ICMPType(ee.typ)
+	}
+	switch rm.Type {
+	case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
+		return ResponseTypeTimeExceeded, rm, true
+	case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
+		if isPortUnreachable(rm) {
+			return ResponseTypeEchoReply, rm, true
+		}
+		return ResponseTypeDestUnreach, rm, true
+	}
+	return ResponseTypeTimeout, nil, false
+}
+
+// recvErr 为从 IP_RECVERR 控制消息中解析出的 ICMP 错误。
+type recvErr struct {
+	typ      int
+	code     int
+	offender net.IP
+}
+
+// parseRecvErr 解析 struct sock_extended_err 及紧随其后的 offender 地址（SO_EE_OFFENDER）；
+// 非 ICMP 来源的错误返回 nil。
+func parseRecvErr(oob []byte) *recvErr {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	for _, m := range msgs {
+		isV4 := m.Header.Level == syscall.SOL_IP && m.Header.Type == syscall.IP_RECVERR
+		isV6 := m.Header.Level == syscall.SOL_IPV6 && m.Header.Type == syscall.IPV6_RECVERR
+		if !isV4 && !isV6 {
+			continue
+		}
+		return decodeExtendedErr(m.Data)
+	}
+	return nil
+}
+
+func decodeExtendedErr(data []byte) *recvErr {
+	// ee_errno(4) ee_origin(1) ee_type(1) ee_code(1) ee_pad(1) ee_info(4) ee_data(4)
+	const eeLen = 16
+	if len(data) < eeLen {
+		return nil
+	}
+	origin := data[4]
+	if origin != soEEOriginICMP && origin != soEEOriginICMP6 {
+		return nil
+	}
+	ee := &recvErr{typ: int(data[5]), code: int(data[6])}
+
+	// offender 为 sockaddr_in / sockaddr_in6，sa_family 为主机字节序
+	sa := data[eeLen:]
+	if len(sa) < 2 {
+		return ee
+	}
+	switch binary.NativeEndian.Uint16(sa[0:2]) {
+	case syscall.AF_INET:
+		if len(sa) >= 8 {
+			ee.offender = net.IP(append([]byte(nil), sa[4:8]...))
+		}
+	case syscall.AF_INET6:
+		if len(sa) >= 24 {
+			ee.offender = net.IP(append([]byte(nil), sa[8:24]...))
+		}
+	}
+	return ee
+}
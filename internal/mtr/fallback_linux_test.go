@@ -0,0 +1,76 @@
+//go:build linux
+
+package mtr
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDecodeExtendedErr(t *testing.T) {
+	data := make([]byte, 16+16)
+	data[4] = soEEOriginICMP
+	data[5] = 11 // time exceeded
+	data[6] = 0
+	binary.NativeEndian.PutUint16(data[16:18], syscall.AF_INET)
+	copy(data[20:24], net.ParseIP("192.0.2.7").To4())
+
+	ee := decodeExtendedErr(data)
+	if ee == nil || ee.typ != 11 || !ee.offender.Equal(net.ParseIP("192.0.2.7")) {
+		t.Fatalf("unexpected decode: %#v", ee)
+	}
+
+	p := newRecvErrUDPProber(ProberOptions{IPVersion: 4})
+	if typ, _, ok := p.classify(ee); !ok || typ != ResponseTypeTimeExceeded {
+		t.Fatalf("expected time exceeded, got %v ok=%v", typ, ok)
+	}
+	if typ, rm, ok := p.classify(&recvErr{typ: 3, code: 3}); !ok || typ != ResponseTypeEchoReply || responseKind(4, typ, rm) != "dest_unreach/port" {
+		t.Fatalf("expected port unreachable to mark destination, got %v", typ)
+	}
+
+	// 本地产生的错误（ee_origin=LOCAL）不对应跳点
+	data[4] = 1
+	if decodeExtendedErr(data) != nil {
+		t.Fatal("expected local-origin error to be ignored")
+	}
+}
+
+func TestNewProberWithFallbackUnprivileged(t *testing.T) {
+	cfg := &Config{Protocol: ProtocolICMP, IPVersion: 4, Unprivileged: true}
+	prober, err := NewProberWithFallback(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer prober.Close()
+	if _, ok := prober.(*recvErrUDPProber); !ok {
+		t.Fatalf("expected recverr prober, got=%T", prober)
+	}
+	if cfg.ProbeMode != ProbeModeUDPRecvErr {
+		t.Fatalf("unexpected probe mode: %q", cfg.ProbeMode)
+	}
+}
+
+func TestRecvErrProberFlowKeepsSourcePort(t *testing.T) {
+	p := newRecvErrUDPProber(ProberOptions{IPVersion: 4, Timeout: 50 * time.Millisecond})
+	if err := p.SetTarget(net.IPv4(127, 0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	var _ FlowProber = p
+	if _, err := p.ProbeFlow(context.Background(), 1, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	port := p.flowPort
+	if port == 0 {
+		t.Fatal("expected source port pinned after the first flow probe")
+	}
+	if _, err := p.ProbeFlow(context.Background(), 2, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if p.flowPort != port {
+		t.Fatalf("source port changed: %d -> %d", port, p.flowPort)
+	}
+}
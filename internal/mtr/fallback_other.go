@@ -0,0 +1,22 @@
+//go:build !linux
+
+package mtr
+
+// newUnprivilegedProber 返回当前平台可用的无特权探测器。
+// 非 Linux 平台（如 macOS）的数据报 ICMP 套接字能收到中间跳的 Time Exceeded，可直接用于逐跳探测。
+func newUnprivilegedProber(opts ProberOptions) (Prober, string, error) {
+	p, err := newDgramICMPProber(opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, ProbeModeICMPDgram, nil
+}
+
+// checkUnprivilegedProbe 尝试打开数据报 ICMP 套接字后立即关闭。
+func checkUnprivilegedProbe(ipVersion int) (string, error) {
+	p, err := newDgramICMPProber(ProberOptions{IPVersion: ipVersion})
+	if err != nil {
+		return "", err
+	}
+	return ProbeModeICMPDgram, p.Close()
+}
@@ -0,0 +1,66 @@
+package mtr
+
+import (
+	"net"
+	"strings"
+)
+
+// hopOwner 为判断 hop 归属所用的标识：优先使用地理库中的运营商，其次使用地址前缀（IPv4 /16，IPv6 /32）。
+type hopOwner struct {
+	isp    string
+	prefix string
+}
+
+func ownerOf(hop SnapshotHop) (hopOwner, bool) {
+	ip := net.ParseIP(hop.IP)
+	if ip == nil {
+		return hopOwner{}, false
+	}
+	var o hopOwner
+	if hop.Location != nil {
+		o.isp = strings.TrimSpace(hop.Location.ISP)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		o.prefix = v4.Mask(net.CIDRMask(16, 32)).String()
+	} else {
+		o.prefix = ip.Mask(net.CIDRMask(32, 128)).String()
+	}
+	return o, true
+}
+
+// sameOwner 三者都有运营商信息时按运营商比较，否则按地址前缀比较，避免混用两种口径。
+func sameOwner(a, b hopOwner, all ...hopOwner) bool {
+	useISP := a.isp != "" && b.isp != ""
+	for _, o := range all {
+		useISP = useISP && o.isp != ""
+	}
+	if useISP {
+		return a.isp == b.isp
+	}
+	return a.prefix == b.prefix
+}
+
+// detectForeignHops 标记 ICMP 源地址归属与前后相邻 hop 不一致的 hop：
+// 前后两个有响应的 hop 属于同一运营商/前缀，而夹在中间的 hop 不是。
+// 这通常是无编号接口、互联链路使用对端地址或第三方地址段造成的，
+// 该 hop 的问题往往并不属于其地址所显示的网络。hops 需按 TTL 升序排列。
+func detectForeignHops(hops []SnapshotHop) {
+	idx := make([]int, 0, len(hops))
+	for i, hop := range hops {
+		if hop.IP != "" {
+			idx = append(idx, i)
+		}
+	}
+	for k := 1; k+1 < len(idx); k++ {
+		prev, cur, next := hops[idx[k-1]], hops[idx[k]], hops[idx[k+1]]
+		if prev.IP == cur.IP || next.IP == cur.IP {
+			continue
+		}
+		po, _ := ownerOf(prev)
+		co, _ := ownerOf(cur)
+		no, _ := ownerOf(next)
+		if sameOwner(po, no, co) && !sameOwner(co, po, no) {
+			hops[idx[k]].Foreign = true
+		}
+	}
+}
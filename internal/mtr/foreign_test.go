@@ -0,0 +1,30 @@
+package mtr
+
+import (
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+)
+
+func TestDetectForeignHops(t *testing.T) {
+	isp := func(name string) *geoip.GeoLocation { return &geoip.GeoLocation{ISP: name} }
+	hops := []SnapshotHop{
+		{TTL: 1, IP: "192.168.1.1"},
+		{TTL: 2, IP: "61.1.1.1", Location: isp("电信")},
+		{TTL: 3, IP: "4.69.1.1", Location: isp("Lumen")},
+		{TTL: 4},
+		{TTL: 5, IP: "61.2.2.2", Location: isp("电信")},
+		{TTL: 6, IP: "10.0.0.1"},
+		{TTL: 7, IP: "10.0.9.9"},
+		{TTL: 8, IP: "172.16.0.1"},
+		{TTL: 9, IP: "10.0.3.3"},
+	}
+
+	detectForeignHops(hops)
+	want := map[int]bool{3: true, 8: true}
+	for _, hop := range hops {
+		if hop.Foreign != want[hop.TTL] {
+			t.Fatalf("unexpected foreign flag at ttl=%d: %v", hop.TTL, hop.Foreign)
+		}
+	}
+}
@@ -1,6 +1,14 @@
 package mtr
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
 
 func FormatDuration(d time.Duration) string {
 	if d <= 0 {
@@ -8,3 +16,53 @@ func FormatDuration(d time.Duration) string {
 	}
 	return d.Round(time.Millisecond).String()
 }
+
+type DurationUnit string
+
+const (
+	DurationUnitMs DurationUnit = "ms"
+	DurationUnitUs DurationUnit = "us"
+	// DurationUnitAuto 对不足 1ms 的耗时使用微秒，其余使用毫秒。
+	DurationUnitAuto DurationUnit = "auto"
+)
+
+// ParseDurationUnit 解析 --units 取值（大小写不敏感，空串视为 ms）。
+func ParseDurationUnit(s string) (DurationUnit, error) {
+	switch u := DurationUnit(strings.ToLower(strings.TrimSpace(s))); u {
+	case "":
+		return DurationUnitMs, nil
+	case DurationUnitMs, DurationUnitUs, DurationUnitAuto:
+		return u, nil
+	default:
+		return "", errors.New(i18n.Tf("err.unitsInvalid", map[string]interface{}{"Units": s}))
+	}
+}
+
+// DurationFormat 控制快照中耗时字符串字段（last/avg/... 及分段时延）的单位与小数位数。
+// 零值等价于整数毫秒（如 "12ms"）。
+type DurationFormat struct {
+	Unit      DurationUnit
+	Precision int
+}
+
+// Format 格式化耗时；d<=0 时返回空串（表示无数据）。
+func (f DurationFormat) Format(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return f.format(d)
+}
+
+func (f DurationFormat) format(d time.Duration) string {
+	unit, suffix := time.Millisecond, "ms"
+	if f.Unit == DurationUnitUs || (f.Unit == DurationUnitAuto && d < time.Millisecond) {
+		unit, suffix = time.Microsecond, "µs"
+	}
+	prec := f.Precision
+	if prec < 0 {
+		prec = 0
+	}
+	scale := math.Pow10(prec)
+	v := math.Round(float64(d)/float64(unit)*scale) / scale
+	return fmt.Sprintf("%.*f%s", prec, v, suffix)
+}
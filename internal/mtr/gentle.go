@@ -0,0 +1,73 @@
+package mtr
+
+import "time"
+
+const (
+	// gentleStartFactor 为 --gentle 起始轮间隔相对目标间隔的倍数。
+	gentleStartFactor = 8
+	// gentleLossThreshold 为允许加速的单轮丢包率上限。
+	gentleLossThreshold = 0.05
+)
+
+// gentlePacer 实现类似慢启动的轮间隔控制：从较长的间隔开始，单轮丢包低时间隔减半，
+// 直至用户指定的间隔；丢包升高时间隔加倍回退，避免测量本身加剧已拥塞链路的负担。
+//
+// 丢包只统计此前已应答过的跳点，始终不应答的路由器不会阻止加速。
+type gentlePacer struct {
+	target   time.Duration
+	max      time.Duration
+	interval time.Duration
+
+	expected int
+	lost     int
+}
+
+func newGentlePacer(target time.Duration) *gentlePacer {
+	if target <= 0 {
+		target = time.Second
+	}
+	max := target * gentleStartFactor
+	return &gentlePacer{target: target, max: max, interval: max}
+}
+
+// setTarget 更新目标间隔（运行中调整轮间隔时使用），起始上限至少与目标间隔相同。
+func (p *gentlePacer) setTarget(target time.Duration) {
+	p.target = target
+	if p.max < target {
+		p.max = target
+	}
+}
+
+// record 记录一次探测结果；answeredBefore 表示该 TTL 在之前的轮次中有过应答。
+func (p *gentlePacer) record(answeredBefore bool, res *ProbeResult) {
+	// 本机发送失败与限速无关，不计入判断
+	if !answeredBefore || (res != nil && res.Type == ResponseTypeSendFailed) {
+		return
+	}
+	p.expected++
+	if res == nil || res.Type == ResponseTypeTimeout || res.IP == nil {
+		p.lost++
+	}
+}
+
+// next 根据本轮丢包调整并返回下一轮前的等待间隔，同时清空本轮计数。
+// 本轮没有可参照的跳点（如首轮）时保持当前间隔。
+func (p *gentlePacer) next() time.Duration {
+	defer func() { p.expected, p.lost = 0, 0 }()
+	if p.expected == 0 {
+		return p.interval
+	}
+	loss := float64(p.lost) / float64(p.expected)
+	if loss <= gentleLossThreshold {
+		p.interval /= 2
+	} else {
+		p.interval *= 2
+	}
+	if p.interval < p.target {
+		p.interval = p.target
+	}
+	if p.interval > p.max {
+		p.interval = p.max
+	}
+	return p.interval
+}
@@ -0,0 +1,42 @@
+package mtr
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGentlePacer(t *testing.T) {
+	p := newGentlePacer(time.Second)
+	reply := &ProbeResult{IP: net.ParseIP("192.0.2.1"), Type: ResponseTypeTimeExceeded}
+	timeout := &ProbeResult{Type: ResponseTypeTimeout}
+
+	// 首轮没有参照时保持起始间隔
+	p.record(false, reply)
+	if got := p.next(); got != 8*time.Second {
+		t.Fatalf("expected start interval, got %v", got)
+	}
+
+	// 无丢包时每轮间隔减半，直至目标间隔
+	for _, want := range []time.Duration{4 * time.Second, 2 * time.Second, time.Second, time.Second} {
+		p.record(true, reply)
+		if got := p.next(); got != want {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	// 从未应答过的跳点超时不计入丢包
+	p.record(false, timeout)
+	if got := p.next(); got != time.Second {
+		t.Fatalf("unanswered hop should not slow down, got %v", got)
+	}
+
+	// 已应答跳点开始丢包时加倍回退，且不超过起始间隔
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		p.record(true, reply)
+		p.record(true, timeout)
+		if got := p.next(); got != want {
+			t.Fatalf("expected back-off to %v, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package mtr
+
+// HopHook 在每次 hop 更新后被调用，用于自定义分类：打标签、触发告警或在展示中隐藏。
+type HopHook interface {
+	Evaluate(hop SnapshotHop) HookResult
+}
+
+type HookResult struct {
+	// Tags 为当前命中的标签（覆盖之前的结果）。
+	Tags []string
+	// Alerts 为本次新触发的告警描述，会以 Err 为 HookAlertError 的 EventTypeWarning 事件发出。
+	Alerts []string
+	// Hidden 为 true 时文本/TUI 输出中不展示该 hop（JSON 中保留并标记）。
+	Hidden bool
+}
+
+// SetHopHook 设置 hop 更新钩子，需在 Run 之前调用。
+func (c *Controller) SetHopHook(h HopHook) {
+	c.hook = h
+}
+
+func (c *Controller) applyHook(ttl int) []string {
+	if c.hook == nil {
+		return nil
+	}
+	c.mu.RLock()
+	hop := c.hops[ttl]
+	if hop == nil {
+		c.mu.RUnlock()
+		return nil
+	}
+	snap := hop.ToSnapshot()
+	c.mu.RUnlock()
+
+	res := c.hook.Evaluate(snap)
+
+	c.mu.Lock()
+	hop.Tags = res.Tags
+	hop.Hidden = res.Hidden
+	c.mu.Unlock()
+	return res.Alerts
+}
@@ -1,9 +1,9 @@
 package mtr
 
 import (
-	"fmt"
 	"math"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/hyqhyq3/mymtr/internal/geoip"
@@ -16,6 +16,17 @@ type Hop struct {
 	Location *geoip.GeoLocation
 	Stats    *HopStats
 	Lost     bool
+	// SendFailed 表示最近一次探测包未能从本机发出。
+	SendFailed bool
+	// Throttled 表示该 hop 从未应答，已降低探测频率（见 Config.DarkHopAfter）。
+	Throttled bool
+
+	// ICMPTimestamp 为最近一次 Timestamp Reply 的时间戳（仅 icmp-ts 模式）。
+	ICMPTimestamp *ICMPTimestamp
+
+	// Tags/Hidden 由 HopHook 维护。
+	Tags   []string
+	Hidden bool
 }
 
 func NewHop(ttl int) *Hop {
@@ -35,20 +46,41 @@ type HopStats struct {
 	Worst    time.Duration `json:"worst"`
 	Avg      time.Duration `json:"avg"`
 	StdDev   time.Duration `json:"stddev"`
-	History  []time.Duration
+	// Jitter 为最近两次 RTT 之差的绝对值，JitterAvg/JitterMax 为其均值与最大值（与 mtr 的 Jttr/Javg/Jmax 一致）。
+	Jitter    time.Duration `json:"jitter"`
+	JitterAvg time.Duration `json:"jitter_avg"`
+	JitterMax time.Duration `json:"jitter_max"`
+	History   []time.Duration
+	// Responses 按响应类别计数（含 timeout）。
+	Responses map[string]int
+	// Suspicious 为 --strict-match 下被判为可疑而丢弃的回包数。
+	Suspicious int
 
-	mean float64
-	m2   float64
-	n    int
+	mean      float64
+	m2        float64
+	n         int
+	jitterSum time.Duration
 }
 
 func NewHopStats() *HopStats {
 	return &HopStats{
-		History: make([]time.Duration, 0, 10),
+		History:   make([]time.Duration, 0, 10),
+		Responses: make(map[string]int),
 	}
 }
 
 func (s *HopStats) AddRTT(rtt time.Duration) {
+	if s.n > 0 {
+		s.Jitter = rtt - s.Last
+		if s.Jitter < 0 {
+			s.Jitter = -s.Jitter
+		}
+		if s.Jitter > s.JitterMax {
+			s.JitterMax = s.Jitter
+		}
+		s.jitterSum += s.Jitter
+		s.JitterAvg = s.jitterSum / time.Duration(s.n)
+	}
 	s.Last = rtt
 	if s.Best == 0 || rtt < s.Best {
 		s.Best = rtt
@@ -105,16 +137,86 @@ type Snapshot struct {
 	Protocol      string        `json:"protocol"`
 	MaxHops       int           `json:"max_hops"`
 	Count         int           `json:"count"`
+	IntervalMs    int64         `json:"interval_ms"`
+	TimeoutMs     int64         `json:"timeout_ms"`
+	Profile       string        `json:"profile,omitempty"`
+	ProbeMode     string        `json:"probe_mode,omitempty"`
 	Hops          []SnapshotHop `json:"hops"`
+	Loops         []RoutingLoop `json:"loops,omitempty"`
+
+	Completeness PathCompleteness  `json:"path_completeness"`
+	ProbeErrors  ProbeErrorCounts  `json:"probe_errors"`
+	Destination  DestinationStatus `json:"destination"`
+	Markers      []Marker          `json:"markers,omitempty"`
+	// Anycast 仅在疑似 anycast（不同轮次到达目标时经过的终端站点明显不同）时填充。
+	Anycast *AnycastReport `json:"anycast,omitempty"`
+	// DNSCheck 为目标的往返解析校验结果（--check-dns）。
+	DNSCheck *DNSCheck `json:"dns_check,omitempty"`
+	// Violations 为最近一轮结束时未满足的路径策略（--path-policy）。
+	Violations []PolicyViolation `json:"policy_violations,omitempty"`
+	// Violated 为运行期间任意一轮违反过的路径策略（每条保留首次违规的描述），决定退出码。
+	Violated []PolicyViolation `json:"policies_violated,omitempty"`
+	// TCPRTT 为 TCP 握手测得的目标时延（--tcp-rtt）。
+	TCPRTT *TCPRTT `json:"tcp_rtt,omitempty"`
+
+	durationFormat DurationFormat
+	hostnameRules  HostnameRules
+}
+
+// FormatMs 按快照的耗时格式（--units/--precision）格式化以毫秒计的耗时，用于 TCP 握手、anycast 站点等
+// 以浮点毫秒保存的字段；ms<=0 时返回空串。
+func (s *Snapshot) FormatMs(ms float64) string {
+	return s.durationFormat.Format(time.Duration(ms * float64(time.Millisecond)))
+}
+
+// ApplyHop 用单个 hop 的最新状态（如 HopUpdated 事件携带的 Hop）更新快照，
+// 并重新标记环路 hop、计算分段时延与路径完整度等路径级字段；用于增量刷新，避免每次重建完整快照。
+func (s *Snapshot) ApplyHop(hop SnapshotHop) {
+	i := sort.Search(len(s.Hops), func(i int) bool { return s.Hops[i].TTL >= hop.TTL })
+	if i < len(s.Hops) && s.Hops[i].TTL == hop.TTL {
+		s.Hops[i] = hop
+	} else {
+		s.Hops = append(s.Hops, SnapshotHop{})
+		copy(s.Hops[i+1:], s.Hops[i:])
+		s.Hops[i] = hop
+	}
+	s.refreshPath()
+}
+
+// refreshPath 根据 Hops 重新计算路径级派生字段。
+func (s *Snapshot) refreshPath() {
+	for i := range s.Hops {
+		s.Hops[i].Loop = false
+		s.Hops[i].Foreign = false
+	}
+	applySegmentLatency(s.Hops, s.durationFormat)
+	applyHostnameRules(s.Hops, s.hostnameRules)
+	markLoops(s.Hops, s.Loops)
+	detectForeignHops(s.Hops)
+	s.Completeness = computeCompleteness(s.Hops, s.TargetIP)
 }
 
 type SnapshotHop struct {
-	TTL      int                `json:"ttl"`
-	IP       string             `json:"ip,omitempty"`
-	Hostname string             `json:"hostname,omitempty"`
-	Lost     bool               `json:"lost"`
+	TTL      int    `json:"ttl"`
+	IP       string `json:"ip,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Lost     bool   `json:"lost"`
+	// SendFailed 表示最近一次探测包未能从本机发出（见 ResponseTypeSendFailed）。
+	SendFailed bool `json:"send_failed,omitempty"`
+	Throttled  bool `json:"throttled,omitempty"`
+	Loop       bool `json:"loop,omitempty"`
+	// Foreign 表示该 hop 的响应源地址与前后 hop 归属不同（见 detectForeignHops）。
+	Foreign  bool               `json:"foreign_address,omitempty"`
 	Location *geoip.GeoLocation `json:"location,omitempty"`
 	Stats    SnapshotHopSta     `json:"stats"`
+
+	ICMPTimestamp *ICMPTimestamp `json:"icmp_timestamp,omitempty"`
+
+	Tags   []string `json:"tags,omitempty"`
+	Hidden bool     `json:"hidden,omitempty"`
+
+	// displayHostname 为按 HostnameRules 缩短后的主机名，仅用于渲染（见 DisplayHostname）。
+	displayHostname string
 }
 
 type SnapshotHopSta struct {
@@ -127,16 +229,39 @@ type SnapshotHopSta struct {
 	WorstMs  int64   `json:"worst_ms"`
 	StdDevMs int64   `json:"stddev_ms"`
 
+	JitterMs    int64 `json:"jitter_ms"`
+	JitterAvgMs int64 `json:"jitter_avg_ms"`
+	JitterMaxMs int64 `json:"jitter_max_ms"`
+
 	HistoryMs []int64 `json:"history_ms,omitempty"`
 
+	Responses map[string]int `json:"responses,omitempty"`
+	// Suspicious 为 --strict-match 下仅宽松匹配、被判为可疑而丢弃的回包数。
+	Suspicious int `json:"suspicious,omitempty"`
+
+	// SegmentMs 为本跳相对前面各跳新增的平均时延（见 applySegmentLatency），仅对有响应的 hop 有效。
+	SegmentMs int64  `json:"segment_ms,omitempty"`
+	Segment   string `json:"segment,omitempty"`
+
+	// avg 为未取整的平均时延，用于计算分段时延。
+	avg time.Duration
+
 	Last   string `json:"last,omitempty"`
 	Best   string `json:"best,omitempty"`
 	Worst  string `json:"worst,omitempty"`
 	Avg    string `json:"avg,omitempty"`
 	StdDev string `json:"stddev,omitempty"`
+
+	Jitter    string `json:"jitter,omitempty"`
+	JitterAvg string `json:"jitter_avg,omitempty"`
+	JitterMax string `json:"jitter_max,omitempty"`
 }
 
 func (h *Hop) ToSnapshot() SnapshotHop {
+	return h.toSnapshot(DurationFormat{})
+}
+
+func (h *Hop) toSnapshot(df DurationFormat) SnapshotHop {
 	ip := ""
 	if h.IP != nil {
 		ip = h.IP.String()
@@ -147,36 +272,55 @@ func (h *Hop) ToSnapshot() SnapshotHop {
 		historyMs = append(historyMs, durationMs(d))
 	}
 	return SnapshotHop{
-		TTL:      h.TTL,
-		IP:       ip,
-		Hostname: h.Hostname,
-		Lost:     h.Lost,
-		Location: h.Location,
+		TTL:           h.TTL,
+		IP:            ip,
+		Hostname:      h.Hostname,
+		Lost:          h.Lost,
+		SendFailed:    h.SendFailed,
+		Throttled:     h.Throttled,
+		Location:      h.Location,
+		ICMPTimestamp: h.ICMPTimestamp,
+		Tags:          h.Tags,
+		Hidden:        h.Hidden,
 		Stats: SnapshotHopSta{
-			Sent:      h.Stats.Sent,
-			Received:  h.Stats.Received,
-			Loss:      h.Stats.Loss,
-			LastMs:    durationMs(h.Stats.Last),
-			AvgMs:     durationMs(h.Stats.Avg),
-			BestMs:    durationMs(h.Stats.Best),
-			WorstMs:   durationMs(h.Stats.Worst),
-			StdDevMs:  durationMs(h.Stats.StdDev),
-			HistoryMs: historyMs,
-
-			Last:   durationStringMs(h.Stats.Last),
-			Best:   durationStringMs(h.Stats.Best),
-			Worst:  durationStringMs(h.Stats.Worst),
-			Avg:    durationStringMs(h.Stats.Avg),
-			StdDev: durationStringMs(h.Stats.StdDev),
+			Sent:        h.Stats.Sent,
+			Received:    h.Stats.Received,
+			Loss:        h.Stats.Loss,
+			LastMs:      durationMs(h.Stats.Last),
+			AvgMs:       durationMs(h.Stats.Avg),
+			BestMs:      durationMs(h.Stats.Best),
+			WorstMs:     durationMs(h.Stats.Worst),
+			StdDevMs:    durationMs(h.Stats.StdDev),
+			JitterMs:    durationMs(h.Stats.Jitter),
+			JitterAvgMs: durationMs(h.Stats.JitterAvg),
+			JitterMaxMs: durationMs(h.Stats.JitterMax),
+			HistoryMs:   historyMs,
+			Responses:   copyCounts(h.Stats.Responses),
+			Suspicious:  h.Stats.Suspicious,
+			avg:         h.Stats.Avg,
+
+			Last:   df.Format(h.Stats.Last),
+			Best:   df.Format(h.Stats.Best),
+			Worst:  df.Format(h.Stats.Worst),
+			Avg:    df.Format(h.Stats.Avg),
+			StdDev: df.Format(h.Stats.StdDev),
+
+			Jitter:    df.Format(h.Stats.Jitter),
+			JitterAvg: df.Format(h.Stats.JitterAvg),
+			JitterMax: df.Format(h.Stats.JitterMax),
 		},
 	}
 }
 
-func durationStringMs(d time.Duration) string {
-	if d <= 0 {
-		return ""
+func copyCounts(m map[string]int) map[string]int {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]int, len(m))
+	for k, v := range m {
+		out[k] = v
 	}
-	return fmt.Sprintf("%dms", durationMs(d))
+	return out
 }
 
 func durationMs(d time.Duration) int64 {
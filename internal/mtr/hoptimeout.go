@@ -0,0 +1,90 @@
+package mtr
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// HopTimeout 为 TTL 区间 [From, To] 的探测超时覆盖；To 为 0 表示不设上限。
+type HopTimeout struct {
+	From    int
+	To      int
+	Timeout time.Duration
+}
+
+// HopTimeouts 为按 TTL 覆盖的超时列表，多个区间重叠时先出现的优先。
+type HopTimeouts []HopTimeout
+
+// ParseHopTimeouts 解析 --timeout-per-hop，如 "1:200ms,2-5:500ms,20-:3s"。
+func ParseHopTimeouts(s string) (HopTimeouts, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var out HopTimeouts
+	for _, part := range strings.Split(s, ",") {
+		ht, ok := parseHopTimeout(strings.TrimSpace(part))
+		if !ok {
+			return nil, errors.New(i18n.Tf("err.hopTimeoutInvalid", map[string]interface{}{"Value": part}))
+		}
+		out = append(out, ht)
+	}
+	return out, nil
+}
+
+func parseHopTimeout(s string) (HopTimeout, bool) {
+	ttls, dur, ok := strings.Cut(s, ":")
+	if !ok {
+		return HopTimeout{}, false
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(dur))
+	if err != nil || d <= 0 {
+		return HopTimeout{}, false
+	}
+	from, to, isRange := strings.Cut(strings.TrimSpace(ttls), "-")
+	ht := HopTimeout{Timeout: d}
+	if ht.From, err = strconv.Atoi(from); err != nil || ht.From < 1 {
+		return HopTimeout{}, false
+	}
+	switch {
+	case !isRange:
+		ht.To = ht.From
+	case to == "":
+		// "20-" 表示 20 跳及以后
+	default:
+		if ht.To, err = strconv.Atoi(to); err != nil || ht.To < ht.From {
+			return HopTimeout{}, false
+		}
+	}
+	return ht, true
+}
+
+// lookup 返回 ttl 对应的覆盖超时。
+func (hs HopTimeouts) lookup(ttl int) (time.Duration, bool) {
+	for _, h := range hs {
+		if ttl >= h.From && (h.To == 0 || ttl <= h.To) {
+			return h.Timeout, true
+		}
+	}
+	return 0, false
+}
+
+// TimeoutFor 返回第 ttl 跳的探测超时：命中 HopTimeouts 时使用覆盖值，否则为 Timeout。
+func (c *Config) TimeoutFor(ttl int) time.Duration {
+	if d, ok := c.HopTimeouts.lookup(ttl); ok {
+		return d
+	}
+	return c.Timeout
+}
+
+// maxTimeout 返回所有跳中最长的超时，作为 prober 的等待上限；每跳的实际超时由控制器通过 ctx 截止时间施加。
+func (c *Config) maxTimeout() time.Duration {
+	d := c.Timeout
+	for _, h := range c.HopTimeouts {
+		d = max(d, h.Timeout)
+	}
+	return d
+}
@@ -0,0 +1,69 @@
+package mtr
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseHopTimeouts(t *testing.T) {
+	hs, err := ParseHopTimeouts("1:200ms, 2-5:500ms,20-:3s")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cfg := &Config{Timeout: time.Second, HopTimeouts: hs}
+	for ttl, want := range map[int]time.Duration{
+		1: 200 * time.Millisecond, 2: 500 * time.Millisecond, 5: 500 * time.Millisecond,
+		6: time.Second, 19: time.Second, 20: 3 * time.Second, 64: 3 * time.Second,
+	} {
+		if got := cfg.TimeoutFor(ttl); got != want {
+			t.Errorf("TimeoutFor(%d) = %v, want %v", ttl, got, want)
+		}
+	}
+	if got := cfg.maxTimeout(); got != 3*time.Second {
+		t.Fatalf("maxTimeout = %v", got)
+	}
+
+	for _, bad := range []string{"1", "0:1s", "5-2:1s", "a:1s", "1:abc", "1:-1s", "1:200ms,,2:1s"} {
+		if _, err := ParseHopTimeouts(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// deadlineProber 记录每次探测时 ctx 的剩余时间。
+type deadlineProber struct {
+	scriptedProber
+	remaining map[int]time.Duration
+}
+
+func (p *deadlineProber) Probe(ctx context.Context, ttl, seq int) (*ProbeResult, error) {
+	if d, ok := ctx.Deadline(); ok {
+		p.remaining[ttl] = time.Until(d)
+	}
+	return p.scriptedProber.Probe(ctx, ttl, seq)
+}
+
+func TestControllerAppliesHopTimeouts(t *testing.T) {
+	hs, err := ParseHopTimeouts("1-2:100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prober := &deadlineProber{remaining: make(map[int]time.Duration)}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 3, Count: 1, Timeout: 2 * time.Second, HopTimeouts: hs, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, ttl := range []int{1, 2} {
+		if d := prober.remaining[ttl]; d <= 0 || d > 100*time.Millisecond {
+			t.Errorf("ttl %d: remaining %v, want <= 100ms", ttl, d)
+		}
+	}
+	if d := prober.remaining[3]; d <= time.Second || d > 2*time.Second {
+		t.Errorf("ttl 3: remaining %v, want the default 2s timeout", d)
+	}
+}
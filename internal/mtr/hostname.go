@@ -0,0 +1,101 @@
+package mtr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// HostnameRule 将以 Suffix 结尾的主机名后缀替换为 Replace（为空时直接去掉后缀）。
+type HostnameRule struct {
+	Suffix  string
+	Replace string
+}
+
+// HostnameRules 为主机名的显示缩短规则，只作用于表格/TUI 渲染，快照 JSON 中仍为完整主机名。
+// 多条规则匹配时使用后缀最长的一条，后缀相同时先出现的优先。
+type HostnameRules []HostnameRule
+
+// DefaultHostnameRules 为常见云/CDN 厂商冗长域名的缩写（--short-hostnames）。
+var DefaultHostnameRules = HostnameRules{
+	{Suffix: "amazonaws.com", Replace: "aws"},
+	{Suffix: "googleusercontent.com", Replace: "gcp"},
+	{Suffix: "cloudapp.azure.com", Replace: "azure"},
+	{Suffix: "akamaitechnologies.com", Replace: "akamai"},
+}
+
+// 显示规则格式：
+//
+//	strip:<suffix>          去掉后缀，如 strip:net.example.com
+//	<suffix>=<short>        将后缀替换为缩写，如 amazonaws.com=aws
+const hostnameRuleStrip = "strip:"
+
+// ParseHostnameRules 解析 --hostname-rule 列表。
+func ParseHostnameRules(specs []string) (HostnameRules, error) {
+	rules := make(HostnameRules, 0, len(specs))
+	for _, spec := range specs {
+		var r HostnameRule
+		if suffix, ok := strings.CutPrefix(strings.TrimSpace(spec), hostnameRuleStrip); ok {
+			r.Suffix = suffix
+		} else {
+			suffix, short, ok := strings.Cut(spec, "=")
+			if !ok || strings.TrimSpace(short) == "" {
+				return nil, errors.New(i18n.Tf("err.hostnameRuleInvalid", map[string]interface{}{"Rule": spec}))
+			}
+			r.Suffix, r.Replace = suffix, strings.TrimSpace(short)
+		}
+		r.Suffix = normalizeHostname(r.Suffix)
+		if r.Suffix == "" {
+			return nil, errors.New(i18n.Tf("err.hostnameRuleInvalid", map[string]interface{}{"Rule": spec}))
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Shorten 按规则缩短主机名；后缀只在标签边界上匹配，且不会把主机名缩成空串。
+func (rs HostnameRules) Shorten(hostname string) string {
+	if len(rs) == 0 || hostname == "" {
+		return hostname
+	}
+	host := strings.TrimSuffix(hostname, ".")
+	lower := strings.ToLower(host)
+	best := -1
+	for i, r := range rs {
+		if !strings.HasSuffix(lower, "."+r.Suffix) {
+			continue
+		}
+		if best < 0 || len(r.Suffix) > len(rs[best].Suffix) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return hostname
+	}
+	r := rs[best]
+	head := host[:len(host)-len(r.Suffix)-1]
+	if r.Replace == "" {
+		return head
+	}
+	return head + "." + r.Replace
+}
+
+// applyHostnameRules 为每个 hop 计算显示用主机名。
+func applyHostnameRules(hops []SnapshotHop, rules HostnameRules) {
+	for i := range hops {
+		hops[i].displayHostname = rules.Shorten(hops[i].Hostname)
+	}
+}
+
+// DisplayHostname 返回渲染用的主机名（应用 --hostname-rule / --short-hostnames 后）。
+func (h SnapshotHop) DisplayHostname() string {
+	if h.displayHostname != "" {
+		return h.displayHostname
+	}
+	return h.Hostname
+}
+
+func normalizeHostname(s string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(s), "."))
+}
@@ -0,0 +1,57 @@
+package mtr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestHostnameRulesShorten(t *testing.T) {
+	rules, err := ParseHostnameRules([]string{"strip:.net.example.com", "example.com=ex", "amazonaws.com=amzn"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rules = append(rules, DefaultHostnameRules...)
+
+	cases := map[string]string{
+		"ae-1.r01.tokyjp.net.example.com":                "ae-1.r01.tokyjp",
+		"core1.example.com":                              "core1.ex",
+		"EC2-1-2-3-4.compute-1.AMAZONAWS.COM.":           "EC2-1-2-3-4.compute-1.amzn",
+		"1.2.3.4.bc.googleusercontent.com":               "1.2.3.4.bc.gcp",
+		"a23-1-2-3.deploy.static.akamaitechnologies.com": "a23-1-2-3.deploy.static.akamai",
+		"notexample.com":                                 "notexample.com",
+		"example.com":                                    "example.com",
+		"":                                               "",
+	}
+	for in, want := range cases {
+		if got := rules.Shorten(in); got != want {
+			t.Errorf("Shorten(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseHostnameRulesInvalid(t *testing.T) {
+	for _, spec := range []string{"example.com", "strip:", "example.com=", "=ex"} {
+		if _, err := ParseHostnameRules([]string{spec}); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestSnapshotDisplayHostnameNotInJSON(t *testing.T) {
+	s := &Snapshot{
+		Hops:          []SnapshotHop{{TTL: 1, IP: "192.0.2.1", Hostname: "r1.net.example.com"}},
+		hostnameRules: HostnameRules{{Suffix: "net.example.com"}},
+	}
+	s.refreshPath()
+	if got := s.Hops[0].DisplayHostname(); got != "r1" {
+		t.Fatalf("DisplayHostname = %q, want r1", got)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"hostname":"r1.net.example.com"`) {
+		t.Fatalf("JSON should keep the full hostname: %s", data)
+	}
+}
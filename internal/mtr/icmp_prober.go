@@ -1,16 +1,19 @@
 package mtr
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
-	"fmt"
+	"math/rand/v2"
 	"net"
-	"os"
 	"time"
 
 	"golang.org/x/net/icmp"
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
 )
 
 type ICMPProber struct {
@@ -20,25 +23,43 @@ type ICMPProber struct {
 	conn   *icmp.PacketConn
 	target net.IP
 	id     int
+	// nonce 写入每个 Echo 负载（见 seqPayload），用于区分 16 位 seq 回绕后的过期回包
+	nonce uint32
+
+	// timestamp 为 true 时使用 ICMP Timestamp Request（仅 IPv4）代替 Echo。
+	timestamp bool
+	// dgram 为 true 时 conn 为无特权的数据报 ICMP 套接字，目的地址需使用 UDPAddr。
+	dgram bool
+	// strict 为 true 时启用 --strict-match 校验。
+	strict bool
 
-	payload []byte
+	errs probeErrorCounter
+}
+
+// NewICMPTimestampProber 创建使用 ICMP Timestamp Request 的探测器，
+// 用于过滤 Echo 但仍响应 Timestamp 的主机。
+func NewICMPTimestampProber(opts ProberOptions) (*ICMPProber, error) {
+	if opts.IPVersion != 4 {
+		return nil, errors.New(i18n.T("err.icmpTimestampIPv4Only"))
+	}
+	p, err := NewICMPProber(opts)
+	if err != nil {
+		return nil, err
+	}
+	p.timestamp = true
+	return p, nil
 }
 
-func NewICMPProber(ipVersion int, timeout time.Duration) (*ICMPProber, error) {
+func NewICMPProber(opts ProberOptions) (*ICMPProber, error) {
+	ipVersion, timeout := opts.IPVersion, opts.Timeout
 	if timeout <= 0 {
 		timeout = time.Second
 	}
-	network := "ip4:icmp"
-	addr := "0.0.0.0"
-	if ipVersion == 6 {
-		network = "ip6:ipv6-icmp"
-		addr = "::"
-	}
 
-	conn, err := icmp.ListenPacket(network, addr)
+	conn, err := listenICMP(ipVersion, opts.Source)
 	if err != nil {
 		if looksLikePermission(err) {
-			return nil, fmt.Errorf("创建原始套接字失败（需要更高权限运行）：%w", err)
+			return nil, &PermissionError{Err: err}
 		}
 		return nil, err
 	}
@@ -47,20 +68,53 @@ func NewICMPProber(ipVersion int, timeout time.Duration) (*ICMPProber, error) {
 		ipVersion: ipVersion,
 		timeout:   timeout,
 		conn:      conn,
-		id:        os.Getpid() & 0xffff,
-		payload:   []byte("mymtr"),
+		id:        nextProbeID(),
+		nonce:     rand.Uint32(),
+		strict:    opts.StrictMatch,
+	}
+	if err := setTrafficClass(conn.IPv4PacketConn(), conn.IPv6PacketConn(), opts.DSCP); err != nil {
+		conn.Close()
+		return nil, err
 	}
 	return p, nil
 }
 
+// newDgramICMPProber 创建基于数据报 ICMP 套接字的 Echo 探测器，作为原始套接字不可用时的降级方式。
+// 仅在内核会把中间跳的 ICMP 错误投递给该套接字的平台（如 macOS）上可用。
+func newDgramICMPProber(opts ProberOptions) (*ICMPProber, error) {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	conn, err := listenICMPDgram(opts.IPVersion, opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	if err := setTrafficClass(conn.IPv4PacketConn(), conn.IPv6PacketConn(), opts.DSCP); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &ICMPProber{
+		ipVersion: opts.IPVersion,
+		timeout:   timeout,
+		conn:      conn,
+		id:        nextProbeID(),
+		nonce:     rand.Uint32(),
+		strict:    opts.StrictMatch,
+		dgram:     true,
+	}, nil
+}
+
 func (p *ICMPProber) SetTarget(ip net.IP) error {
 	if ip == nil {
-		return errors.New("target ip 不能为空")
+		return ErrTargetNil
 	}
 	p.target = ip
 	return nil
 }
 
+func (p *ICMPProber) ProbeErrors() ProbeErrorCounts { return p.errs.ProbeErrors() }
+
 func (p *ICMPProber) Close() error {
 	if p.conn == nil {
 		return nil
@@ -70,7 +124,7 @@ func (p *ICMPProber) Close() error {
 
 func (p *ICMPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult, error) {
 	if p.target == nil {
-		return nil, errors.New("尚未设置 target ip")
+		return nil, ErrTargetNotSet
 	}
 	if ctx == nil {
 		ctx = context.Background()
@@ -81,7 +135,8 @@ func (p *ICMPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 		return nil, err
 	}
 
-	msg, proto, err := p.echoMessage(seq)
+	payload := p.payload(seq)
+	msg, proto, err := p.echoMessage(seq, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +145,12 @@ func (p *ICMPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 		return nil, err
 	}
 
-	if _, err := p.conn.WriteTo(b, &net.IPAddr{IP: p.target}); err != nil {
-		return nil, err
+	var dst net.Addr = &net.IPAddr{IP: p.target}
+	if p.dgram {
+		dst = &net.UDPAddr{IP: p.target}
+	}
+	if _, err := p.conn.WriteTo(b, dst); err != nil {
+		return p.errs.sendFailed(ttl, seq, err)
 	}
 
 	deadline := now.Add(p.timeout)
@@ -110,46 +169,71 @@ func (p *ICMPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 	}()
 	defer close(unblock)
 
+	suspicious := 0
 	buf := make([]byte, 1500)
 	for {
 		n, peer, err := p.conn.ReadFrom(buf)
 		if err != nil {
 			if ctx.Err() != nil {
 				return &ProbeResult{
-					TTL:       ttl,
-					Seq:       seq,
-					Type:      ResponseTypeTimeout,
-					Timestamp: now,
+					TTL:        ttl,
+					Seq:        seq,
+					Type:       ResponseTypeTimeout,
+					Timestamp:  now,
+					Suspicious: suspicious,
 				}, nil
 			}
 			if isTimeout(err) {
 				return &ProbeResult{
-					TTL:       ttl,
-					Seq:       seq,
-					Type:      ResponseTypeTimeout,
-					Timestamp: now,
+					TTL:        ttl,
+					Seq:        seq,
+					Type:       ResponseTypeTimeout,
+					Timestamp:  now,
+					Suspicious: suspicious,
 				}, nil
 			}
-			return nil, err
+			if isFatalReadError(err) {
+				return nil, err
+			}
+			p.errs.read.Add(1)
+			return &ProbeResult{
+				TTL:        ttl,
+				Seq:        seq,
+				Type:       ResponseTypeTimeout,
+				Timestamp:  now,
+				Suspicious: suspicious,
+			}, nil
 		}
 
 		rm, err := icmp.ParseMessage(proto, buf[:n])
 		if err != nil {
+			p.errs.parse.Add(1)
 			continue
 		}
 
-		typ := p.classifyReply(proto, rm, seq)
+		typ := p.classifyReply(proto, rm, seq, payload)
+		if typ != ResponseTypeTimeout && p.strict && !p.strictMatches(typ, rm, peer, payload) {
+			suspicious++
+			continue
+		}
 		switch typ {
-		case ResponseTypeEchoReply, ResponseTypeTimeExceeded:
+		case ResponseTypeEchoReply, ResponseTypeTimeExceeded, ResponseTypeDestUnreach:
 			ip := extractPeerIP(peer)
-			return &ProbeResult{
+			res := &ProbeResult{
 				TTL:       ttl,
 				Seq:       seq,
 				IP:        ip,
 				RTT:       time.Since(now),
 				Type:      typ,
 				Timestamp: now,
-			}, nil
+				Kind:      responseKind(p.ipVersion, typ, rm),
+
+				Suspicious: suspicious,
+			}
+			if typ == ResponseTypeEchoReply && p.timestamp {
+				res.ICMPTimestamp = parseTimestampReply(rm, time.Now())
+			}
+			return res, nil
 		default:
 			continue
 		}
@@ -157,53 +241,96 @@ func (p *ICMPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 }
 
 func (p *ICMPProber) setTTL(ttl int) error {
-	if ttl <= 0 {
-		ttl = 1
-	}
 	if p.ipVersion == 4 {
-		return p.conn.IPv4PacketConn().SetTTL(ttl)
+		return setProbeTTL(p.conn.IPv4PacketConn(), nil, ttl)
 	}
-	return p.conn.IPv6PacketConn().SetHopLimit(ttl)
+	return setProbeTTL(nil, p.conn.IPv6PacketConn(), ttl)
 }
 
-func (p *ICMPProber) echoMessage(seq int) (icmp.Message, int, error) {
+// payload 返回序号为 seq 的探测负载；Timestamp 报文格式固定，没有负载。
+func (p *ICMPProber) payload(seq int) []byte {
+	if p.timestamp {
+		return nil
+	}
+	return seqPayload(p.nonce, seq)
+}
+
+func (p *ICMPProber) echoMessage(seq int, payload []byte) (icmp.Message, int, error) {
+	if p.timestamp {
+		// Timestamp Request：ID(2) Seq(2) Originate(4) Receive(4) Transmit(4)
+		data := make([]byte, 16)
+		binary.BigEndian.PutUint16(data[0:2], uint16(p.id))
+		binary.BigEndian.PutUint16(data[2:4], uint16(wireSeq(seq)))
+		binary.BigEndian.PutUint32(data[4:8], msSinceMidnightUTC(time.Now()))
+		return icmp.Message{
+			Type: ipv4.ICMPTypeTimestamp,
+			Code: 0,
+			Body: &icmp.RawBody{Data: data},
+		}, 1, nil
+	}
 	if p.ipVersion == 4 {
 		return icmp.Message{
 			Type: ipv4.ICMPTypeEcho,
 			Code: 0,
-			Body: &icmp.Echo{ID: p.id, Seq: seq, Data: p.payload},
+			Body: &icmp.Echo{ID: p.id, Seq: wireSeq(seq), Data: payload},
 		}, 1, nil
 	}
 	return icmp.Message{
 		Type: ipv6.ICMPTypeEchoRequest,
 		Code: 0,
-		Body: &icmp.Echo{ID: p.id, Seq: seq, Data: p.payload},
+		Body: &icmp.Echo{ID: p.id, Seq: wireSeq(seq), Data: payload},
 	}, 58, nil
 }
 
-func (p *ICMPProber) classifyReply(proto int, rm *icmp.Message, seq int) ResponseType {
+func (p *ICMPProber) classifyReply(proto int, rm *icmp.Message, seq int, payload []byte) ResponseType {
 	if rm == nil {
 		return ResponseTypeTimeout
 	}
 
 	switch rm.Type {
 	case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
-		if echo, ok := rm.Body.(*icmp.Echo); ok && echo.ID == p.id && echo.Seq == seq {
+		if p.matchesIDSeq(rm.Body, seq, payload) {
+			return ResponseTypeEchoReply
+		}
+	case ipv4.ICMPTypeTimestampReply:
+		if p.timestamp && p.matchesIDSeq(rm.Body, seq, payload) {
 			return ResponseTypeEchoReply
 		}
 	case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
-		if p.matchesQuoted(proto, rm.Body, seq) {
+		if p.matchesQuoted(proto, rm.Body, seq, payload) {
 			return ResponseTypeTimeExceeded
 		}
+	case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
+		if p.matchesQuoted(proto, rm.Body, seq, payload) {
+			return ResponseTypeDestUnreach
+		}
 	}
 	return ResponseTypeTimeout
 }
 
-func (p *ICMPProber) matchesQuoted(proto int, body icmp.MessageBody, seq int) bool {
+// strictMatches 对已按 ID/Seq 匹配的回包做完整校验：Echo Reply 须来自目标且负载一致，
+// ICMP 错误须引用发往目标、负载一致的原始报文。
+func (p *ICMPProber) strictMatches(typ ResponseType, rm *icmp.Message, peer net.Addr, payload []byte) bool {
+	if typ == ResponseTypeEchoReply {
+		if ip := extractPeerIP(peer); ip == nil || !ip.Equal(p.target) {
+			return false
+		}
+		if echo, ok := rm.Body.(*icmp.Echo); ok {
+			return bytes.Equal(echo.Data, payload)
+		}
+		// Timestamp Reply 没有可比对的负载
+		return p.timestamp
+	}
+	return strictQuoteMatches(quotedPacket(rm.Body), p.ipVersion, p.target, payload)
+}
+
+func (p *ICMPProber) matchesQuoted(proto int, body icmp.MessageBody, seq int, payload []byte) bool {
 	var data []byte
 	switch b := body.(type) {
 	case *icmp.TimeExceeded:
 		data = b.Data
+	case *icmp.DstUnreach:
+		data = b.Data
 	default:
 		return false
 	}
@@ -220,8 +347,7 @@ func (p *ICMPProber) matchesQuoted(proto int, body icmp.MessageBody, seq int) bo
 		if err != nil {
 			return false
 		}
-		echo, ok := inner.Body.(*icmp.Echo)
-		return ok && echo.ID == p.id && echo.Seq == seq
+		return p.matchesIDSeq(inner.Body, seq, payload)
 	}
 
 	if _, err := ipv6.ParseHeader(data); err != nil {
@@ -235,8 +361,51 @@ func (p *ICMPProber) matchesQuoted(proto int, body icmp.MessageBody, seq int) bo
 	if err != nil {
 		return false
 	}
-	echo, ok := inner.Body.(*icmp.Echo)
-	return ok && echo.ID == p.id && echo.Seq == seq
+	return p.matchesIDSeq(inner.Body, seq, payload)
+}
+
+// matchesIDSeq 校验 Echo 或 Timestamp 报文中的 ID/Seq（16 位），Echo 报文还需负载中的 nonce 与完整序号一致。
+func (p *ICMPProber) matchesIDSeq(body icmp.MessageBody, seq int, payload []byte) bool {
+	switch b := body.(type) {
+	case *icmp.Echo:
+		return b.ID == p.id && b.Seq == wireSeq(seq) && payloadMatches(b.Data, payload)
+	case *icmp.RawBody:
+		// x/net/icmp 不解析 Timestamp 报文，按原始字节读取 ID/Seq
+		if !p.timestamp || len(b.Data) < 4 {
+			return false
+		}
+		id := int(binary.BigEndian.Uint16(b.Data[0:2]))
+		quotedSeq := int(binary.BigEndian.Uint16(b.Data[2:4]))
+		return id == p.id && quotedSeq == wireSeq(seq)
+	}
+	return false
+}
+
+// parseTimestampReply 解析 Timestamp Reply 中的三个时间戳（自 UTC 零点起的毫秒数）。
+func parseTimestampReply(rm *icmp.Message, received time.Time) *ICMPTimestamp {
+	raw, ok := rm.Body.(*icmp.RawBody)
+	if !ok || len(raw.Data) < 16 {
+		return nil
+	}
+	ts := &ICMPTimestamp{
+		Originate: binary.BigEndian.Uint32(raw.Data[4:8]),
+		Receive:   binary.BigEndian.Uint32(raw.Data[8:12]),
+		Transmit:  binary.BigEndian.Uint32(raw.Data[12:16]),
+	}
+	// 最高位置 1 表示非标准时间（RFC 792），无法用于时钟比对
+	const nonStandard = 1 << 31
+	if ts.Receive&nonStandard == 0 && ts.Transmit&nonStandard == 0 {
+		back := int64(msSinceMidnightUTC(received))
+		offset := ((int64(ts.Receive) - int64(ts.Originate)) + (int64(ts.Transmit) - back)) / 2
+		ts.ClockOffsetMs = &offset
+	}
+	return ts
+}
+
+func msSinceMidnightUTC(t time.Time) uint32 {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return uint32(t.Sub(midnight).Milliseconds())
 }
 
 func extractPeerIP(peer net.Addr) net.IP {
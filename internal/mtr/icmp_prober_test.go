@@ -0,0 +1,47 @@
+package mtr
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+func TestICMPTimestampReply(t *testing.T) {
+	p := &ICMPProber{ipVersion: 4, id: 0x1234, timestamp: true}
+
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint16(data[0:2], 0x1234)
+	binary.BigEndian.PutUint16(data[2:4], 7)
+	binary.BigEndian.PutUint32(data[4:8], msSinceMidnightUTC(now))
+	binary.BigEndian.PutUint32(data[8:12], msSinceMidnightUTC(now.Add(60*time.Millisecond)))
+	binary.BigEndian.PutUint32(data[12:16], msSinceMidnightUTC(now.Add(60*time.Millisecond)))
+
+	b, err := (&icmp.Message{Type: ipv4.ICMPTypeTimestampReply, Body: &icmp.RawBody{Data: data}}).Marshal(nil)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	rm, err := icmp.ParseMessage(1, b)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if typ := p.classifyReply(1, rm, 7, nil); typ != ResponseTypeEchoReply {
+		t.Fatalf("expected echo reply classification, got=%v", typ)
+	}
+	if typ := p.classifyReply(1, rm, 8, nil); typ != ResponseTypeTimeout {
+		t.Fatalf("expected seq mismatch to be ignored, got=%v", typ)
+	}
+
+	ts := parseTimestampReply(rm, now.Add(20*time.Millisecond))
+	if ts == nil || ts.ClockOffsetMs == nil {
+		t.Fatalf("expected timestamps with clock offset, got=%#v", ts)
+	}
+	// ((60-0) + (60-20)) / 2 = 50ms
+	if *ts.ClockOffsetMs != 50 {
+		t.Fatalf("unexpected clock offset: %d", *ts.ClockOffsetMs)
+	}
+}
@@ -0,0 +1,57 @@
+package mtr
+
+import "sort"
+
+// RoutingLoop 描述同一轮探测中同一响应 IP 出现在多个 TTL 的情况（路由环路或 MPLS TTL 传播异常）。
+type RoutingLoop struct {
+	IP   string `json:"ip"`
+	TTLs []int  `json:"ttls"`
+}
+
+// loopCandidate 判断单轮中的 hop 能否用于环路判断：只有中间路由返回的 TTL 超时才能说明报文在绕圈，
+// 目标的应答（echo reply、端口不可达等）与不可达报文会在多个 TTL 上重复出现，不代表环路。
+func loopCandidate(h RoundHop, targetIP string) bool {
+	return h.IP != "" && h.IP != targetIP && h.Kind == ResponseTypeTimeExceeded.String()
+}
+
+// roundLoopTTL 返回 last 的响应 IP 在同一轮更早的 hops 中出现过的最小 TTL，未出现时返回 0。
+// 只比较同一轮的结果，避免路径长度在轮次之间变化（如 anycast 切换站点）被误判为环路。
+func roundLoopTTL(hops []RoundHop, last RoundHop, targetIP string) int {
+	if !loopCandidate(last, targetIP) {
+		return 0
+	}
+	for _, h := range hops {
+		if h.TTL != last.TTL && h.IP == last.IP && loopCandidate(h, targetIP) {
+			return h.TTL
+		}
+	}
+	return 0
+}
+
+// addLoopTTLs 把 ttls 合并进已记录的环路 TTL 列表，保持升序且不重复。
+func addLoopTTLs(cur []int, ttls ...int) []int {
+	for _, ttl := range ttls {
+		i := sort.SearchInts(cur, ttl)
+		if i < len(cur) && cur[i] == ttl {
+			continue
+		}
+		cur = append(cur, 0)
+		copy(cur[i+1:], cur[i:])
+		cur[i] = ttl
+	}
+	return cur
+}
+
+// markLoops 标记 loops 中涉及的 hop（IP 与 TTL 均匹配）。
+func markLoops(hops []SnapshotHop, loops []RoutingLoop) {
+	for _, l := range loops {
+		for i := range hops {
+			if hops[i].IP != l.IP {
+				continue
+			}
+			if j := sort.SearchInts(l.TTLs, hops[i].TTL); j < len(l.TTLs) && l.TTLs[j] == hops[i].TTL {
+				hops[i].Loop = true
+			}
+		}
+	}
+}
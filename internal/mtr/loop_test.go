@@ -0,0 +1,82 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoundLoopTTL(t *testing.T) {
+	te := func(ttl int, ip string) RoundHop { return RoundHop{TTL: ttl, IP: ip, Kind: "time_exceeded"} }
+	hops := []RoundHop{te(1, "10.0.0.1"), te(2, "10.0.0.2"), te(3, "10.0.0.3"), {TTL: 4, Kind: "timeout"}}
+
+	if got := roundLoopTTL(hops, te(5, "10.0.0.2"), "10.0.0.9"); got != 2 {
+		t.Fatalf("expected loop back to ttl 2, got=%d", got)
+	}
+	if got := roundLoopTTL(hops, te(5, "10.0.0.5"), "10.0.0.9"); got != 0 {
+		t.Fatalf("expected no loop, got=%d", got)
+	}
+	// 目标应答与不可达报文在多个 TTL 上重复出现属正常现象
+	reply := RoundHop{TTL: 5, IP: "10.0.0.9", Kind: "echo_reply"}
+	if got := roundLoopTTL(append(hops, RoundHop{TTL: 4, IP: "10.0.0.9", Kind: "echo_reply"}), reply, "10.0.0.9"); got != 0 {
+		t.Fatalf("expected destination replies ignored, got=%d", got)
+	}
+	unreach := RoundHop{TTL: 5, IP: "10.0.0.3", Kind: "dest_unreach/host"}
+	if got := roundLoopTTL(hops, unreach, "10.0.0.9"); got != 0 {
+		t.Fatalf("expected unreachable replies ignored, got=%d", got)
+	}
+}
+
+func TestMarkLoops(t *testing.T) {
+	hops := []SnapshotHop{{TTL: 1, IP: "10.0.0.1"}, {TTL: 2, IP: "10.0.0.2"}, {TTL: 3, IP: "10.0.0.3"}, {TTL: 4, IP: "10.0.0.2"}, {TTL: 5, IP: "10.0.0.2"}}
+	markLoops(hops, []RoutingLoop{{IP: "10.0.0.2", TTLs: []int{2, 4}}})
+	for _, hop := range hops {
+		if hop.Loop != (hop.TTL == 2 || hop.TTL == 4) {
+			t.Fatalf("unexpected loop flag at ttl=%d: %v", hop.TTL, hop.Loop)
+		}
+	}
+	if got := addLoopTTLs([]int{2, 4}, 3, 4); !reflect.DeepEqual(got, []int{2, 3, 4}) {
+		t.Fatalf("unexpected merged ttls: %v", got)
+	}
+}
+
+// shrinkingProber 模拟路径在轮次之间变短：首轮在 TTL 3 到达目标，之后在 TTL 2 到达。
+type shrinkingProber struct {
+	calls int
+}
+
+func (p *shrinkingProber) Probe(_ context.Context, ttl, seq int) (*ProbeResult, error) {
+	p.calls++
+	destTTL := 3
+	if p.calls > 3 {
+		destTTL = 2
+	}
+	if ttl >= destTTL {
+		return &ProbeResult{TTL: ttl, Seq: seq, IP: net.ParseIP("127.0.0.1"), Type: ResponseTypeEchoReply, Kind: "echo_reply"}, nil
+	}
+	return &ProbeResult{TTL: ttl, Seq: seq, IP: net.IPv4(10, 0, 0, byte(ttl)), Type: ResponseTypeTimeExceeded, Kind: "time_exceeded"}, nil
+}
+
+func (p *shrinkingProber) SetTarget(net.IP) error { return nil }
+func (p *shrinkingProber) Close() error           { return nil }
+
+func TestControllerNoLoopOnPathLengthChange(t *testing.T) {
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 5, Count: 2, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &shrinkingProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for ev := range c.Events() {
+		if ev.Type == EventTypeWarning {
+			t.Fatalf("unexpected warning: %s", ev.Message)
+		}
+	}
+	if loops := c.Snapshot().Loops; loops != nil {
+		t.Fatalf("expected no loops, got=%#v", loops)
+	}
+}
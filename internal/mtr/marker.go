@@ -0,0 +1,20 @@
+package mtr
+
+import "time"
+
+// Marker 为用户在探测过程中插入的时间标记（如“此刻感觉网络变卡”），
+// 随快照导出，便于事后与各跳的时延、丢包对照。
+type Marker struct {
+	Time  time.Time `json:"time"`
+	Round int       `json:"round"`
+	Note  string    `json:"note,omitempty"`
+}
+
+// AddMarker 在当前轮次插入一个时间标记并返回它。
+func (c *Controller) AddMarker(note string) Marker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m := Marker{Time: time.Now(), Round: c.round, Note: note}
+	c.markers = append(c.markers, m)
+	return m
+}
@@ -3,6 +3,7 @@ package mtr
 import (
 	"errors"
 	"net"
+	"os"
 	"strings"
 )
 
@@ -18,6 +19,9 @@ func looksLikePermission(err error) bool {
 	if err == nil {
 		return false
 	}
+	if errors.Is(err, os.ErrPermission) {
+		return true
+	}
 	s := strings.ToLower(err.Error())
 	return strings.Contains(s, "operation not permitted") || strings.Contains(s, "permission denied")
 }
@@ -0,0 +1,87 @@
+package mtr
+
+import "time"
+
+// PathCompleteness 描述到目标为止各 TTL 的可观测程度。
+type PathCompleteness struct {
+	// Responded 为曾经有过响应的 TTL 数量。
+	Responded int `json:"responded"`
+	// Total 为到目标（未到达时为已探测的最大 TTL）为止的 TTL 数量。
+	Total int `json:"total"`
+	// Percent 为 Responded/Total 的百分比（0~100）。
+	Percent float64 `json:"percent"`
+	// DestinationReached 表示目标本身是否响应过。
+	DestinationReached bool `json:"destination_reached"`
+}
+
+const (
+	DestinationStateUnknown     = "unknown"
+	DestinationStateReached     = "reached"
+	DestinationStateUnreachable = "unreachable"
+)
+
+// DestinationStatus 描述最近一轮探测的终止方式：到达目标、被目标不可达终止，或两者都不是。
+type DestinationStatus struct {
+	State string `json:"state"`
+	TTL   int    `json:"ttl,omitempty"`
+	IP    string `json:"ip,omitempty"`
+	// Reason 为终止时的响应类别，如 dest_unreach/admin_prohibited。
+	Reason string `json:"reason,omitempty"`
+}
+
+// applySegmentLatency 计算每一跳相对前面各跳新增的平均时延（分段时延）。
+// hops 需按 TTL 升序。中间路由器常对 ICMP 降级处理，
+// 平均时延可能比后续 hop 还高，因此以前面各跳平均时延的最大值为基线，负值计为 0。
+func applySegmentLatency(out []SnapshotHop, df DurationFormat) {
+	var baseline time.Duration
+	for i, hop := range out {
+		if hop.Stats.Received == 0 {
+			continue
+		}
+		avg := hop.Stats.avg
+		if avg == 0 {
+			// 从 JSON 载入的快照没有纳秒精度的平均值
+			avg = time.Duration(hop.Stats.AvgMs) * time.Millisecond
+		}
+		seg := avg - baseline
+		if seg < 0 {
+			seg = 0
+		}
+		if avg > baseline {
+			baseline = avg
+		}
+		out[i].Stats.SegmentMs = durationMs(seg)
+		out[i].Stats.Segment = df.format(seg)
+	}
+}
+
+// computeCompleteness 计算路径完整度；hops 需按 TTL 升序排列。
+func computeCompleteness(hops []SnapshotHop, targetIP string) PathCompleteness {
+	var pc PathCompleteness
+	if len(hops) == 0 {
+		return pc
+	}
+
+	last := hops[len(hops)-1].TTL
+	for _, hop := range hops {
+		if targetIP != "" && hop.IP == targetIP && hop.Stats.Received > 0 {
+			last = hop.TTL
+			pc.DestinationReached = true
+			break
+		}
+	}
+
+	for _, hop := range hops {
+		if hop.TTL > last {
+			break
+		}
+		if hop.Stats.Received > 0 {
+			pc.Responded++
+		}
+	}
+	pc.Total = last
+	if pc.Total > 0 {
+		pc.Percent = float64(pc.Responded) / float64(pc.Total) * 100
+	}
+	return pc
+}
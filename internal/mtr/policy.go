@@ -0,0 +1,54 @@
+package mtr
+
+import "github.com/hyqhyq3/mymtr/internal/i18n"
+
+// PolicyViolation 为一条未满足的路径策略。
+type PolicyViolation struct {
+	Policy string `json:"policy"`
+	Detail string `json:"detail"`
+}
+
+// Message 返回面向用户的违规描述。
+func (v PolicyViolation) Message() string {
+	return i18n.Tf("warn.policyViolated", map[string]interface{}{"Policy": v.Policy, "Detail": v.Detail})
+}
+
+// PathPolicy 在每轮结束时基于完整快照检查路径约束（如必须经过某运营商、不得离开某国家）。
+type PathPolicy interface {
+	Evaluate(s *Snapshot) []PolicyViolation
+}
+
+// SetPathPolicy 设置路径策略，需在 Run 之前调用。
+func (c *Controller) SetPathPolicy(p PathPolicy) {
+	c.policy = p
+}
+
+// checkPolicy 在一轮结束后评估路径策略并保存结果，返回需要告警的违规：每条策略在整个探测过程中只告警一次，
+// 路径来回变化导致策略反复违规时不重复告警（最近一轮的结果见快照中的 Violations，运行期间的累计结果见 Violated）。
+func (c *Controller) checkPolicy() []PolicyViolation {
+	if c.policy == nil {
+		return nil
+	}
+	violations := c.policy.Evaluate(c.Snapshot())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var fresh []PolicyViolation
+	for _, v := range violations {
+		if !containsPolicy(c.violated, v.Policy) {
+			c.violated = append(c.violated, v)
+			fresh = append(fresh, v)
+		}
+	}
+	c.violations = violations
+	return fresh
+}
+
+func containsPolicy(vs []PolicyViolation, policy string) bool {
+	for _, v := range vs {
+		if v.Policy == policy {
+			return true
+		}
+	}
+	return false
+}
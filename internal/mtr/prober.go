@@ -3,9 +3,15 @@ package mtr
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net"
+	"os"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/net/icmp"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
 )
 
 type Prober interface {
@@ -21,6 +27,22 @@ type ProbeResult struct {
 	RTT       time.Duration
 	Type      ResponseType
 	Timestamp time.Time
+	// Kind 为细分的响应类别（如 time_exceeded、dest_unreach/admin_prohibited），用于统计。
+	Kind string
+	// Suspicious 为本次探测期间因 --strict-match 校验不通过而被丢弃的回包数。
+	Suspicious int
+
+	// ICMPTimestamp 仅在 icmp-ts 模式收到 Timestamp Reply 时填充。
+	ICMPTimestamp *ICMPTimestamp
+}
+
+// ICMPTimestamp 为 ICMP Timestamp Reply 携带的时间戳（自 UTC 零点起的毫秒数）。
+type ICMPTimestamp struct {
+	Originate uint32 `json:"originate_ms"`
+	Receive   uint32 `json:"receive_ms"`
+	Transmit  uint32 `json:"transmit_ms"`
+	// ClockOffsetMs 为远端时钟相对本机的粗略偏差；远端返回非标准时间时为空。
+	ClockOffsetMs *int64 `json:"clock_offset_ms,omitempty"`
 }
 
 type ResponseType int
@@ -30,17 +52,133 @@ const (
 	ResponseTypeEchoReply
 	ResponseTypeTimeExceeded
 	ResponseTypeDestUnreach
+	// ResponseTypeSendFailed 表示探测包未能发出（如本机无路由、网卡断开），与超时分开统计。
+	ResponseTypeSendFailed
 )
 
-func NewProber(protocol Protocol, ipVersion int, timeout time.Duration) (Prober, error) {
-	switch protocol {
+// ProberOptions 为创建探测器所需的参数。
+type ProberOptions struct {
+	IPVersion int
+	Timeout   time.Duration
+	// Source 非空时绑定该源地址发送与接收（多出口主机）。
+	Source net.IP
+	// StrictMatch 为 true 时对回包做完整校验（见 strictQuoteMatches），仅宽松匹配的回包记为可疑并丢弃。
+	StrictMatch bool
+	// DSCP 为探测包的 DSCP 标记，0 表示不设置。
+	DSCP int
+}
+
+// NewProber 按 cfg 中的协议、IP 版本、超时与源地址创建探测器。
+func NewProber(cfg *Config) (Prober, error) {
+	opts, err := proberOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
 	case ProtocolICMP:
-		return NewICMPProber(ipVersion, timeout)
+		return NewICMPProber(opts)
+	case ProtocolICMPTimestamp:
+		return NewICMPTimestampProber(opts)
 	case ProtocolUDP:
-		return NewUDPProber(ipVersion, timeout)
+		return NewUDPProber(opts)
 	default:
-		return nil, fmt.Errorf("未知 protocol：%s", protocol)
+		return nil, &UnknownProtocolError{Protocol: cfg.Protocol}
 	}
 }
 
-var ErrProtocolNotImplemented = errors.New("协议暂未实现")
+// 权限不足时自动降级使用的无特权探测方式，记录在 Config.ProbeMode / Snapshot.ProbeMode 中。
+const (
+	// ProbeModeICMPDgram 使用无特权的数据报 ICMP 套接字（macOS 等平台）。
+	ProbeModeICMPDgram = "icmp-dgram"
+	// ProbeModeUDPRecvErr 使用 connect 的 UDP 套接字，并通过 IP_RECVERR 错误队列读取中间跳的 ICMP 错误（Linux）。
+	ProbeModeUDPRecvErr = "udp-recverr"
+)
+
+// NewProberWithFallback 与 NewProber 相同，但原始套接字权限不足时会改用当前平台可用的无特权探测方式，
+// 并将所用方式写入 cfg.ProbeMode；没有可用的降级方式时返回原始的权限错误。
+// cfg.Unprivileged 为 true 时跳过原始套接字，直接使用无特权方式。
+func NewProberWithFallback(cfg *Config) (Prober, error) {
+	cfg.ProbeMode = ""
+	if cfg.Unprivileged {
+		opts, err := proberOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		prober, mode, err := newUnprivilegedProber(opts)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ProbeMode = mode
+		return prober, nil
+	}
+	prober, err := NewProber(cfg)
+	var permErr *PermissionError
+	if err == nil || !errors.As(err, &permErr) {
+		return prober, err
+	}
+
+	opts, optErr := proberOptions(cfg)
+	if optErr != nil {
+		return nil, optErr
+	}
+	fallback, mode, fbErr := newUnprivilegedProber(opts)
+	if fbErr != nil {
+		return nil, err
+	}
+	cfg.ProbeMode = mode
+	return fallback, nil
+}
+
+func proberOptions(cfg *Config) (ProberOptions, error) {
+	opts := ProberOptions{
+		IPVersion:   cfg.IPVersion,
+		Timeout:     cfg.maxTimeout(),
+		StrictMatch: cfg.StrictMatch,
+		DSCP:        cfg.DSCP,
+	}
+	if src := strings.TrimSpace(cfg.Source); src != "" {
+		ip := net.ParseIP(src)
+		if ip == nil || (cfg.IPVersion == 4) != (ip.To4() != nil) {
+			return opts, errors.New(i18n.Tf("err.sourceInvalid", map[string]interface{}{"Source": src, "Version": cfg.IPVersion}))
+		}
+		opts.Source = ip
+	}
+	return opts, nil
+}
+
+// listenICMP 创建接收 ICMP 的原始套接字；指定源地址时只绑定该地址。
+func listenICMP(ipVersion int, source net.IP) (*icmp.PacketConn, error) {
+	network := "ip4:icmp"
+	addr := "0.0.0.0"
+	if ipVersion == 6 {
+		network = "ip6:ipv6-icmp"
+		addr = "::"
+	}
+	if source != nil {
+		addr = source.String()
+	}
+	return icmp.ListenPacket(network, addr)
+}
+
+// listenICMPDgram 创建无特权的数据报 ICMP 套接字（不需要 root / CAP_NET_RAW）。
+func listenICMPDgram(ipVersion int, source net.IP) (*icmp.PacketConn, error) {
+	network := "udp4"
+	addr := "0.0.0.0"
+	if ipVersion == 6 {
+		network = "udp6"
+		addr = "::"
+	}
+	if source != nil {
+		addr = source.String()
+	}
+	return icmp.ListenPacket(network, addr)
+}
+
+var probeIDSeq uint32
+
+// nextProbeID 为同一进程内的多个探测器分配不同的 ICMP ID，避免并行探测时回包串扰。
+func nextProbeID() int {
+	n := atomic.AddUint32(&probeIDSeq, 1) - 1
+	return (os.Getpid() + int(n)) & 0xffff
+}
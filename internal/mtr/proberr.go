@@ -0,0 +1,78 @@
+package mtr
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// ProbeErrorCounts 统计探测器遇到的非致命错误，用于区分“网络正常但探测器异常”的情况。
+type ProbeErrorCounts struct {
+	// Send 为发送失败次数。
+	Send uint64 `json:"send"`
+	// Parse 为收到但无法解析的 ICMP 报文数。
+	Parse uint64 `json:"parse"`
+	// Read 为套接字读错误（如连接被重置）次数，发生时本次探测按超时处理。
+	Read uint64 `json:"read"`
+	// LastSendError 为最近一次发送失败的原因，便于判断本机网络问题。
+	LastSendError string `json:"last_send_error,omitempty"`
+}
+
+func (c ProbeErrorCounts) Total() uint64 {
+	return c.Send + c.Parse + c.Read
+}
+
+// probeErrorReporter 由支持错误统计的探测器实现。
+type probeErrorReporter interface {
+	ProbeErrors() ProbeErrorCounts
+}
+
+type probeErrorCounter struct {
+	send  atomic.Uint64
+	parse atomic.Uint64
+	read  atomic.Uint64
+
+	lastSend atomic.Value // string
+}
+
+func (c *probeErrorCounter) ProbeErrors() ProbeErrorCounts {
+	return ProbeErrorCounts{
+		Send:  c.send.Load(),
+		Parse: c.parse.Load(),
+		Read:  c.read.Load(),
+
+		LastSendError: c.lastSendError(),
+	}
+}
+
+func (c *probeErrorCounter) lastSendError() string {
+	s, _ := c.lastSend.Load().(string)
+	return s
+}
+
+// sendFailed 记录一次发送失败。套接字已关闭时返回原错误以结束探测，
+// 其余错误（如 ENETUNREACH）转换为 send_failed 结果，会话继续进行。
+func (c *probeErrorCounter) sendFailed(ttl, seq int, err error) (*ProbeResult, error) {
+	c.send.Add(1)
+	if isFatalSendError(err) {
+		return nil, err
+	}
+	c.lastSend.Store(err.Error())
+	return &ProbeResult{
+		TTL:       ttl,
+		Seq:       seq,
+		Type:      ResponseTypeSendFailed,
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// isFatalReadError 判断读错误是否意味着套接字已不可用（如被关闭）。
+func isFatalReadError(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
+
+// isFatalSendError 判断发送错误是否意味着探测器已不可用；其余发送错误只影响单个探测包。
+func isFatalSendError(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,29 @@
+package mtr
+
+import "time"
+
+// RoundProgress 为当前轮次的探测进度，用于区分“某跳响应慢”与“程序停滞”。
+type RoundProgress struct {
+	Round int
+	// TTL 为正在探测的 TTL；0 表示不在探测中（轮次之间或已结束）。
+	TTL int
+	// InFlight 为已发出、尚未得到结果的探测包数。
+	InFlight int
+	// Started 为本轮开始时间。
+	Started time.Time
+}
+
+// Progress 返回当前轮次的探测进度，可在 Run 运行期间随时调用。
+func (c *Controller) Progress() RoundProgress {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.progress
+}
+
+// setProbing 记录正在探测的 TTL 及在途探测数。
+func (c *Controller) setProbing(ttl, inFlight int) {
+	c.mu.Lock()
+	c.progress.TTL = ttl
+	c.progress.InFlight = inFlight
+	c.mu.Unlock()
+}
@@ -0,0 +1,53 @@
+package mtr
+
+import (
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// ReachabilityChange 描述目标可达性的一次变化，由 EventTypeReachability 事件携带。
+type ReachabilityChange struct {
+	Reachable bool      `json:"reachable"`
+	At        time.Time `json:"at"`
+	// Downtime/Lost 仅在恢复时有效：不可达持续时长，以及期间未到达目标的探测数（每轮一个）。
+	Downtime time.Duration `json:"downtime,omitempty"`
+	Lost     int           `json:"lost,omitempty"`
+}
+
+// Message 返回面向用户的描述。
+func (r ReachabilityChange) Message() string {
+	if r.Reachable {
+		return i18n.Tf("event.destRecovered", map[string]interface{}{
+			"Downtime": r.Downtime.Round(time.Second).String(),
+			"Lost":     r.Lost,
+		})
+	}
+	return i18n.Tf("event.destLost", map[string]interface{}{"Time": r.At.Format("15:04:05")})
+}
+
+// reachabilityTracker 根据每轮是否到达目标判断可达性变化。
+// 目标从未到达过时不报告（如目标本身不响应探测），避免持续模式下误报。
+type reachabilityTracker struct {
+	reachedOnce bool
+	down        bool
+	since       time.Time
+	lost        int
+}
+
+func (t *reachabilityTracker) update(reached bool, now time.Time) *ReachabilityChange {
+	switch {
+	case reached && t.down:
+		change := &ReachabilityChange{Reachable: true, At: now, Downtime: now.Sub(t.since), Lost: t.lost}
+		t.down, t.lost = false, 0
+		return change
+	case reached:
+		t.reachedOnce = true
+	case t.down:
+		t.lost++
+	case t.reachedOnce:
+		t.down, t.since, t.lost = true, now, 1
+		return &ReachabilityChange{Reachable: false, At: now}
+	}
+	return nil
+}
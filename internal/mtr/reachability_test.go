@@ -0,0 +1,38 @@
+package mtr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReachabilityTracker(t *testing.T) {
+	var tr reachabilityTracker
+	base := time.Date(2024, 1, 1, 14, 2, 13, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	// 从未到达过的目标不报告
+	if c := tr.update(false, at(0)); c != nil {
+		t.Fatalf("unexpected change before first reach: %+v", c)
+	}
+	if c := tr.update(true, at(1)); c != nil {
+		t.Fatalf("first reach should not be reported: %+v", c)
+	}
+
+	lost := tr.update(false, at(2))
+	if lost == nil || lost.Reachable || !lost.At.Equal(at(2)) {
+		t.Fatalf("expected unreachable transition, got=%+v", lost)
+	}
+	for i := 3; i < 5; i++ {
+		if c := tr.update(false, at(i)); c != nil {
+			t.Fatalf("unexpected repeated change: %+v", c)
+		}
+	}
+
+	rec := tr.update(true, at(96))
+	if rec == nil || !rec.Reachable || rec.Downtime != 94*time.Second || rec.Lost != 3 {
+		t.Fatalf("unexpected recovery: %+v", rec)
+	}
+	if c := tr.update(true, at(97)); c != nil {
+		t.Fatalf("unexpected change while reachable: %+v", c)
+	}
+}
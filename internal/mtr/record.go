@@ -0,0 +1,80 @@
+package mtr
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// ProbeRecord 为单个探测的原始结果，每条记录对应 JSONL 文件中的一行。
+type ProbeRecord struct {
+	TargetIP string    `json:"target_ip"`
+	Round    int       `json:"round"`
+	TTL      int       `json:"ttl"`
+	Seq      int       `json:"seq"`
+	IP       string    `json:"ip,omitempty"`
+	RTTMs    float64   `json:"rtt_ms,omitempty"`
+	Type     string    `json:"type"`
+	Kind     string    `json:"kind,omitempty"`
+	SentAt   time.Time `json:"sent_at"`
+	// ReceivedAt 仅在收到回包时填充（SentAt + RTT）。
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+	Suspicious int        `json:"suspicious,omitempty"`
+}
+
+// ProbeRecorder 将探测结果逐条写为 JSONL，可在多个 Controller 间共享。
+type ProbeRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewProbeRecorder(w io.Writer) *ProbeRecorder {
+	return &ProbeRecorder{enc: json.NewEncoder(w)}
+}
+
+// Record 写入一条记录。
+func (r *ProbeRecorder) Record(rec ProbeRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(rec)
+}
+
+func newProbeRecord(targetIP string, round, ttl, seq int, sentAt time.Time, res *ProbeResult) ProbeRecord {
+	rec := ProbeRecord{TargetIP: targetIP, Round: round, TTL: ttl, Seq: seq, Type: ResponseTypeTimeout.String(), SentAt: sentAt}
+	if res == nil {
+		return rec
+	}
+	rec.Type = res.Type.String()
+	rec.Kind = res.Kind
+	rec.Suspicious = res.Suspicious
+	if res.IP != nil {
+		rec.IP = res.IP.String()
+		rec.RTTMs = float64(res.RTT.Microseconds()) / 1000
+		at := sentAt.Add(res.RTT)
+		rec.ReceivedAt = &at
+	}
+	return rec
+}
+
+// SetProbeRecorder 设置探测结果记录器，需在 Run 之前调用。
+func (c *Controller) SetProbeRecorder(r *ProbeRecorder) {
+	c.recorder = r
+}
+
+// recordProbe 记录一次探测结果；写入失败时停止记录并返回一次告警。
+func (c *Controller) recordProbe(round, ttl, seq int, sentAt time.Time, res *ProbeResult) string {
+	if c.recorder == nil {
+		return ""
+	}
+	c.mu.RLock()
+	targetIP := c.config.TargetIP
+	c.mu.RUnlock()
+	if err := c.recorder.Record(newProbeRecord(targetIP, round, ttl, seq, sentAt, res)); err != nil {
+		c.recorder = nil
+		return i18n.Tf("warn.recordFailed", map[string]interface{}{"Error": err.Error()})
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package mtr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestControllerRecordsProbes(t *testing.T) {
+	prober := &scriptedProber{replies: map[int]*ProbeResult{
+		1: {IP: net.ParseIP("10.0.0.1"), Type: ResponseTypeTimeExceeded, Kind: "time_exceeded", RTT: 1500 * time.Microsecond},
+		3: {IP: net.ParseIP("127.0.0.1"), Type: ResponseTypeEchoReply, Kind: "echo_reply", RTT: 2 * time.Millisecond},
+	}}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 5, Count: 2, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	c.SetProbeRecorder(NewProbeRecorder(&buf))
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var recs []ProbeRecord
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var r ProbeRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("invalid line %q: %v", sc.Text(), err)
+		}
+		recs = append(recs, r)
+	}
+	if len(recs) != 6 {
+		t.Fatalf("expected 6 records (3 per round), got=%d", len(recs))
+	}
+	first, lost, last := recs[0], recs[1], recs[5]
+	if first.Round != 0 || first.TTL != 1 || first.IP != "10.0.0.1" || first.RTTMs != 1.5 || first.Type != "time_exceeded" || first.TargetIP != "127.0.0.1" {
+		t.Fatalf("unexpected first record: %+v", first)
+	}
+	if first.SentAt.IsZero() || first.ReceivedAt == nil || !first.ReceivedAt.Equal(first.SentAt.Add(1500*time.Microsecond)) {
+		t.Fatalf("unexpected timestamps: %+v", first)
+	}
+	if lost.Type != "timeout" || lost.IP != "" || lost.ReceivedAt != nil {
+		t.Fatalf("unexpected timeout record: %+v", lost)
+	}
+	if last.Round != 1 || last.TTL != 3 || last.Seq != 6 || last.Type != "echo_reply" {
+		t.Fatalf("unexpected last record: %+v", last)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestControllerRecordFailureWarnsOnce(t *testing.T) {
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 3, Count: 1, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &scriptedProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetProbeRecorder(NewProbeRecorder(failingWriter{}))
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	warnings := 0
+	for ev := range c.Events() {
+		if ev.Type == EventTypeWarning {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Fatalf("expected one warning, got=%d", warnings)
+	}
+}
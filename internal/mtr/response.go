@@ -0,0 +1,85 @@
+package mtr
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func (t ResponseType) String() string {
+	switch t {
+	case ResponseTypeEchoReply:
+		return "echo_reply"
+	case ResponseTypeTimeExceeded:
+		return "time_exceeded"
+	case ResponseTypeDestUnreach:
+		return "dest_unreach"
+	case ResponseTypeSendFailed:
+		return "send_failed"
+	default:
+		return "timeout"
+	}
+}
+
+// responseKind 根据实际收到的 ICMP 报文给出细分的响应类别，
+// 目标不可达会附带子类型（如 dest_unreach/admin_prohibited）。
+func responseKind(ipVersion int, typ ResponseType, rm *icmp.Message) string {
+	if rm == nil {
+		return typ.String()
+	}
+	switch rm.Type {
+	case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
+		return "echo_reply"
+	case ipv4.ICMPTypeTimestampReply:
+		return "timestamp_reply"
+	case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
+		return "time_exceeded"
+	case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
+		return "dest_unreach/" + unreachCodeName(ipVersion, rm.Code)
+	}
+	return typ.String()
+}
+
+// isTerminalUnreach 判断响应类别是否表示探测包无法继续前进（路径在此终止）。
+// frag_needed 只与包大小有关，不视为终止。
+func isTerminalUnreach(kind string) bool {
+	return strings.HasPrefix(kind, "dest_unreach/") && kind != "dest_unreach/frag_needed"
+}
+
+var unreachCodeNamesV4 = map[int]string{
+	0:  "net",
+	1:  "host",
+	2:  "protocol",
+	3:  "port",
+	4:  "frag_needed",
+	5:  "source_route_failed",
+	6:  "net_unknown",
+	7:  "host_unknown",
+	9:  "net_prohibited",
+	10: "host_prohibited",
+	13: "admin_prohibited",
+}
+
+var unreachCodeNamesV6 = map[int]string{
+	0: "no_route",
+	1: "admin_prohibited",
+	2: "beyond_scope",
+	3: "address",
+	4: "port",
+	5: "policy_failed",
+	6: "reject_route",
+}
+
+func unreachCodeName(ipVersion, code int) string {
+	names := unreachCodeNamesV4
+	if ipVersion == 6 {
+		names = unreachCodeNamesV6
+	}
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return "code_" + strconv.Itoa(code)
+}
@@ -0,0 +1,84 @@
+package mtr
+
+import "time"
+
+// defaultRoundHistory 为 Config.RoundHistory 未设置时保留的轮次快照数。
+const defaultRoundHistory = 100
+
+// RoundSnapshot 为单轮探测的原始结果（非累计统计），供热力图、diff、导出等按轮次使用。
+type RoundSnapshot struct {
+	Round      int               `json:"round"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Hops       []RoundHop        `json:"hops"`
+	Dest       DestinationStatus `json:"destination"`
+}
+
+// RoundHop 为某轮中单个 TTL 的探测结果。
+type RoundHop struct {
+	TTL int    `json:"ttl"`
+	IP  string `json:"ip,omitempty"`
+	// Kind 为响应类别（timeout、time_exceeded、send_failed 等）。
+	Kind  string    `json:"kind"`
+	RTTMs float64   `json:"rtt_ms,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+func newRoundHop(ttl int, res *ProbeResult) RoundHop {
+	h := RoundHop{TTL: ttl, Kind: ResponseTypeTimeout.String(), At: time.Now()}
+	if res == nil {
+		return h
+	}
+	h.Kind = res.Type.String()
+	if res.Kind != "" {
+		h.Kind = res.Kind
+	}
+	if !res.Timestamp.IsZero() {
+		h.At = res.Timestamp
+	}
+	if res.IP != nil && res.Type != ResponseTypeTimeout {
+		h.IP = res.IP.String()
+		h.RTTMs = float64(res.RTT) / float64(time.Millisecond)
+	}
+	return h
+}
+
+// recordRound 保存一轮结果，只保留最近 RoundHistory 轮。
+func (c *Controller) recordRound(r RoundSnapshot) {
+	limit := c.config.RoundHistory
+	if limit <= 0 {
+		limit = defaultRoundHistory
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rounds = append(c.rounds, r)
+	if n := len(c.rounds) - limit; n > 0 {
+		c.rounds = append(c.rounds[:0:0], c.rounds[n:]...)
+	}
+}
+
+// RoundSnapshot 返回指定轮次（从 0 开始）的结果；该轮尚未结束或已超出保留范围时返回 false。
+func (c *Controller) RoundSnapshot(round int) (*RoundSnapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for i := len(c.rounds) - 1; i >= 0; i-- {
+		if c.rounds[i].Round == round {
+			r := c.rounds[i]
+			r.Hops = append([]RoundHop(nil), r.Hops...)
+			return &r, true
+		}
+	}
+	return nil, false
+}
+
+// RoundSnapshots 返回当前保留的全部轮次结果（按轮次升序）。
+func (c *Controller) RoundSnapshots() []RoundSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]RoundSnapshot, len(c.rounds))
+	for i, r := range c.rounds {
+		r.Hops = append([]RoundHop(nil), r.Hops...)
+		out[i] = r
+	}
+	return out
+}
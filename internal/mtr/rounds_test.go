@@ -0,0 +1,51 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRoundSnapshotRetention(t *testing.T) {
+	prober := &scriptedProber{replies: map[int]*ProbeResult{
+		1: {IP: net.ParseIP("10.0.0.1"), Type: ResponseTypeTimeExceeded, Kind: "time_exceeded", RTT: 1500 * time.Microsecond},
+		3: {IP: net.ParseIP("127.0.0.1"), Type: ResponseTypeEchoReply, Kind: "echo_reply"},
+	}}
+	cfg := &Config{Target: "127.0.0.1", MaxHops: 5, Count: 4, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4, RoundHistory: 2}
+	c, err := NewController(cfg, prober, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.RoundSnapshot(1); ok {
+		t.Fatal("expected round 1 to be evicted")
+	}
+	all := c.RoundSnapshots()
+	if len(all) != 2 || all[0].Round != 2 || all[1].Round != 3 {
+		t.Fatalf("unexpected retained rounds: %+v", all)
+	}
+
+	r, ok := c.RoundSnapshot(3)
+	if !ok {
+		t.Fatal("expected round 3 to be retained")
+	}
+	if r.StartedAt.IsZero() || r.FinishedAt.Before(r.StartedAt) {
+		t.Fatalf("unexpected round timestamps: %v - %v", r.StartedAt, r.FinishedAt)
+	}
+	if len(r.Hops) != 3 {
+		t.Fatalf("expected 3 hops, got=%+v", r.Hops)
+	}
+	if h := r.Hops[0]; h.IP != "10.0.0.1" || h.Kind != "time_exceeded" || h.RTTMs != 1.5 {
+		t.Fatalf("unexpected hop 1: %+v", h)
+	}
+	if h := r.Hops[1]; h.IP != "" || h.Kind != "timeout" {
+		t.Fatalf("unexpected hop 2: %+v", h)
+	}
+	if r.Dest.State != DestinationStateReached {
+		t.Fatalf("unexpected destination: %+v", r.Dest)
+	}
+}
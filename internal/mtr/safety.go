@@ -0,0 +1,135 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// 编译进二进制的安全上限，防止误操作产生滥用性的探测洪泛。分发给其他团队使用时可在构建时收紧，例如
+// -ldflags "-X github.com/hyqhyq3/mymtr/internal/mtr.safetyMaxPPS=20"；无法解析的值按默认值处理。
+var (
+	safetyMaxPPS      = "50"
+	safetyMaxTargets  = "16"
+	safetyMinInterval = "100ms"
+)
+
+const (
+	defaultSafetyMaxPPS      = 50
+	defaultSafetyMaxTargets  = 16
+	defaultSafetyMinInterval = 100 * time.Millisecond
+)
+
+// SafetyLimits 为探测的硬性安全上限，由 Controller 强制执行；Config.Safety 为 nil 表示不限制
+// （嵌入方自行负责，或 CLI 指定了 --i-know-what-im-doing）。同一实例可在多个 Controller 间共享，
+// 此时 MaxPPS 与 MaxTargets 按整体计算。
+type SafetyLimits struct {
+	// MaxPPS 为每秒发出的探测包上限，超出时发送前排队等待。
+	MaxPPS float64
+	// MaxTargets 为同时运行的 Controller（探测目标）数上限。
+	MaxTargets int
+	// MinInterval 为轮间隔下限。
+	MinInterval time.Duration
+
+	mu     sync.Mutex
+	next   time.Time
+	active int
+}
+
+var (
+	compiledSafetyOnce sync.Once
+	compiledSafety     *SafetyLimits
+)
+
+// CompiledSafetyLimits 返回编译进二进制的安全上限；进程内返回同一实例，使所有 Controller 共享配额。
+func CompiledSafetyLimits() *SafetyLimits {
+	compiledSafetyOnce.Do(func() {
+		compiledSafety = &SafetyLimits{
+			MaxPPS:      defaultSafetyMaxPPS,
+			MaxTargets:  defaultSafetyMaxTargets,
+			MinInterval: defaultSafetyMinInterval,
+		}
+		if v, err := strconv.ParseFloat(safetyMaxPPS, 64); err == nil && v > 0 {
+			compiledSafety.MaxPPS = v
+		}
+		if v, err := strconv.Atoi(safetyMaxTargets); err == nil && v > 0 {
+			compiledSafety.MaxTargets = v
+		}
+		if v, err := time.ParseDuration(safetyMinInterval); err == nil && v > 0 {
+			compiledSafety.MinInterval = v
+		}
+	})
+	return compiledSafety
+}
+
+// CheckTargets 检查同时探测 n 个目标是否超出上限，便于在启动前报错。
+func (l *SafetyLimits) CheckTargets(n int) error {
+	if l == nil || l.MaxTargets <= 0 || n <= l.MaxTargets {
+		return nil
+	}
+	return errors.New(i18n.Tf("err.safetyTargets", map[string]interface{}{"Count": n, "Max": l.MaxTargets}))
+}
+
+// checkInterval 检查轮间隔是否低于下限。
+func (l *SafetyLimits) checkInterval(d time.Duration) error {
+	if l == nil || d >= l.MinInterval {
+		return nil
+	}
+	return errors.New(i18n.Tf("err.safetyInterval", map[string]interface{}{"Interval": d, "Min": l.MinInterval}))
+}
+
+// acquire 在 Controller 开始运行时占用一个目标名额。
+func (l *SafetyLimits) acquire() error {
+	if l == nil || l.MaxTargets <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active >= l.MaxTargets {
+		return errors.New(i18n.Tf("err.safetyTargets", map[string]interface{}{"Count": l.active + 1, "Max": l.MaxTargets}))
+	}
+	l.active++
+	return nil
+}
+
+func (l *SafetyLimits) release() {
+	if l == nil || l.MaxTargets <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+}
+
+// wait 在发出探测包前调用：按 MaxPPS 为所有共享该实例的 Controller 排出发送时刻，必要时等待。
+func (l *SafetyLimits) wait(ctx context.Context) error {
+	if l == nil || l.MaxPPS <= 0 {
+		return nil
+	}
+	gap := time.Duration(float64(time.Second) / l.MaxPPS)
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(gap)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
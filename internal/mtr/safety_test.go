@@ -0,0 +1,83 @@
+package mtr
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSafetyLimitsPacing(t *testing.T) {
+	l := &SafetyLimits{MaxPPS: 200}
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Fatalf("11 probes at 200pps took only %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := &SafetyLimits{MaxPPS: 1}
+	slow.wait(ctx)
+	if err := slow.wait(ctx); err == nil {
+		t.Fatal("expected queued wait to stop when the context is cancelled")
+	}
+}
+
+func TestSafetyLimitsTargetsAndInterval(t *testing.T) {
+	l := &SafetyLimits{MaxTargets: 2, MinInterval: 100 * time.Millisecond}
+	if err := l.CheckTargets(3); err == nil {
+		t.Fatal("expected 3 targets to exceed the limit")
+	}
+	if err := l.acquire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.acquire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.acquire(); err == nil {
+		t.Fatal("expected third concurrent controller to be rejected")
+	}
+	l.release()
+	if err := l.acquire(); err != nil {
+		t.Fatalf("released slot should be reusable: %v", err)
+	}
+
+	cfg := &Config{Target: "192.0.2.1", IPVersion: 4, Interval: 10 * time.Millisecond, Safety: l}
+	if _, err := NewController(cfg, &scriptedProber{}, nil); err == nil {
+		t.Fatal("expected interval below the safety minimum to be rejected")
+	}
+	cfg = &Config{Target: "192.0.2.1", IPVersion: 4, Interval: time.Second, Safety: l}
+	c, err := NewController(cfg, &scriptedProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetInterval(time.Millisecond)
+	if got := c.Config().Interval; got != 100*time.Millisecond {
+		t.Fatalf("SetInterval should clamp to the minimum, got %v", got)
+	}
+
+	// ECMP 枚举同样占用目标名额，名额已满时不发出任何探测
+	fp := &scriptedFlowProber{}
+	ecmpCfg := &Config{Target: "192.0.2.1", IPVersion: 4, Protocol: ProtocolUDP, Interval: time.Second, Safety: l}
+	if _, err := DiscoverECMP(context.Background(), ecmpCfg, fp, 2); err == nil || len(fp.probed) != 0 {
+		t.Fatalf("expected ECMP discovery to respect MaxTargets, err=%v probed=%v", err, fp.probed)
+	}
+
+	var unlimited *SafetyLimits
+	if err := unlimited.CheckTargets(1000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scriptedFlowProber 为支持 ProbeFlow 的 scriptedProber。
+type scriptedFlowProber struct {
+	scriptedProber
+}
+
+func (p *scriptedFlowProber) ProbeFlow(ctx context.Context, ttl, seq, flow int) (*ProbeResult, error) {
+	return p.Probe(ctx, ttl, seq)
+}
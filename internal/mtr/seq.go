@@ -0,0 +1,44 @@
+package mtr
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// 探测序号：seqAllocator 按发送顺序分配单调递增的序号（不回绕，用于记录与日志）。
+// ICMP 报文只有 16 位 seq 字段，发送时取低 16 位（wireSeq）；长时间运行后 16 位 seq 必然重复，
+// 因此 Echo 负载中同时携带完整序号与每个 prober 随机生成的 nonce，回包（或路由器引用的原始报文）
+// 带有负载时据此排除撞上同一 16 位 seq 的过期回包或其他进程的探测。
+type seqAllocator struct {
+	last int
+}
+
+// next 返回下一个序号（从 1 开始）。
+func (a *seqAllocator) next() int {
+	a.last++
+	return a.last
+}
+
+// wireSeq 返回写入 16 位 seq 字段的值。
+func wireSeq(seq int) int {
+	return seq & 0xffff
+}
+
+// probePayloadPrefix 为探测负载的固定前缀，便于在抓包中识别。
+const probePayloadPrefix = "mymt"
+
+// seqPayload 构造携带 nonce 与完整序号的探测负载："mymt" | nonce(4) | seq(4)。
+func seqPayload(nonce uint32, seq int) []byte {
+	b := make([]byte, 12)
+	copy(b, probePayloadPrefix)
+	binary.BigEndian.PutUint32(b[4:8], nonce)
+	binary.BigEndian.PutUint32(b[8:12], uint32(seq))
+	return b
+}
+
+// payloadMatches 比对回包中能拿到的那部分负载：路由器可能只引用 8 字节头部甚至截断负载，
+// 缺失部分不作判断，已有部分必须一致。
+func payloadMatches(got, want []byte) bool {
+	n := min(len(got), len(want))
+	return bytes.Equal(got[:n], want[:n])
+}
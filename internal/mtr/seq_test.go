@@ -0,0 +1,95 @@
+package mtr
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+func TestSeqAllocatorMultiDay(t *testing.T) {
+	// 3 天、每秒一轮、每轮 30 跳：远超 16 位 seq 空间
+	const probes = 3 * 24 * 3600 * 30
+	var a seqAllocator
+	prev := 0
+	for i := 0; i < probes; i++ {
+		seq := a.next()
+		if seq <= prev {
+			t.Fatalf("sequence not monotonic: %d after %d", seq, prev)
+		}
+		prev = seq
+	}
+	if prev != probes {
+		t.Fatalf("last seq = %d, want %d", prev, probes)
+	}
+	if w := wireSeq(prev); w < 0 || w > 0xffff {
+		t.Fatalf("wire seq out of range: %d", w)
+	}
+}
+
+// echoReply 经过一次编解码，模拟内核收到的回包（16 位 seq 字段已回绕）。
+func echoReply(t *testing.T, id, seq int, data []byte) *icmp.Message {
+	t.Helper()
+	b, err := (&icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: id, Seq: wireSeq(seq), Data: data}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm, err := icmp.ParseMessage(1, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+func TestICMPMatchAfterWraparound(t *testing.T) {
+	p := &ICMPProber{ipVersion: 4, id: 7, nonce: 0xdeadbeef}
+	seq := 3*0x10000 + 42 // 第 4 次回绕后的序号
+	payload := p.payload(seq)
+
+	if typ := p.classifyReply(1, echoReply(t, 7, seq, payload), seq, payload); typ != ResponseTypeEchoReply {
+		t.Fatalf("expected reply for wrapped seq to match, got=%v", typ)
+	}
+
+	// 同一 16 位 seq 的过期回包（来自 0x10000 个探测之前）
+	stale := 2*0x10000 + 42
+	if typ := p.classifyReply(1, echoReply(t, 7, stale, p.payload(stale)), seq, payload); typ != ResponseTypeTimeout {
+		t.Fatalf("expected stale reply with the same wire seq to be ignored, got=%v", typ)
+	}
+
+	// 另一个 prober（不同 nonce）的探测撞上同一 ID/seq
+	other := &ICMPProber{ipVersion: 4, id: 7, nonce: 1}
+	if typ := p.classifyReply(1, echoReply(t, 7, seq, other.payload(seq)), seq, payload); typ != ResponseTypeTimeout {
+		t.Fatalf("expected reply carrying another nonce to be ignored, got=%v", typ)
+	}
+}
+
+func TestICMPQuotedMatchAfterWraparound(t *testing.T) {
+	p := &ICMPProber{ipVersion: 4, id: 7, nonce: 42}
+	seq := 0x10000 + 5
+	payload := p.payload(seq)
+
+	quoted := func(s int, data []byte) *icmp.Message {
+		inner, err := (&icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 7, Seq: wireSeq(s), Data: data}}).Marshal(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := &ipv4.Header{Version: 4, Len: ipv4.HeaderLen, TotalLen: ipv4.HeaderLen + len(inner), TTL: 1, Protocol: 1, Src: net.ParseIP("192.0.2.10"), Dst: net.ParseIP("198.51.100.7")}
+		hb, err := h.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: append(hb, inner...)}}
+	}
+
+	if typ := p.classifyReply(1, quoted(seq, payload), seq, payload); typ != ResponseTypeTimeExceeded {
+		t.Fatalf("expected quoted probe to match, got=%v", typ)
+	}
+	// 只引用了 8 字节 ICMP 头部：只能按 16 位 seq 匹配
+	if typ := p.classifyReply(1, quoted(seq, nil), seq, payload); typ != ResponseTypeTimeExceeded {
+		t.Fatalf("expected header-only quote to match, got=%v", typ)
+	}
+	if typ := p.classifyReply(1, quoted(5, p.payload(5)), seq, payload); typ != ResponseTypeTimeout {
+		t.Fatalf("expected quote of a stale probe to be ignored, got=%v", typ)
+	}
+}
@@ -0,0 +1,57 @@
+package mtr
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// CurrentSchemaVersion 为当前 JSON 快照的 schema 版本；JSON 结构有任何变化（包括新增字段）时递增并在此记录：
+//
+//	1：首个带 schema_version 的版本
+//	2：新增 jitter_*、segment_ms/segment、probe_errors、destination、markers、anycast、dns_check、policy_violations、policies_violated、tcp_rtt 等字段
+const CurrentSchemaVersion = 2
+
+// snapshotMigration 将文档从版本 N 原地迁移到 N+1。
+type snapshotMigration func(doc map[string]json.RawMessage) error
+
+// snapshotMigrations 仅在字段语义或结构不兼容时补充；新增字段的版本无需迁移，旧文档中缺失的字段解码为零值。
+var snapshotMigrations = map[int]snapshotMigration{}
+
+// LoadSnapshot 读取 JSON 快照。旧版本按需迁移；高于当前版本的文档尽力解码（忽略未知字段）。
+// 返回的 SchemaVersion 保留文档中的原始版本，缺失时为 0（引入版本号之前的输出）。
+func LoadSnapshot(r io.Reader) (*Snapshot, error) {
+	var doc map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.New(i18n.Tf("err.snapshotDecode", map[string]interface{}{"Error": err.Error()}))
+	}
+
+	version := 0
+	if raw, ok := doc["schema_version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, errors.New(i18n.Tf("err.snapshotDecode", map[string]interface{}{"Error": err.Error()}))
+		}
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		migrate, ok := snapshotMigrations[v]
+		if !ok {
+			continue
+		}
+		if err := migrate(doc); err != nil {
+			return nil, errors.New(i18n.Tf("err.snapshotDecode", map[string]interface{}{"Error": err.Error()}))
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, errors.New(i18n.Tf("err.snapshotDecode", map[string]interface{}{"Error": err.Error()}))
+	}
+	s.SchemaVersion = version
+	return &s, nil
+}
@@ -1,7 +1,10 @@
 package mtr
 
 import (
+	"bytes"
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -56,4 +59,126 @@ func TestSnapshot_JSONSchema(t *testing.T) {
 	if _, ok := stats["history_ms"]; !ok {
 		t.Fatalf("expected history_ms in stats")
 	}
+	// 未计算分段时延时不输出 segment_ms
+	if _, ok := stats["segment_ms"]; ok {
+		t.Fatalf("expected segment_ms omitted when zero")
+	}
+}
+
+func TestComputeCompleteness(t *testing.T) {
+	hops := []SnapshotHop{
+		{TTL: 1, IP: "10.0.0.1", Stats: SnapshotHopSta{Received: 3}},
+		{TTL: 2, Stats: SnapshotHopSta{Received: 0}},
+		{TTL: 3, IP: "10.0.0.3", Stats: SnapshotHopSta{Received: 1}},
+		{TTL: 4, IP: "8.8.8.8", Stats: SnapshotHopSta{Received: 2}},
+	}
+
+	pc := computeCompleteness(hops, "8.8.8.8")
+	if !pc.DestinationReached || pc.Responded != 3 || pc.Total != 4 || pc.Percent != 75 {
+		t.Fatalf("unexpected completeness: %#v", pc)
+	}
+
+	pc = computeCompleteness(hops[:2], "8.8.8.8")
+	if pc.DestinationReached || pc.Responded != 1 || pc.Total != 2 {
+		t.Fatalf("unexpected completeness without destination: %#v", pc)
+	}
+}
+
+func TestApplySegmentLatency(t *testing.T) {
+	mk := func(ttl int, avg time.Duration) *Hop {
+		h := NewHop(ttl)
+		if avg > 0 {
+			h.Stats.Sent, h.Stats.Received = 1, 1
+			h.Stats.AddRTT(avg)
+		}
+		return h
+	}
+	hops := []*Hop{
+		mk(1, 2*time.Millisecond),
+		mk(2, 0),
+		mk(3, 30*time.Millisecond),
+		mk(4, 12*time.Millisecond), // ICMP 降级导致的回落
+		mk(5, 45*time.Millisecond),
+	}
+	out := make([]SnapshotHop, len(hops))
+	for i, h := range hops {
+		out[i] = h.ToSnapshot()
+	}
+
+	applySegmentLatency(out, DurationFormat{})
+	got := []string{}
+	for _, h := range out {
+		got = append(got, h.Stats.Segment)
+	}
+	want := []string{"2ms", "", "28ms", "0ms", "15ms"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected segments: got=%v want=%v", got, want)
+	}
+}
+
+func TestSnapshotApplyHop(t *testing.T) {
+	s := &Snapshot{
+		TargetIP: "10.0.0.9",
+		Hops: []SnapshotHop{
+			{TTL: 1, IP: "10.0.0.1", Stats: SnapshotHopSta{Received: 1}},
+			{TTL: 3, IP: "10.0.0.1", Stats: SnapshotHopSta{Received: 1}},
+		},
+		Loops: []RoutingLoop{{IP: "10.0.0.1", TTLs: []int{1, 3}}},
+	}
+	s.refreshPath()
+	if !s.Hops[0].Loop || !s.Hops[1].Loop {
+		t.Fatalf("expected loop hops marked before update, got=%#v", s.Hops)
+	}
+
+	s.ApplyHop(SnapshotHop{TTL: 2, IP: "10.0.0.2", Stats: SnapshotHopSta{Received: 1}})
+	s.ApplyHop(SnapshotHop{TTL: 3, IP: "10.0.0.9", Stats: SnapshotHopSta{Received: 2}})
+
+	if got := []int{s.Hops[0].TTL, s.Hops[1].TTL, s.Hops[2].TTL}; !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("unexpected hop order: %v", got)
+	}
+	// 环路记录保留运行期间的历史，只有 IP 已变化的 hop 不再标记
+	if !s.Hops[0].Loop || s.Hops[2].Loop {
+		t.Fatalf("unexpected loop marks after update: %#v", s.Hops)
+	}
+	if !s.Completeness.DestinationReached || s.Completeness.Responded != 3 {
+		t.Fatalf("unexpected completeness: %#v", s.Completeness)
+	}
+}
+
+func TestLoadSnapshot(t *testing.T) {
+	h := NewHop(1)
+	h.IP = []byte{8, 8, 8, 8}
+	h.Stats.Sent = 1
+	h.Stats.Received = 1
+	h.Stats.AddRTT(10 * time.Millisecond)
+
+	want := &Snapshot{SchemaVersion: CurrentSchemaVersion, Target: "example.com", Hops: []SnapshotHop{h.ToSnapshot()}}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	got, err := LoadSnapshot(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Target != "example.com" || len(got.Hops) != 1 || got.Hops[0].Stats.LastMs != 10 {
+		t.Fatalf("unexpected snapshot: %#v", got)
+	}
+
+	legacy, err := LoadSnapshot(strings.NewReader(`{"target":"old.example","hops":[{"ttl":1,"lost":true,"stats":{}}]}`))
+	if err != nil {
+		t.Fatalf("load legacy: %v", err)
+	}
+	if legacy.SchemaVersion != 0 || legacy.Target != "old.example" {
+		t.Fatalf("unexpected legacy snapshot: %#v", legacy)
+	}
+
+	// 更高版本的文档尽力解码，未知字段被忽略
+	future, err := LoadSnapshot(strings.NewReader(`{"schema_version":99,"target":"new.example","new_field":{"x":1}}`))
+	if err != nil {
+		t.Fatalf("load future: %v", err)
+	}
+	if future.SchemaVersion != 99 || future.Target != "new.example" {
+		t.Fatalf("unexpected future snapshot: %#v", future)
+	}
 }
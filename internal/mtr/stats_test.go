@@ -43,3 +43,40 @@ func TestHopStats_StdDev(t *testing.T) {
 		t.Fatalf("stddev too far: got=%v want≈%v", s.StdDev, time.Duration(want))
 	}
 }
+
+func TestDurationFormat(t *testing.T) {
+	d := 1234567 * time.Nanosecond
+	cases := []struct {
+		f    DurationFormat
+		in   time.Duration
+		want string
+	}{
+		{DurationFormat{}, d, "1ms"},
+		{DurationFormat{Precision: 2}, d, "1.23ms"},
+		{DurationFormat{Unit: DurationUnitUs}, d, "1235µs"},
+		{DurationFormat{Unit: DurationUnitAuto, Precision: 1}, 420 * time.Microsecond, "420.0µs"},
+		{DurationFormat{Unit: DurationUnitAuto, Precision: 1}, d, "1.2ms"},
+		{DurationFormat{}, 0, ""},
+	}
+	for _, tc := range cases {
+		if got := tc.f.Format(tc.in); got != tc.want {
+			t.Fatalf("Format(%v) with %+v = %q, want %q", tc.in, tc.f, got, tc.want)
+		}
+	}
+	if _, err := ParseDurationUnit("ns"); err == nil {
+		t.Fatal("expected invalid unit error")
+	}
+}
+
+func TestHopStats_Jitter(t *testing.T) {
+	s := NewHopStats()
+	s.AddRTT(10 * time.Millisecond)
+	if s.Jitter != 0 || s.JitterAvg != 0 {
+		t.Fatalf("expected no jitter after one sample, got=%v/%v", s.Jitter, s.JitterAvg)
+	}
+	s.AddRTT(16 * time.Millisecond)
+	s.AddRTT(14 * time.Millisecond)
+	if s.Jitter != 2*time.Millisecond || s.JitterMax != 6*time.Millisecond || s.JitterAvg != 4*time.Millisecond {
+		t.Fatalf("unexpected jitter: last=%v max=%v avg=%v", s.Jitter, s.JitterMax, s.JitterAvg)
+	}
+}
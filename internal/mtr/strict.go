@@ -0,0 +1,48 @@
+package mtr
+
+import (
+	"bytes"
+	"net"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// quotedPacket 返回 ICMP 错误报文中引用的原始 IP 报文。
+func quotedPacket(body icmp.MessageBody) []byte {
+	switch b := body.(type) {
+	case *icmp.TimeExceeded:
+		return b.Data
+	case *icmp.DstUnreach:
+		return b.Data
+	}
+	return nil
+}
+
+// strictQuoteMatches 用于 --strict-match：在 ID/Seq/端口匹配之外，进一步要求引用报文的 IP 目的地址为探测目标，
+// 且引用中超出 8 字节传输层头部的负载（路由器引用了多少就比对多少）与发出的负载一致。
+// 繁忙主机上其他程序的探测流量可能恰好撞上 ID/Seq 或端口，这一步用于排除此类误归属。
+func strictQuoteMatches(data []byte, ipVersion int, target net.IP, payload []byte) bool {
+	var dst net.IP
+	var transport []byte
+	if ipVersion == 4 {
+		h, err := ipv4.ParseHeader(data)
+		if err != nil || h.Len <= 0 || len(data) < h.Len+8 {
+			return false
+		}
+		dst, transport = h.Dst, data[h.Len:]
+	} else {
+		h, err := ipv6.ParseHeader(data)
+		if err != nil || len(data) < ipv6.HeaderLen+8 {
+			return false
+		}
+		dst, transport = h.Dst, data[ipv6.HeaderLen:]
+	}
+	if target == nil || !dst.Equal(target) {
+		return false
+	}
+	quoted := transport[8:]
+	n := min(len(quoted), len(payload))
+	return bytes.Equal(quoted[:n], payload[:n])
+}
@@ -0,0 +1,64 @@
+package mtr
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// quotedIPv4 构造 ICMP 错误中引用的原始报文：IPv4 头 + 8 字节传输层头部 + 负载。
+func quotedIPv4(t *testing.T, dst net.IP, payload []byte) []byte {
+	t.Helper()
+	h := &ipv4.Header{Version: 4, Len: ipv4.HeaderLen, TotalLen: ipv4.HeaderLen + 8 + len(payload), TTL: 1, Protocol: 17, Src: net.ParseIP("192.0.2.10"), Dst: dst}
+	b, err := h.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = append(b, make([]byte, 8)...)
+	return append(b, payload...)
+}
+
+func TestStrictQuoteMatches(t *testing.T) {
+	target := net.ParseIP("198.51.100.7")
+	payload := []byte("mymt\x00\x00\x00\x2a")
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "full quote", data: quotedIPv4(t, target, payload), want: true},
+		{name: "header only quote", data: quotedIPv4(t, target, nil), want: true},
+		{name: "truncated payload", data: quotedIPv4(t, target, payload[:4]), want: true},
+		{name: "other target", data: quotedIPv4(t, net.ParseIP("198.51.100.8"), payload), want: false},
+		{name: "other payload", data: quotedIPv4(t, target, []byte("mymt\x00\x00\x00\x2b")), want: false},
+		{name: "garbage", data: []byte{1, 2, 3}, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strictQuoteMatches(tc.data, 4, target, payload); got != tc.want {
+				t.Fatalf("expected %v, got=%v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestICMPStrictMatchesEchoReply(t *testing.T) {
+	target := net.ParseIP("198.51.100.7")
+	p := &ICMPProber{ipVersion: 4, target: target, id: 7, strict: true}
+	payload := []byte("mymtr")
+	reply := func(data string) *icmp.Message {
+		return &icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 7, Seq: 1, Data: []byte(data)}}
+	}
+
+	if !p.strictMatches(ResponseTypeEchoReply, reply("mymtr"), &net.IPAddr{IP: target}, payload) {
+		t.Fatal("expected genuine echo reply to match")
+	}
+	if p.strictMatches(ResponseTypeEchoReply, reply("other"), &net.IPAddr{IP: target}, payload) {
+		t.Fatal("expected payload mismatch to be suspicious")
+	}
+	if p.strictMatches(ResponseTypeEchoReply, reply("mymtr"), &net.IPAddr{IP: net.ParseIP("203.0.113.1")}, payload) {
+		t.Fatal("expected reply from another host to be suspicious")
+	}
+}
@@ -0,0 +1,59 @@
+package mtr
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+)
+
+// SubnetSamples 从前缀中挑选 n 个有代表性的主机地址：首个与最后一个可用地址，
+// 其余在两者之间均匀分布。IPv4 的 /30 及更短前缀会跳过网络地址与广播地址。
+func SubnetSamples(cidr string, n int) ([]netip.Addr, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, errors.New(i18n.Tf("err.subnetInvalid", map[string]interface{}{"Subnet": cidr}))
+	}
+	if n < 1 {
+		return nil, errors.New(i18n.Tf("err.subnetSamplesInvalid", map[string]interface{}{"Samples": n}))
+	}
+	prefix = prefix.Masked()
+
+	bits := prefix.Addr().BitLen()
+	first := new(big.Int).SetBytes(prefix.Addr().AsSlice())
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix.Bits()))
+	last := new(big.Int).Sub(new(big.Int).Add(first, size), big.NewInt(1))
+	if prefix.Addr().Is4() && prefix.Bits() <= 30 {
+		first.Add(first, big.NewInt(1))
+		last.Sub(last, big.NewInt(1))
+	} else if prefix.Addr().Is6() && prefix.Bits() <= 126 {
+		// 跳过子网路由器任播地址（全零主机位）
+		first.Add(first, big.NewInt(1))
+	}
+
+	hosts := new(big.Int).Add(new(big.Int).Sub(last, first), big.NewInt(1))
+	if hosts.IsInt64() && hosts.Int64() < int64(n) {
+		n = int(hosts.Int64())
+	}
+
+	span := new(big.Int).Sub(last, first)
+	out := make([]netip.Addr, 0, n)
+	for i := 0; i < n; i++ {
+		off := big.NewInt(0)
+		if n > 1 {
+			off.Mul(span, big.NewInt(int64(i)))
+			off.Div(off, big.NewInt(int64(n-1)))
+		}
+		out = append(out, addrFromInt(new(big.Int).Add(first, off), bits))
+	}
+	return out, nil
+}
+
+func addrFromInt(v *big.Int, bits int) netip.Addr {
+	buf := make([]byte, bits/8)
+	v.FillBytes(buf)
+	addr, _ := netip.AddrFromSlice(buf)
+	return addr
+}
@@ -0,0 +1,38 @@
+package mtr
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubnetSamples(t *testing.T) {
+	for _, tc := range []struct {
+		cidr string
+		n    int
+		want string
+	}{
+		{"203.0.113.0/28", 4, "[203.0.113.1 203.0.113.5 203.0.113.9 203.0.113.14]"},
+		{"203.0.113.7/28", 1, "[203.0.113.1]"},
+		{"203.0.113.0/30", 8, "[203.0.113.1 203.0.113.2]"},
+		{"203.0.113.4/31", 4, "[203.0.113.4 203.0.113.5]"},
+		{"203.0.113.9/32", 3, "[203.0.113.9]"},
+		{"2001:db8::/64", 3, "[2001:db8::1 2001:db8:0:0:8000:: 2001:db8::ffff:ffff:ffff:ffff]"},
+	} {
+		got, err := SubnetSamples(tc.cidr, tc.n)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.cidr, err)
+		}
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Fatalf("%s n=%d: expected %s, got %s", tc.cidr, tc.n, tc.want, s)
+		}
+	}
+
+	for _, bad := range []string{"203.0.113.0", "not-a-prefix"} {
+		if _, err := SubnetSamples(bad, 4); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if _, err := SubnetSamples("203.0.113.0/28", 0); err == nil {
+		t.Fatal("expected error for zero samples")
+	}
+}
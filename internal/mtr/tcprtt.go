@@ -0,0 +1,117 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// ICMP 时延明显高于 TCP 握手时延的判定阈值：比例与绝对差需同时满足。
+const (
+	deprioritizedRatio  = 1.5
+	deprioritizedMinGap = 5 * time.Millisecond
+)
+
+// TCPRTT 为通过 TCP 握手（SYN 到 SYN/ACK 或 RST）测得的目标时延，独立于 ICMP 探测，
+// 用于发现目标对 ICMP 降低优先级（ICMP 时延虚高）的情况。
+type TCPRTT struct {
+	Port     int     `json:"port"`
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+	Loss     float64 `json:"loss"`
+	AvgMs    float64 `json:"avg_ms,omitempty"`
+	BestMs   float64 `json:"best_ms,omitempty"`
+	WorstMs  float64 `json:"worst_ms,omitempty"`
+	// ICMPAvgMs 为同一目标在 ICMP/UDP 探测中的平均时延（目标未到达时为 0）。
+	ICMPAvgMs float64 `json:"icmp_avg_ms,omitempty"`
+	// Deprioritized 表示 ICMP 时延明显高于 TCP 握手时延，目标很可能对 ICMP 做了限速或低优先级处理。
+	Deprioritized bool `json:"icmp_deprioritized,omitempty"`
+}
+
+// tcpRTTStats 累计每轮一次的握手时延。
+type tcpRTTStats struct {
+	sent, received   int
+	sum, best, worst time.Duration
+}
+
+func (s *tcpRTTStats) add(rtt time.Duration, ok bool) {
+	s.sent++
+	if !ok {
+		return
+	}
+	s.received++
+	s.sum += rtt
+	if s.best == 0 || rtt < s.best {
+		s.best = rtt
+	}
+	if rtt > s.worst {
+		s.worst = rtt
+	}
+}
+
+// snapshot 生成对外结果；icmpAvg 为目标 hop 的 ICMP 平均时延（未知时为 0）。
+func (s *tcpRTTStats) snapshot(port int, icmpAvg time.Duration) *TCPRTT {
+	out := &TCPRTT{Port: port, Sent: s.sent, Received: s.received}
+	if s.sent > 0 {
+		out.Loss = (1 - float64(s.received)/float64(s.sent)) * 100
+	}
+	if s.received == 0 {
+		return out
+	}
+	avg := s.sum / time.Duration(s.received)
+	out.AvgMs, out.BestMs, out.WorstMs = msFloat(avg), msFloat(s.best), msFloat(s.worst)
+	if icmpAvg > 0 {
+		out.ICMPAvgMs = msFloat(icmpAvg)
+		out.Deprioritized = icmpAvg-avg >= deprioritizedMinGap && float64(icmpAvg) >= float64(avg)*deprioritizedRatio
+	}
+	return out
+}
+
+func msFloat(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// dialRTT 发起一次 TCP 握手并返回耗时；对端以 RST 拒绝连接同样是一次有效的往返。
+func dialRTT(ctx context.Context, ip net.IP, port int, timeout time.Duration) (time.Duration, bool) {
+	d := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+	rtt := time.Since(start)
+	if err == nil {
+		conn.Close()
+		return rtt, true
+	}
+	return rtt, errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// measureTCPRTT 在一轮结束后对目标做一次握手测量（仅 Config.TCPRTTPort 大于 0 时）。
+func (c *Controller) measureTCPRTT(ctx context.Context, target net.IP) {
+	port := c.config.TCPRTTPort
+	if port <= 0 {
+		return
+	}
+	rtt, ok := dialRTT(ctx, target, port, c.config.Timeout)
+	if ctx.Err() != nil {
+		return
+	}
+	c.mu.Lock()
+	c.tcpRTT.add(rtt, ok)
+	c.mu.Unlock()
+}
+
+// tcpRTTSnapshot 返回握手时延统计，调用方需持有 c.mu。
+func (c *Controller) tcpRTTSnapshot() *TCPRTT {
+	if c.config.TCPRTTPort <= 0 {
+		return nil
+	}
+	var icmpAvg time.Duration
+	if c.dest.State == DestinationStateReached {
+		if hop := c.hops[c.dest.TTL]; hop != nil {
+			icmpAvg = hop.Stats.Avg
+		}
+	}
+	return c.tcpRTT.snapshot(c.config.TCPRTTPort, icmpAvg)
+}
@@ -0,0 +1,72 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDialRTT(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	ip := net.ParseIP("127.0.0.1")
+	if _, ok := dialRTT(context.Background(), ip, port, time.Second); !ok {
+		t.Fatalf("expected handshake to open port to count")
+	}
+	ln.Close()
+	// 端口关闭后对端回 RST，同样是一次有效往返
+	if _, ok := dialRTT(context.Background(), ip, port, time.Second); !ok {
+		t.Fatalf("expected refused connection to count as a sample")
+	}
+}
+
+func TestTCPRTTStatsSnapshot(t *testing.T) {
+	var s tcpRTTStats
+	s.add(10*time.Millisecond, true)
+	s.add(0, false)
+	s.add(20*time.Millisecond, true)
+
+	got := s.snapshot(443, 40*time.Millisecond)
+	if got.Sent != 3 || got.Received != 2 || got.AvgMs != 15 || got.BestMs != 10 || got.WorstMs != 20 {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+	if got.Loss < 33 || got.Loss > 34 {
+		t.Fatalf("loss = %.2f", got.Loss)
+	}
+	if !got.Deprioritized || got.ICMPAvgMs != 40 {
+		t.Fatalf("expected ICMP 40ms vs TCP 15ms to be flagged: %+v", got)
+	}
+
+	// 差距不足 5ms 时不判定
+	if s.snapshot(443, 19*time.Millisecond).Deprioritized {
+		t.Fatalf("small gap should not be flagged")
+	}
+	// ICMP 未到达目标时无法对照
+	if got := s.snapshot(443, 0); got.Deprioritized || got.ICMPAvgMs != 0 {
+		t.Fatalf("unexpected comparison without ICMP: %+v", got)
+	}
+}
+
+func TestSnapshotFormatMs(t *testing.T) {
+	s := &Snapshot{durationFormat: DurationFormat{Unit: DurationUnitMs, Precision: 2}}
+	if got := s.FormatMs(12.345); got != "12.35ms" {
+		t.Fatalf("unexpected formatted value: %q", got)
+	}
+	if got := s.FormatMs(0); got != "" {
+		t.Fatalf("expected empty string for missing value, got=%q", got)
+	}
+}
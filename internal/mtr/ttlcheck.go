@@ -0,0 +1,57 @@
+package mtr
+
+import (
+	"net"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// setProbeTTL 设置探测包的 TTL/HopLimit（p4、p6 二选一），ttl<=0 时按 1 处理。
+func setProbeTTL(p4 *ipv4.PacketConn, p6 *ipv6.PacketConn, ttl int) error {
+	if ttl <= 0 {
+		ttl = 1
+	}
+	if p4 != nil {
+		return p4.SetTTL(ttl)
+	}
+	return p6.SetHopLimit(ttl)
+}
+
+// isOnLink 判断 ip 是否为本机地址或位于本机某个接口的直连网段内。
+var isOnLink = func(ip net.IP) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		// 无法判断时按直连处理，避免误报
+		return true
+	}
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTTLRewrite 在 TTL=1 的探测就收到目标本身的应答、而目标并不在直连网段时返回一次告警：
+// 这通常说明 TTL 被 VPN/隧道或中间设备改写，整条路径会被压缩成单跳。
+// 这只是启发式判断，部分改写或按固定偏移递减的情况无法发现（见 TODO.md）。
+func (c *Controller) checkTTLRewrite(ttl int, res *ProbeResult) error {
+	if ttl != 1 || res == nil || res.IP == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttlWarned {
+		return nil
+	}
+	target := net.ParseIP(c.config.TargetIP)
+	if target == nil || !res.IP.Equal(target) || isOnLink(target) {
+		return nil
+	}
+	c.ttlWarned = true
+	return &TTLRewrittenError{IP: c.config.TargetIP}
+}
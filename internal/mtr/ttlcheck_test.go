@@ -0,0 +1,65 @@
+package mtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+func TestSetProbeTTL(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skip(err)
+	}
+	defer conn.Close()
+	pc := ipv4.NewPacketConn(conn)
+	if err := setProbeTTL(pc, nil, 7); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := pc.TTL(); err == nil && got != 7 {
+		t.Fatalf("expected ttl 7, got=%d", got)
+	}
+}
+
+func TestControllerWarnsOnTTLRewrite(t *testing.T) {
+	orig := isOnLink
+	defer func() { isOnLink = orig }()
+
+	for _, tc := range []struct {
+		name   string
+		onLink bool
+		want   int
+	}{
+		{name: "remote", want: 1},
+		{name: "on-link", onLink: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			isOnLink = func(net.IP) bool { return tc.onLink }
+			prober := &scriptedProber{replies: map[int]*ProbeResult{
+				1: {IP: net.ParseIP("198.51.100.7"), Type: ResponseTypeEchoReply, Kind: "echo_reply"},
+			}}
+			cfg := &Config{Target: "198.51.100.7", MaxHops: 5, Count: 2, Interval: time.Millisecond, Protocol: ProtocolICMP, IPVersion: 4, Offline: true}
+			c, err := NewController(cfg, prober, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			warnings := 0
+			for ev := range c.Events() {
+				var tr *TTLRewrittenError
+				if ev.Type == EventTypeWarning && errors.As(ev.Err, &tr) {
+					warnings++
+				}
+			}
+			if warnings != tc.want {
+				t.Fatalf("expected %d warnings, got=%d", tc.want, warnings)
+			}
+		})
+	}
+}
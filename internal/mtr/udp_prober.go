@@ -3,8 +3,6 @@ package mtr
 import (
 	"context"
 	"encoding/binary"
-	"errors"
-	"fmt"
 	"net"
 	"time"
 
@@ -21,24 +19,25 @@ type UDPProber struct {
 	icmpConn  *icmp.PacketConn
 	basePort  int
 	localAddr net.IP
+	// flowPort 为 ProbeFlow 固定使用的源端口，首次探测时由系统分配后保持不变。
+	flowPort int
+	// strict 为 true 时启用 --strict-match 校验。
+	strict bool
+	dscp   int
+
+	errs probeErrorCounter
 }
 
-func NewUDPProber(ipVersion int, timeout time.Duration) (*UDPProber, error) {
+func NewUDPProber(opts ProberOptions) (*UDPProber, error) {
+	ipVersion, timeout := opts.IPVersion, opts.Timeout
 	if timeout <= 0 {
 		timeout = time.Second
 	}
 
-	network := "ip4:icmp"
-	addr := "0.0.0.0"
-	if ipVersion == 6 {
-		network = "ip6:ipv6-icmp"
-		addr = "::"
-	}
-
-	conn, err := icmp.ListenPacket(network, addr)
+	conn, err := listenICMP(ipVersion, opts.Source)
 	if err != nil {
 		if looksLikePermission(err) {
-			return nil, fmt.Errorf("创建原始套接字失败（需要更高权限运行）：%w", err)
+			return nil, &PermissionError{Err: err}
 		}
 		return nil, err
 	}
@@ -48,17 +47,22 @@ func NewUDPProber(ipVersion int, timeout time.Duration) (*UDPProber, error) {
 		timeout:   timeout,
 		icmpConn:  conn,
 		basePort:  33434,
+		localAddr: opts.Source,
+		strict:    opts.StrictMatch,
+		dscp:      opts.DSCP,
 	}, nil
 }
 
 func (p *UDPProber) SetTarget(ip net.IP) error {
 	if ip == nil {
-		return errors.New("target ip 不能为空")
+		return ErrTargetNil
 	}
 	p.target = ip
 	return nil
 }
 
+func (p *UDPProber) ProbeErrors() ProbeErrorCounts { return p.errs.ProbeErrors() }
+
 func (p *UDPProber) Close() error {
 	if p.icmpConn == nil {
 		return nil
@@ -66,32 +70,65 @@ func (p *UDPProber) Close() error {
 	return p.icmpConn.Close()
 }
 
+// udpFlow 描述一次 UDP 探测使用的端口与负载长度。
+type udpFlow struct {
+	destPort  int
+	localPort int // 0 表示由系统分配
+	// padding 为额外的负载字节数。固定五元组时用它区分不同 TTL 的回包
+	// （UDP 长度字段位于被引用的 8 字节头部内，不参与 ECMP 哈希）。
+	padding int
+	// pinSource 为 true 时记录系统分配的源端口，供后续同类探测复用。
+	pinSource bool
+}
+
 func (p *UDPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult, error) {
+	return p.probe(ctx, ttl, seq, udpFlow{destPort: p.basePort + (seq % 10000)})
+}
+
+// ProbeFlow 以固定五元组发送探测：目的端口由 flow 决定，源端口在 prober 生命周期内不变，
+// 因此同一 flow 的所有 TTL 都会被 ECMP 哈希到同一条路径上（Paris traceroute 思路）。
+func (p *UDPProber) ProbeFlow(ctx context.Context, ttl, seq, flow int) (*ProbeResult, error) {
+	return p.probe(ctx, ttl, seq, udpFlow{
+		destPort:  p.basePort + flow,
+		localPort: p.flowPort,
+		padding:   ttl,
+		pinSource: true,
+	})
+}
+
+func (p *UDPProber) probe(ctx context.Context, ttl, seq int, flow udpFlow) (*ProbeResult, error) {
 	if p.target == nil {
-		return nil, errors.New("尚未设置 target ip")
+		return nil, ErrTargetNotSet
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	destPort := p.basePort + (seq % 10000)
-	udpConn, localPort, err := p.dialUDP(destPort)
+	destPort := flow.destPort
+	udpConn, localPort, err := p.dialUDP(destPort, flow.localPort)
 	if err != nil {
-		return nil, err
+		// UDP connect 在本机无路由时即失败，同样按发送失败处理
+		return p.errs.sendFailed(ttl, seq, err)
 	}
 	defer udpConn.Close()
+	if flow.pinSource && p.flowPort == 0 {
+		p.flowPort = localPort
+	}
 
 	if err := p.setUDPTTL(udpConn, ttl); err != nil {
 		return nil, err
 	}
+	if err := p.setDSCP(udpConn); err != nil {
+		return nil, err
+	}
 
-	payload := make([]byte, 8)
+	payload := make([]byte, 8+flow.padding)
 	copy(payload[:4], []byte("mymt"))
 	binary.BigEndian.PutUint32(payload[4:], uint32(seq))
 
 	start := time.Now()
 	if _, err := udpConn.Write(payload); err != nil {
-		return nil, err
+		return p.errs.sendFailed(ttl, seq, err)
 	}
 
 	deadline := start.Add(p.timeout)
@@ -114,38 +151,56 @@ func (p *UDPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 		proto = 58
 	}
 
+	suspicious := 0
 	buf := make([]byte, 1500)
 	for {
 		n, peer, err := p.icmpConn.ReadFrom(buf)
 		if err != nil {
 			if ctx.Err() != nil {
 				return &ProbeResult{
-					TTL:       ttl,
-					Seq:       seq,
-					Type:      ResponseTypeTimeout,
-					Timestamp: start,
+					TTL:        ttl,
+					Seq:        seq,
+					Type:       ResponseTypeTimeout,
+					Timestamp:  start,
+					Suspicious: suspicious,
 				}, nil
 			}
 			if isTimeout(err) {
 				return &ProbeResult{
-					TTL:       ttl,
-					Seq:       seq,
-					Type:      ResponseTypeTimeout,
-					Timestamp: start,
+					TTL:        ttl,
+					Seq:        seq,
+					Type:       ResponseTypeTimeout,
+					Timestamp:  start,
+					Suspicious: suspicious,
 				}, nil
 			}
-			return nil, err
+			if isFatalReadError(err) {
+				return nil, err
+			}
+			p.errs.read.Add(1)
+			return &ProbeResult{
+				TTL:        ttl,
+				Seq:        seq,
+				Type:       ResponseTypeTimeout,
+				Timestamp:  start,
+				Suspicious: suspicious,
+			}, nil
 		}
 
 		rm, err := icmp.ParseMessage(proto, buf[:n])
 		if err != nil {
+			p.errs.parse.Add(1)
 			continue
 		}
 
-		typ, ok := p.classifyUDPReply(rm, localPort, destPort)
+		typ, ok := p.classifyUDPReply(rm, localPort, destPort, 8+len(payload))
 		if !ok {
 			continue
 		}
+		if p.strict && !strictQuoteMatches(quotedPacket(rm.Body), p.ipVersion, p.target, payload) {
+			suspicious++
+			continue
+		}
 
 		return &ProbeResult{
 			TTL:       ttl,
@@ -154,49 +209,60 @@ func (p *UDPProber) Probe(ctx context.Context, ttl int, seq int) (*ProbeResult,
 			RTT:       time.Since(start),
 			Type:      typ,
 			Timestamp: start,
+			Kind:      responseKind(p.ipVersion, typ, rm),
+
+			Suspicious: suspicious,
 		}, nil
 	}
 }
 
-func (p *UDPProber) dialUDP(destPort int) (*net.UDPConn, int, error) {
+func (p *UDPProber) dialUDP(destPort, localPort int) (*net.UDPConn, int, error) {
 	network := "udp4"
 	if p.ipVersion == 6 {
 		network = "udp6"
 	}
 	raddr := &net.UDPAddr{IP: p.target, Port: destPort}
-	conn, err := net.DialUDP(network, nil, raddr)
+	var laddr *net.UDPAddr
+	if p.localAddr != nil || localPort != 0 {
+		laddr = &net.UDPAddr{IP: p.localAddr, Port: localPort}
+	}
+	conn, err := net.DialUDP(network, laddr, raddr)
 	if err != nil {
 		return nil, 0, err
 	}
-	localPort := 0
 	if la, ok := conn.LocalAddr().(*net.UDPAddr); ok {
 		localPort = la.Port
 	}
 	return conn, localPort, nil
 }
 
-func (p *UDPProber) setUDPTTL(conn *net.UDPConn, ttl int) error {
-	if ttl <= 0 {
-		ttl = 1
+func (p *UDPProber) setDSCP(conn *net.UDPConn) error {
+	if p.ipVersion == 4 {
+		return setTrafficClass(ipv4.NewPacketConn(conn), nil, p.dscp)
 	}
+	return setTrafficClass(nil, ipv6.NewPacketConn(conn), p.dscp)
+}
+
+func (p *UDPProber) setUDPTTL(conn *net.UDPConn, ttl int) error {
 	if p.ipVersion == 4 {
-		return ipv4.NewPacketConn(conn).SetTTL(ttl)
+		return setProbeTTL(ipv4.NewPacketConn(conn), nil, ttl)
 	}
-	return ipv6.NewPacketConn(conn).SetHopLimit(ttl)
+	return setProbeTTL(nil, ipv6.NewPacketConn(conn), ttl)
 }
 
-func (p *UDPProber) classifyUDPReply(rm *icmp.Message, localPort, destPort int) (ResponseType, bool) {
+// udpLen 为探测包的 UDP 长度字段，0 表示不校验。
+func (p *UDPProber) classifyUDPReply(rm *icmp.Message, localPort, destPort, udpLen int) (ResponseType, bool) {
 	if rm == nil {
 		return ResponseTypeTimeout, false
 	}
 
 	switch rm.Type {
 	case ipv4.ICMPTypeTimeExceeded, ipv6.ICMPTypeTimeExceeded:
-		if p.matchesQuotedUDP(rm.Body, localPort, destPort) {
+		if p.matchesQuotedUDP(rm.Body, localPort, destPort, udpLen) {
 			return ResponseTypeTimeExceeded, true
 		}
 	case ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable:
-		if !p.matchesQuotedUDP(rm.Body, localPort, destPort) {
+		if !p.matchesQuotedUDP(rm.Body, localPort, destPort, udpLen) {
 			return ResponseTypeTimeout, false
 		}
 
@@ -210,7 +276,7 @@ func (p *UDPProber) classifyUDPReply(rm *icmp.Message, localPort, destPort int)
 	return ResponseTypeTimeout, false
 }
 
-func (p *UDPProber) matchesQuotedUDP(body icmp.MessageBody, localPort, destPort int) bool {
+func (p *UDPProber) matchesQuotedUDP(body icmp.MessageBody, localPort, destPort, udpLen int) bool {
 	var data []byte
 	switch b := body.(type) {
 	case *icmp.TimeExceeded:
@@ -238,6 +304,9 @@ func (p *UDPProber) matchesQuotedUDP(body icmp.MessageBody, localPort, destPort
 	if localPort != 0 && src != localPort {
 		return false
 	}
+	if udpLen != 0 && int(binary.BigEndian.Uint16(udpHeader[4:6])) != udpLen {
+		return false
+	}
 	return true
 }
 
@@ -0,0 +1,100 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+)
+
+// warmUpConcurrency 为首轮结束后并行补全位置与主机名的最大并发数。
+const warmUpConcurrency = 8
+
+type warmUpJob struct {
+	ttl int
+	ip  net.IP
+	geo bool
+	dns bool
+}
+
+// warmUp 在首轮发现路径后并行补全所有跳点缺失的地理位置与主机名，
+// 让后续画面尽快完整，而不是随探测轮次逐跳补齐。在线数据源的限速由 resolver 自身保证。
+func (c *Controller) warmUp(ctx context.Context, round int) {
+	jobs := c.warmUpJobs()
+	if len(jobs) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, warmUpConcurrency)
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j warmUpJob) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if ctx.Err() != nil {
+				return
+			}
+			var loc *geoip.GeoLocation
+			var name string
+			if j.geo {
+				loc = c.resolver.Resolve(j.ip)
+			}
+			if j.dns {
+				name = reverseDNS(ctx, j.ip)
+			}
+			if c.applyEnrichment(j.ttl, j.ip, loc, name) {
+				c.emit(Event{Type: EventTypeHopUpdated, TTL: j.ttl, Round: round, Hop: c.hopSnapshot(j.ttl)})
+			}
+		}(j)
+	}
+	wg.Wait()
+}
+
+// warmUpJobs 收集已有响应但仍缺少位置或主机名的跳点。
+func (c *Controller) warmUpJobs() []warmUpJob {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var jobs []warmUpJob
+	for ttl, hop := range c.hops {
+		if hop.IP == nil {
+			continue
+		}
+		j := warmUpJob{
+			ttl: ttl,
+			ip:  hop.IP,
+			geo: c.resolver != nil && hop.Location == nil,
+			dns: c.config.reverseDNSEnabled() && hop.Hostname == "",
+		}
+		if j.geo || j.dns {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// applyEnrichment 将补全结果写回 hop；期间 hop 的 IP 已变化时丢弃结果。返回是否有更新。
+func (c *Controller) applyEnrichment(ttl int, ip net.IP, loc *geoip.GeoLocation, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hop := c.hops[ttl]
+	if hop == nil || !hop.IP.Equal(ip) {
+		return false
+	}
+	changed := false
+	if loc != nil && hop.Location == nil {
+		hop.Location = loc
+		changed = true
+	}
+	if name != "" && hop.Hostname == "" {
+		hop.Hostname = name
+		changed = true
+	}
+	return changed
+}
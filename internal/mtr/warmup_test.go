@@ -0,0 +1,69 @@
+package mtr
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+)
+
+// flakyResolver 对每个 IP 的第一次查询返回 nil（模拟在线接口限流丢弃），之后正常返回。
+type flakyResolver struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (r *flakyResolver) Resolve(ip net.IP) *geoip.GeoLocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.seen[ip.String()] {
+		r.seen[ip.String()] = true
+		return nil
+	}
+	return &geoip.GeoLocation{Country: "test", Raw: ip.String()}
+}
+
+func (r *flakyResolver) Source() string                 { return "test" }
+func (r *flakyResolver) Close() error                   { return nil }
+func (r *flakyResolver) Shutdown(context.Context) error { return nil }
+
+func TestControllerWarmUp(t *testing.T) {
+	cfg := &Config{Target: "192.0.2.9", MaxHops: 3, Count: 1, Protocol: ProtocolICMP, IPVersion: 4}
+	c, err := NewController(cfg, &scriptedProber{}, &flakyResolver{seen: make(map[string]bool)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips := []string{"10.0.0.1", "10.0.0.2", "192.0.2.9"}
+	for i, ip := range ips {
+		c.applyResult(context.Background(), i+1, &ProbeResult{IP: net.ParseIP(ip), Type: ResponseTypeTimeExceeded})
+	}
+	for _, hop := range c.Snapshot().Hops {
+		if hop.Location != nil {
+			t.Fatalf("expected first lookup to be dropped for TTL %d", hop.TTL)
+		}
+	}
+
+	c.warmUp(context.Background(), 0)
+
+	for _, hop := range c.Snapshot().Hops {
+		if hop.Location == nil || hop.Location.Raw != hop.IP {
+			t.Fatalf("expected TTL %d to be enriched, got %#v", hop.TTL, hop.Location)
+		}
+	}
+	updated := map[int]bool{}
+	for len(c.events) > 0 {
+		if e := <-c.events; e.Type == EventTypeHopUpdated && e.Hop != nil && e.Hop.Location != nil {
+			updated[e.TTL] = true
+		}
+	}
+	if len(updated) != len(ips) {
+		t.Fatalf("expected a HopUpdated event per enriched hop, got %v", updated)
+	}
+
+	// 已补全的跳点不会再次查询
+	if jobs := c.warmUpJobs(); len(jobs) != 0 {
+		t.Fatalf("expected no pending jobs, got %v", jobs)
+	}
+}
@@ -0,0 +1,100 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// 路径策略格式（表达式字段与 hop 规则相同，只对有响应的 hop 求值）：
+//
+//	require:<name>=<expr>  路径中至少一个 hop 满足表达式，如 require:via-ct=isp contains "电信"
+//	forbid:<name>=<expr>   路径中任何 hop 都不得满足表达式，如 forbid:stay-cn=country != "" && country != "中国"
+const (
+	policyRequire = "require"
+	policyForbid  = "forbid"
+)
+
+type policy struct {
+	kind    string
+	name    string
+	source  string
+	program *vm.Program
+}
+
+// Policies 实现 mtr.PathPolicy。
+type Policies struct {
+	policies []policy
+}
+
+var _ mtr.PathPolicy = (*Policies)(nil)
+
+// ParsePolicies 解析路径策略列表；specs 为空时返回 nil。
+func ParsePolicies(specs []string) (*Policies, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	ps := &Policies{}
+	for _, spec := range specs {
+		p, err := parsePolicy(spec)
+		if err != nil {
+			return nil, errors.New(i18n.Tf("err.policyInvalid", map[string]interface{}{"Policy": spec, "Error": err.Error()}))
+		}
+		ps.policies = append(ps.policies, p)
+	}
+	return ps, nil
+}
+
+func parsePolicy(spec string) (policy, error) {
+	head, src, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(src) == "" {
+		return policy{}, errors.New("expected <require|forbid>:<name>=<expr>")
+	}
+	kind, name, _ := strings.Cut(strings.TrimSpace(head), ":")
+	p := policy{kind: strings.ToLower(kind), name: strings.TrimSpace(name), source: strings.TrimSpace(src)}
+	if p.kind != policyRequire && p.kind != policyForbid {
+		return policy{}, fmt.Errorf("unknown policy kind %q", kind)
+	}
+	if p.name == "" {
+		return policy{}, fmt.Errorf("%s policy requires a name", p.kind)
+	}
+	program, err := expr.Compile(p.source, expr.Env(hopEnv(mtr.SnapshotHop{})), expr.AsBool())
+	if err != nil {
+		return policy{}, err
+	}
+	p.program = program
+	return p, nil
+}
+
+func (ps *Policies) Evaluate(s *mtr.Snapshot) []mtr.PolicyViolation {
+	var out []mtr.PolicyViolation
+	for _, p := range ps.policies {
+		var matched []mtr.SnapshotHop
+		for _, hop := range s.Hops {
+			if hop.IP == "" {
+				continue
+			}
+			res, err := expr.Run(p.program, hopEnv(hop))
+			if ok, _ := res.(bool); ok && err == nil {
+				matched = append(matched, hop)
+			}
+		}
+		switch {
+		case p.kind == policyRequire && len(matched) == 0:
+			out = append(out, mtr.PolicyViolation{Policy: p.name, Detail: i18n.Tf("policy.requireUnmet", map[string]interface{}{"Expr": p.source})})
+		case p.kind == policyForbid && len(matched) > 0:
+			hops := make([]string, 0, len(matched))
+			for _, h := range matched {
+				hops = append(hops, fmt.Sprintf("TTL %d %s", h.TTL, h.IP))
+			}
+			out = append(out, mtr.PolicyViolation{Policy: p.name, Detail: i18n.Tf("policy.forbidMatched", map[string]interface{}{"Expr": p.source, "Hops": strings.Join(hops, ", ")})})
+		}
+	}
+	return out
+}
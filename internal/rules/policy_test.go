@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestPolicies(t *testing.T) {
+	ps, err := ParsePolicies([]string{
+		`require:via-ct=isp contains "电信"`,
+		`forbid:stay-cn=country != "" && country != "中国"`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &mtr.Snapshot{Hops: []mtr.SnapshotHop{
+		{TTL: 1, IP: "10.0.0.1", Location: &geoip.GeoLocation{Country: "中国", ISP: "电信"}},
+		{TTL: 2},
+		{TTL: 3, IP: "198.51.100.1", Location: &geoip.GeoLocation{Country: "中国", ISP: "联通"}},
+	}}
+	if v := ps.Evaluate(s); len(v) != 0 {
+		t.Fatalf("expected no violations, got=%+v", v)
+	}
+
+	s.Hops[0].Location.ISP = "移动"
+	s.Hops = append(s.Hops, mtr.SnapshotHop{TTL: 4, IP: "203.0.113.9", Location: &geoip.GeoLocation{Country: "美国"}})
+	v := ps.Evaluate(s)
+	if len(v) != 2 || v[0].Policy != "via-ct" || v[1].Policy != "stay-cn" || !strings.Contains(v[1].Detail, "TTL 4 203.0.113.9") {
+		t.Fatalf("unexpected violations: %+v", v)
+	}
+}
+
+func TestParsePoliciesInvalid(t *testing.T) {
+	for _, spec := range []string{
+		`require=ttl > 1`,
+		`allow:x=ttl > 1`,
+		`forbid:x=ttl +`,
+	} {
+		if _, err := ParsePolicies([]string{spec}); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package rules 基于 expr 表达式实现 hop 自定义分类规则。
+//
+// 规则格式：
+//
+//	tag:<name>=<expr>    表达式为真时为 hop 打上标签 name
+//	alert:<name>=<expr>  表达式由假变真时发出告警
+//	hide=<expr>          表达式为真时在文本/TUI 中隐藏该 hop
+//
+// 表达式可用字段：ttl、ip、hostname、loss、sent、received、last、avg、best、worst、stddev
+// （时间单位为毫秒）以及 country、province、city、isp、geo。
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+type action string
+
+const (
+	actionTag   action = "tag"
+	actionAlert action = "alert"
+	actionHide  action = "hide"
+)
+
+type rule struct {
+	action  action
+	name    string
+	source  string
+	program *vm.Program
+}
+
+// Engine 实现 mtr.HopHook。
+type Engine struct {
+	rules []rule
+
+	mu     sync.Mutex
+	firing map[string]bool
+}
+
+var _ mtr.HopHook = (*Engine)(nil)
+
+// Parse 解析规则列表；specs 为空时返回 nil。
+func Parse(specs []string) (*Engine, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	e := &Engine{firing: make(map[string]bool)}
+	for _, spec := range specs {
+		r, err := parseRule(spec)
+		if err != nil {
+			return nil, errors.New(i18n.Tf("err.ruleInvalid", map[string]interface{}{"Rule": spec, "Error": err.Error()}))
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e, nil
+}
+
+// Clone 返回规则相同、告警状态独立的 Engine，供同时运行的多个 Controller 各自使用。
+func (e *Engine) Clone() *Engine {
+	return &Engine{rules: e.rules, firing: make(map[string]bool)}
+}
+
+func parseRule(spec string) (rule, error) {
+	head, src, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(src) == "" {
+		return rule{}, errors.New("expected <action>[:<name>]=<expr>")
+	}
+	kind, name, _ := strings.Cut(strings.TrimSpace(head), ":")
+	r := rule{action: action(strings.ToLower(kind)), name: strings.TrimSpace(name), source: strings.TrimSpace(src)}
+	switch r.action {
+	case actionTag, actionAlert:
+		if r.name == "" {
+			return rule{}, fmt.Errorf("%s rule requires a name", r.action)
+		}
+	case actionHide:
+	default:
+		return rule{}, fmt.Errorf("unknown action %q", kind)
+	}
+
+	program, err := expr.Compile(r.source, expr.Env(hopEnv(mtr.SnapshotHop{})), expr.AsBool())
+	if err != nil {
+		return rule{}, err
+	}
+	r.program = program
+	return r, nil
+}
+
+func (e *Engine) Evaluate(hop mtr.SnapshotHop) mtr.HookResult {
+	var res mtr.HookResult
+	env := hopEnv(hop)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range e.rules {
+		out, err := expr.Run(r.program, env)
+		matched, _ := out.(bool)
+		if err != nil {
+			matched = false
+		}
+		switch r.action {
+		case actionTag:
+			if matched {
+				res.Tags = append(res.Tags, r.name)
+			}
+		case actionHide:
+			res.Hidden = res.Hidden || matched
+		case actionAlert:
+			key := fmt.Sprintf("%d/%s", hop.TTL, r.name)
+			if matched && !e.firing[key] {
+				res.Alerts = append(res.Alerts, i18n.Tf("warn.ruleAlert", map[string]interface{}{"Name": r.name, "TTL": hop.TTL, "Expr": r.source}))
+			}
+			e.firing[key] = matched
+		}
+	}
+	return res
+}
+
+func hopEnv(hop mtr.SnapshotHop) map[string]interface{} {
+	env := map[string]interface{}{
+		"ttl":      hop.TTL,
+		"ip":       hop.IP,
+		"hostname": hop.Hostname,
+		"loss":     hop.Stats.Loss,
+		"sent":     hop.Stats.Sent,
+		"received": hop.Stats.Received,
+		"last":     float64(hop.Stats.LastMs),
+		"avg":      float64(hop.Stats.AvgMs),
+		"best":     float64(hop.Stats.BestMs),
+		"worst":    float64(hop.Stats.WorstMs),
+		"stddev":   float64(hop.Stats.StdDevMs),
+		"country":  "",
+		"province": "",
+		"city":     "",
+		"isp":      "",
+		"geo":      "",
+	}
+	if loc := hop.Location; loc != nil {
+		env["country"] = loc.Country
+		env["province"] = loc.Province
+		env["city"] = loc.City
+		env["isp"] = loc.ISP
+		env["geo"] = loc.String()
+	}
+	return env
+}
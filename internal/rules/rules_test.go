@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	e, err := Parse([]string{
+		"tag:slow=avg > 100",
+		"tag:cn=country == '中国'",
+		"alert:lossy=loss >= 50",
+		"hide=ip == ''",
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	hop := mtr.SnapshotHop{
+		TTL:      3,
+		IP:       "10.0.0.1",
+		Location: &geoip.GeoLocation{Country: "中国"},
+		Stats:    mtr.SnapshotHopSta{AvgMs: 150, Loss: 60},
+	}
+	res := e.Evaluate(hop)
+	if !reflect.DeepEqual(res.Tags, []string{"slow", "cn"}) {
+		t.Fatalf("unexpected tags: %v", res.Tags)
+	}
+	if len(res.Alerts) != 1 || res.Hidden {
+		t.Fatalf("unexpected result: %#v", res)
+	}
+
+	// 告警只在由假变真时触发一次
+	if res := e.Evaluate(hop); len(res.Alerts) != 0 {
+		t.Fatalf("expected alert to fire once, got=%v", res.Alerts)
+	}
+	// 克隆出的 Engine 有独立的告警状态
+	if res := e.Clone().Evaluate(hop); len(res.Alerts) != 1 {
+		t.Fatalf("expected clone to fire independently, got=%v", res.Alerts)
+	}
+
+	if res := e.Evaluate(mtr.SnapshotHop{TTL: 4}); !res.Hidden {
+		t.Fatalf("expected unanswered hop hidden")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"tag=loss > 1", "drop=loss > 1", "hide=", "hide=loss +"} {
+		if _, err := Parse([]string{spec}); err == nil {
+			t.Fatalf("expected %q to fail", spec)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+const (
+	// chartHeight 为延迟图的行数；每行用 8 级方块字符，纵向共 chartHeight*8 级。
+	chartHeight = 5
+	// chartLabelWidth 为左侧纵轴刻度的宽度（含分隔线）。
+	chartLabelWidth = 10
+	// chartDefaultWidth 为尚未收到窗口尺寸时的图宽。
+	chartDefaultWidth = 60
+	chartLossMark     = '×'
+)
+
+var chartBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// chartPoint 为某一轮中选中 hop 的结果。
+type chartPoint struct {
+	ms   float64
+	lost bool
+}
+
+// hopSeries 从逐轮结果中取出 ttl 的 RTT 序列；未探测该 TTL 的轮次（已提前到达目标、暗跳降频）不计入。
+func hopSeries(rounds []mtr.RoundSnapshot, ttl int) []chartPoint {
+	points := make([]chartPoint, 0, len(rounds))
+	for _, r := range rounds {
+		for _, h := range r.Hops {
+			if h.TTL != ttl {
+				continue
+			}
+			points = append(points, chartPoint{ms: h.RTTMs, lost: h.IP == ""})
+			break
+		}
+	}
+	return points
+}
+
+// renderChart 绘制选中 hop 最近若干轮的 RTT 柱状图，丢包的轮次在底部以 × 标出；width 为可用的终端宽度，
+// formatMs 按 --units/--precision 格式化时延（见 mtr.Snapshot.FormatMs）。
+func renderChart(ttl int, points []chartPoint, width int, formatMs func(float64) string) string {
+	if width <= 0 {
+		width = chartDefaultWidth
+	}
+	cols := width - chartLabelWidth
+	if cols < 10 {
+		cols = 10
+	}
+	if len(points) > cols {
+		points = points[len(points)-cols:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s TTL %d", i18n.T("tui.chart.title"), ttl)
+	var (
+		maxMs, minMs, sum float64
+		received, lost    int
+	)
+	for _, p := range points {
+		if p.lost {
+			lost++
+			continue
+		}
+		if received == 0 || p.ms < minMs {
+			minMs = p.ms
+		}
+		if p.ms > maxMs {
+			maxMs = p.ms
+		}
+		sum += p.ms
+		received++
+	}
+	if len(points) == 0 {
+		b.WriteString("  " + i18n.T("tui.chart.empty") + "\n")
+		return b.String()
+	}
+	b.WriteString("  " + i18n.Tf("tui.chart.rounds", map[string]interface{}{"Count": len(points)}))
+	if received > 0 {
+		b.WriteString("  " + i18n.Tf("tui.chart.stats", map[string]interface{}{
+			"Min": emptyAsDash(formatMs(minMs)), "Avg": emptyAsDash(formatMs(sum / float64(received))), "Max": emptyAsDash(formatMs(maxMs)),
+		}))
+	}
+	b.WriteString("  " + i18n.Tf("tui.chart.lost", map[string]interface{}{"Count": lost}) + "\n")
+
+	scale := maxMs
+	if scale < 1 {
+		scale = 1
+	}
+	levels := make([]int, len(points))
+	for i, p := range points {
+		if p.lost {
+			continue
+		}
+		levels[i] = int(p.ms/scale*chartHeight*8 + 0.5)
+		if levels[i] == 0 {
+			// 非零时延至少画出最低一级，与丢包（空白）区分
+			levels[i] = 1
+		}
+	}
+	for row := chartHeight - 1; row >= 0; row-- {
+		switch row {
+		case chartHeight - 1:
+			fmt.Fprintf(&b, "%9s┤", formatMs(scale))
+		case 0:
+			fmt.Fprintf(&b, "%7s0 ┤", "")
+		default:
+			fmt.Fprintf(&b, "%9s│", "")
+		}
+		for i, p := range points {
+			if p.lost {
+				if row == 0 {
+					b.WriteRune(chartLossMark)
+				} else {
+					b.WriteByte(' ')
+				}
+				continue
+			}
+			lv := levels[i] - row*8
+			if lv < 0 {
+				lv = 0
+			}
+			if lv > 8 {
+				lv = 8
+			}
+			b.WriteRune(chartBlocks[lv])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestHopSeries(t *testing.T) {
+	rounds := []mtr.RoundSnapshot{
+		{Round: 0, Hops: []mtr.RoundHop{{TTL: 1, IP: "192.0.2.1", RTTMs: 1}, {TTL: 2, IP: "192.0.2.2", RTTMs: 10}}},
+		{Round: 1, Hops: []mtr.RoundHop{{TTL: 1, IP: "192.0.2.1", RTTMs: 2}, {TTL: 2, Kind: "timeout"}}},
+		// 第 2 跳在本轮被跳过（如暗跳降频）
+		{Round: 2, Hops: []mtr.RoundHop{{TTL: 1, IP: "192.0.2.1", RTTMs: 3}}},
+		{Round: 3, Hops: []mtr.RoundHop{{TTL: 1, IP: "192.0.2.1", RTTMs: 1}, {TTL: 2, IP: "192.0.2.2", RTTMs: 20}}},
+	}
+	got := hopSeries(rounds, 2)
+	want := []chartPoint{{ms: 10}, {lost: true}, {ms: 20}}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected series: %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("point %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	formatMs := (&mtr.Snapshot{}).FormatMs
+	out := renderChart(2, []chartPoint{{ms: 10}, {lost: true}, {ms: 20}}, 40, formatMs)
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != chartHeight+1 {
+		t.Fatalf("expected header plus %d rows, got %d:\n%s", chartHeight, len(lines), out)
+	}
+	if !strings.Contains(lines[0], "max 20ms") || !strings.Contains(lines[0], "lost 1") {
+		t.Fatalf("unexpected header: %q", lines[0])
+	}
+	top, bottom := []rune(lines[1]), []rune(lines[len(lines)-1])
+	if top[len(top)-1] != '█' || top[len(top)-2] != ' ' {
+		t.Fatalf("expected the 20ms sample to reach the top row: %q", lines[1])
+	}
+	if bottom[len(bottom)-2] != chartLossMark {
+		t.Fatalf("expected loss marker on the bottom row: %q", lines[len(lines)-1])
+	}
+
+	if out := renderChart(3, nil, 40, formatMs); !strings.Contains(out, "TTL 3") || strings.Count(out, "\n") != 1 {
+		t.Fatalf("unexpected empty chart:\n%s", out)
+	}
+
+	// 超出宽度时只保留最近的轮次
+	many := make([]chartPoint, 100)
+	for i := range many {
+		many[i] = chartPoint{ms: 5}
+	}
+	out = renderChart(1, many, 40, formatMs)
+	if !strings.Contains(out, "last 30 rounds") {
+		t.Fatalf("expected chart to be trimmed to the available width:\n%s", out)
+	}
+}
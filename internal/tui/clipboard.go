@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// copyToClipboard 通过 OSC 52 转义序列写入系统剪贴板，支持 SSH 会话；
+// 在 tmux/screen 中使用对应的透传格式。
+func copyToClipboard(w io.Writer, text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	_, err := seq.WriteTo(w)
+	return err
+}
+
+// hopClipboardLine 返回单个 hop 的摘要（地址、主机名、位置与关键统计），便于粘贴到聊天或工单中。
+func hopClipboardLine(hop mtr.SnapshotHop) string {
+	parts := []string{fmt.Sprintf("TTL %d", hop.TTL), hopAddress(hop)}
+	if hop.Hostname != "" {
+		parts = append(parts, hop.Hostname)
+	}
+	if loc := hop.Location.String(); loc != "" {
+		parts = append(parts, loc)
+	}
+	parts = append(parts, fmt.Sprintf("loss %.1f%%", hop.Stats.Loss))
+	if hop.Stats.Avg != "" {
+		parts = append(parts, "avg "+hop.Stats.Avg)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// tableClipboardText 返回完整表格的纯文本（视图中的全部列，不受终端宽度限制，不含样式）。
+func tableClipboardText(s *mtr.Snapshot, view columnView) string {
+	cols := layoutColumns(view.filter(tableColumns), 0)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Target: %s (%s)  Protocol: %s\n", s.Target, s.TargetIP, s.Protocol)
+	b.WriteString(renderHeader(cols))
+	b.WriteString("\n")
+	for _, hop := range s.Hops {
+		if hop.Hidden {
+			continue
+		}
+		b.WriteString(renderRow(cols, hop))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// yank 复制选中 hop 的摘要；未选中 hop 或 full 为 true 时复制完整表格。
+func (m *model) yank(full bool) {
+	if m.snapshot == nil {
+		return
+	}
+	text := ""
+	notice := ""
+	if hop, ok := m.selectedHop(); ok && !full {
+		text = hopClipboardLine(hop)
+		notice = i18n.Tf("tui.yankHop", map[string]interface{}{"TTL": hop.TTL})
+	} else {
+		text = tableClipboardText(m.snapshot, m.view)
+		notice = i18n.T("tui.yankTable")
+	}
+	if err := copyToClipboard(m.clipboard, text); err != nil {
+		m.notice = i18n.Tf("tui.yankFailed", map[string]interface{}{"Error": err.Error()})
+		return
+	}
+	m.notice = notice
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestYank(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	var out bytes.Buffer
+	m := newModel(context.Background(), nil, nil, nil)
+	m.clipboard = &out
+	m.snapshot = &mtr.Snapshot{Target: "example.com", TargetIP: "192.0.2.9", Hops: []mtr.SnapshotHop{
+		{TTL: 1, IP: "192.0.2.1", Hostname: "gw.example", Stats: mtr.SnapshotHopSta{Avg: "3ms"}},
+		{TTL: 2, IP: "192.0.2.9"},
+	}}
+
+	decode := func() string {
+		t.Helper()
+		s := out.String()
+		out.Reset()
+		if !strings.HasPrefix(s, "\x1b]52;c;") {
+			t.Fatalf("expected OSC 52 sequence, got %q", s)
+		}
+		payload := strings.TrimSuffix(strings.TrimPrefix(s, "\x1b]52;c;"), "\x07")
+		b, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// 未选中 hop 时复制整个表格
+	m.yank(false)
+	if table := decode(); !strings.Contains(table, "Address") || !strings.Contains(table, "gw.example") || !strings.Contains(table, "192.0.2.9") {
+		t.Fatalf("unexpected table copy:\n%s", table)
+	}
+
+	// y 键复制选中的 hop
+	m.selectedTTL = 1
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if line := decode(); line != "TTL 1  192.0.2.1  gw.example  loss 0.0%  avg 3ms" {
+		t.Fatalf("unexpected hop copy: %q", line)
+	}
+}
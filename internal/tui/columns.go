@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// column 描述表格中的一列。
+// priority 越大越先被隐藏（终端宽度不足时）；priority 为 0 的列始终显示。
+// width 为 0 的列为弹性列，占用剩余宽度（不少于 minWidth）。
+type column struct {
+	key      string
+	title    string
+	width    int
+	minWidth int
+	priority int
+	right    bool
+	// group 非空的列可按组切换显示（见 columnView）
+	group string
+	value func(hop mtr.SnapshotHop) string
+}
+
+// 可切换的列组：时延列与抖动列二选一（对应 mtr 的 j 键）。
+const (
+	groupLatency = "latency"
+	groupJitter  = "jitter"
+)
+
+const columnGap = 2
+
+// tableColumns 为 TUI 表格的列注册表，顺序即显示顺序。
+var tableColumns = []column{
+	{key: "ttl", title: "TTL", width: 3, priority: 0, value: func(h mtr.SnapshotHop) string { return fmt.Sprintf("%d", h.TTL) }},
+	{key: "loss", title: "Loss%", width: 5, priority: 1, right: true, value: func(h mtr.SnapshotHop) string { return fmt.Sprintf("%.1f", h.Stats.Loss) }},
+	{key: "sent", title: "Snt", width: 3, priority: 4, value: func(h mtr.SnapshotHop) string { return fmt.Sprintf("%d", h.Stats.Sent) }},
+	{key: "recv", title: "Rcv", width: 3, priority: 5, value: func(h mtr.SnapshotHop) string { return fmt.Sprintf("%d", h.Stats.Received) }},
+	{key: "last", title: "Last", width: 8, priority: 3, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Last) }},
+	{key: "avg", title: "Avg", width: 8, priority: 2, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Avg) }},
+	{key: "segment", title: "Seg", width: 8, priority: 5, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Segment) }},
+	{key: "best", title: "Best", width: 8, priority: 6, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Best) }},
+	{key: "worst", title: "Wrst", width: 8, priority: 6, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Worst) }},
+	{key: "stddev", title: "StDev", width: 8, priority: 9, group: groupLatency, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.StdDev) }},
+	{key: "jitter", title: "Jttr", width: 8, priority: 3, group: groupJitter, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.Jitter) }},
+	{key: "javg", title: "Javg", width: 8, priority: 2, group: groupJitter, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.JitterAvg) }},
+	{key: "jmax", title: "Jmax", width: 8, priority: 6, group: groupJitter, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.Stats.JitterMax) }},
+	{key: "address", title: "Address", width: 16, priority: 0, value: hopAddress},
+	{key: "hostname", title: "Hostname", width: 20, priority: 8, value: func(h mtr.SnapshotHop) string { return emptyAsDash(h.DisplayHostname()) }},
+	{key: "location", title: "Location", minWidth: 20, priority: 7, value: hopLocation},
+}
+
+func hopAddress(h mtr.SnapshotHop) string {
+	addr := h.IP
+	if addr == "" && h.SendFailed {
+		addr = "! send failed"
+	} else if addr == "" {
+		addr = "*"
+	}
+	if h.Loop {
+		addr = "↺" + addr
+	}
+	if h.Foreign {
+		// "≠" 已用于对比模式的分歧标记，这里用 "⇄" 表示响应源地址来自别的网络
+		addr = "⇄" + addr
+	}
+	if h.Stats.Suspicious > 0 {
+		addr = "?" + addr
+	}
+	return addr
+}
+
+func hopLocation(h mtr.SnapshotHop) string {
+	if h.Location == nil {
+		return "-"
+	}
+	return emptyAsDash(h.Location.String())
+}
+
+// columnView 为用户在 TUI 中切换的列显示选项，零值为默认视图（时延列、显示主机名与位置）。
+type columnView struct {
+	jitter     bool // 以抖动列替换时延列
+	noHostname bool
+	noLocation bool
+}
+
+// filter 按视图选项从列注册表中挑出要显示的列（顺序不变）。
+func (v columnView) filter(cols []column) []column {
+	out := make([]column, 0, len(cols))
+	for _, c := range cols {
+		switch {
+		case c.group == groupLatency && v.jitter, c.group == groupJitter && !v.jitter:
+			continue
+		case c.key == "hostname" && v.noHostname, c.key == "location" && v.noLocation:
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// layoutColumns 根据终端宽度挑选可显示的列，并为弹性列分配宽度。
+// width<=0（尚未收到窗口尺寸）时显示全部列。
+func layoutColumns(cols []column, width int) []column {
+	visible := make([]column, len(cols))
+	copy(visible, cols)
+
+	for width > 0 && totalWidth(visible) > width {
+		drop := -1
+		for i, c := range visible {
+			if c.priority > 0 && (drop < 0 || c.priority >= visible[drop].priority) {
+				drop = i
+			}
+		}
+		if drop < 0 {
+			break
+		}
+		visible = append(visible[:drop], visible[drop+1:]...)
+	}
+
+	for i := range visible {
+		if visible[i].width == 0 {
+			w := visible[i].minWidth
+			if width > 0 {
+				if rest := width - totalWidth(visible) + visible[i].minWidth; rest > w {
+					w = rest
+				}
+			}
+			visible[i].width = w
+		}
+	}
+	return visible
+}
+
+func totalWidth(cols []column) int {
+	total := 0
+	for i, c := range cols {
+		w := c.width
+		if w == 0 {
+			w = c.minWidth
+		}
+		total += w
+		if i > 0 {
+			total += columnGap
+		}
+	}
+	return total
+}
+
+func renderHeader(cols []column) string {
+	cells := make([]string, 0, len(cols))
+	for _, c := range cols {
+		cells = append(cells, fitCell(c.title, c.width, c.right))
+	}
+	return strings.TrimRight(strings.Join(cells, strings.Repeat(" ", columnGap)), " ")
+}
+
+func renderRow(cols []column, hop mtr.SnapshotHop) string {
+	cells := make([]string, 0, len(cols))
+	for _, c := range cols {
+		cells = append(cells, fitCell(c.value(hop), c.width, c.right))
+	}
+	return strings.TrimRight(strings.Join(cells, strings.Repeat(" ", columnGap)), " ")
+}
+
+// fitCell 按显示宽度截断并补齐单元格（兼容中文等宽字符）。
+func fitCell(s string, width int, right bool) string {
+	s = runewidth.Truncate(s, width, "…")
+	if right {
+		return runewidth.FillLeft(s, width)
+	}
+	return runewidth.FillRight(s, width)
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestLayoutColumns_HidesLowPriorityFirst(t *testing.T) {
+	all := layoutColumns(tableColumns, 0)
+	if len(all) != len(tableColumns) {
+		t.Fatalf("expected all columns without width, got=%d", len(all))
+	}
+
+	narrow := layoutColumns(tableColumns, 80)
+	if totalWidth(narrow) > 80 {
+		t.Fatalf("layout exceeds width: %d", totalWidth(narrow))
+	}
+	keys := make(map[string]bool)
+	for _, c := range narrow {
+		keys[c.key] = true
+	}
+	if keys["stddev"] || keys["hostname"] {
+		t.Fatalf("expected stddev/hostname hidden first, got=%v", keys)
+	}
+	if !keys["ttl"] || !keys["address"] || !keys["loss"] {
+		t.Fatalf("expected essential columns kept, got=%v", keys)
+	}
+
+	wide := layoutColumns(tableColumns, 200)
+	if len(wide) != len(tableColumns) {
+		t.Fatalf("expected columns restored on wide terminal, got=%d", len(wide))
+	}
+}
+
+func TestColumnViewFilter(t *testing.T) {
+	keys := func(cols []column) map[string]bool {
+		out := make(map[string]bool)
+		for _, c := range cols {
+			out[c.key] = true
+		}
+		return out
+	}
+
+	def := keys(columnView{}.filter(tableColumns))
+	if !def["avg"] || def["javg"] || !def["hostname"] || !def["location"] {
+		t.Fatalf("unexpected default view: %v", def)
+	}
+
+	alt := keys(columnView{jitter: true, noHostname: true, noLocation: true}.filter(tableColumns))
+	if alt["avg"] || alt["last"] || !alt["javg"] || !alt["jmax"] || alt["hostname"] || alt["location"] {
+		t.Fatalf("unexpected toggled view: %v", alt)
+	}
+	if !alt["ttl"] || !alt["loss"] || !alt["address"] {
+		t.Fatalf("expected essential columns kept, got=%v", alt)
+	}
+}
+
+func TestHopAddressForeignMarker(t *testing.T) {
+	got := hopAddress(mtr.SnapshotHop{IP: "10.0.0.1", Foreign: true})
+	if got != "⇄10.0.0.1" {
+		t.Fatalf("unexpected foreign marker: %q", got)
+	}
+}
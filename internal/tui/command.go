@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// applyCommand 解析命令模式输入（不含前导冒号），并将修改应用到 cfg。
+// 支持：target <host>、interval <duration>、timeout <duration>、protocol <icmp|icmp-ts|udp>。
+func applyCommand(cfg *mtr.Config, line string) error {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return errors.New(i18n.Tf("tui.cmd.usage", map[string]interface{}{"Command": line}))
+	}
+	name, arg := strings.ToLower(fields[0]), fields[1]
+
+	switch name {
+	case "target":
+		cfg.Target = arg
+		cfg.TargetIP = ""
+	case "interval", "timeout":
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return errors.New(i18n.Tf("tui.cmd.durationInvalid", map[string]interface{}{"Value": arg}))
+		}
+		if name == "interval" {
+			cfg.Interval = d
+		} else {
+			cfg.Timeout = d
+		}
+		cfg.Profile = ""
+	case "protocol":
+		p := mtr.Protocol(strings.ToLower(arg))
+		if !p.Supported() {
+			return errors.New(i18n.Tf("tui.cmd.protocolUnsupported", map[string]interface{}{"Protocol": arg}))
+		}
+		// 由旧协议默认档位填充的 interval/timeout 改用新协议的默认值
+		if cfg.Profile != "" {
+			old := mtr.DefaultProfile(cfg.Protocol)
+			if cfg.Interval == old.Interval {
+				cfg.Interval = 0
+			}
+			if cfg.Timeout == old.Timeout {
+				cfg.Timeout = 0
+			}
+			cfg.Profile = ""
+		}
+		cfg.Protocol = p
+		cfg.ApplyProtocolProfile()
+	default:
+		return errors.New(i18n.Tf("tui.cmd.usage", map[string]interface{}{"Command": line}))
+	}
+	return nil
+}
+
+// updateCommandInput 处理命令模式下的按键：enter 执行，esc 取消，backspace 删除。
+func (m *model) updateCommandInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.cmdMode = false
+		return m.runCommand(strings.TrimSpace(m.cmdInput))
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.cmdMode = false
+	case tea.KeyBackspace:
+		if r := []rune(m.cmdInput); len(r) > 0 {
+			m.cmdInput = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.cmdInput += " "
+	case tea.KeyRunes:
+		m.cmdInput += string(msg.Runes)
+	}
+	return nil
+}
+
+// runCommand 执行命令：mark 插入时间标记；其余命令基于当前配置生成新配置，停止正在运行的 Controller 并以新配置重启。
+func (m *model) runCommand(line string) tea.Cmd {
+	if line == "" {
+		return nil
+	}
+	// mark <备注> 只插入时间标记，不重启探测
+	if name, note, _ := strings.Cut(line, " "); strings.EqualFold(name, "mark") {
+		m.addMarker(strings.TrimSpace(note))
+		return nil
+	}
+	cfg := m.controller.Config()
+	if err := applyCommand(&cfg, line); err != nil {
+		m.notice = err.Error()
+		return nil
+	}
+	controller, err := m.restart(&cfg)
+	if err != nil {
+		m.notice = i18n.Tf("tui.cmd.restartFailed", map[string]interface{}{"Error": err.Error()})
+		return nil
+	}
+
+	m.stopController()
+	m.controller = controller
+	m.snapshot = controller.Snapshot()
+	m.rounds = nil
+	m.lastRound = 0
+	m.err = nil
+	m.done = false
+	m.selectedTTL = 0
+	m.notice = i18n.Tf("tui.cmd.restarted", map[string]interface{}{"Command": line})
+	return m.startController()
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestApplyCommand(t *testing.T) {
+	cfg := mtr.Config{Target: "a.example", TargetIP: "192.0.2.1", Protocol: mtr.ProtocolICMP}
+	cfg.ApplyProtocolProfile()
+
+	if err := applyCommand(&cfg, "target b.example"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Target != "b.example" || cfg.TargetIP != "" {
+		t.Fatalf("unexpected target: %+v", cfg)
+	}
+
+	// 协议默认档位随协议切换
+	if err := applyCommand(&cfg, "protocol udp"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Protocol != mtr.ProtocolUDP || cfg.Interval != time.Second || cfg.Timeout != 2*time.Second {
+		t.Fatalf("unexpected profile after protocol switch: %+v", cfg)
+	}
+
+	// 显式设置的 interval 不再被协议默认值覆盖
+	if err := applyCommand(&cfg, "interval 250ms"); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyCommand(&cfg, "protocol icmp"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Interval != 250*time.Millisecond {
+		t.Fatalf("explicit interval overwritten: %v", cfg.Interval)
+	}
+
+	for _, bad := range []string{"protocol tcp", "interval soon", "interval -1s", "jump now", "target"} {
+		if err := applyCommand(&cfg, bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+type idleProber struct{}
+
+func (idleProber) Probe(_ context.Context, ttl, seq int) (*mtr.ProbeResult, error) {
+	return &mtr.ProbeResult{TTL: ttl, Seq: seq}, nil
+}
+func (idleProber) SetTarget(net.IP) error { return nil }
+func (idleProber) Close() error           { return nil }
+
+func TestMarkerCommand(t *testing.T) {
+	c, err := mtr.NewController(&mtr.Config{Target: "192.0.2.1", IPVersion: 4, Protocol: mtr.ProtocolICMP}, idleProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(context.Background(), nil, c, nil)
+	m.snapshot = c.Snapshot()
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	if cmd := m.runCommand("mark link flapped"); cmd != nil {
+		t.Fatal("mark should not restart probing")
+	}
+
+	markers := c.Snapshot().Markers
+	if len(markers) != 2 || markers[0].Note != "" || markers[1].Note != "link flapped" {
+		t.Fatalf("unexpected markers: %+v", markers)
+	}
+	if len(m.snapshot.Markers) != 2 {
+		t.Fatalf("markers not reflected in view snapshot: %+v", m.snapshot.Markers)
+	}
+	if out := renderDetail(mtr.SnapshotHop{TTL: 1}, markers); !strings.Contains(out, "link flapped") {
+		t.Fatalf("detail view missing marker note:\n%s", out)
+	}
+}
+
+func TestMarkerBeforeFirstEvent(t *testing.T) {
+	c, err := mtr.NewController(&mtr.Config{Target: "192.0.2.1", IPVersion: 4, Protocol: mtr.ProtocolICMP}, idleProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(context.Background(), nil, c, nil)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	if n := len(c.Snapshot().Markers); n != 1 {
+		t.Fatalf("expected 1 marker, got=%d", n)
+	}
+	if m.notice == "" {
+		t.Fatal("expected marker notice")
+	}
+}
+
+func TestMtrKeys(t *testing.T) {
+	c, err := mtr.NewController(&mtr.Config{Target: "192.0.2.1", IPVersion: 4, Protocol: mtr.ProtocolICMP, Interval: time.Second}, idleProber{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(context.Background(), nil, c, nil)
+	m.snapshot = c.Snapshot()
+
+	press := func(r rune) { m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}) }
+	press('n')
+	press('l')
+	press('j')
+	if !m.view.noHostname || !m.view.noLocation || !m.view.jitter {
+		t.Fatalf("expected all views toggled, got=%+v", m.view)
+	}
+	if out := m.View(); strings.Contains(out, "Hostname") || !strings.Contains(out, "Jttr") {
+		t.Fatalf("unexpected table header:\n%s", out)
+	}
+
+	press('+')
+	if got := c.Config().Interval; got != 2*time.Second {
+		t.Fatalf("expected interval doubled, got=%v", got)
+	}
+	for i := 0; i < 6; i++ {
+		press('-')
+	}
+	if got := c.Config().Interval; got != minLiveInterval {
+		t.Fatalf("expected interval clamped to %v, got=%v", minLiveInterval, got)
+	}
+}
+
+func TestProgressStatusLocalized(t *testing.T) {
+	t.Cleanup(func() { i18n.SetLanguage("") })
+	start := time.Unix(1000, 0)
+	p := mtr.RoundProgress{TTL: 7, InFlight: 2, Started: start}
+
+	i18n.SetLanguage("en")
+	if got := progressStatus(p, start.Add(1500*time.Millisecond)); got != "Probing: TTL 7, 2 in flight, 1.5s" {
+		t.Fatalf("unexpected status: %q", got)
+	}
+	i18n.SetLanguage("zh")
+	if got := progressStatus(p, start.Add(1500*time.Millisecond)); !strings.HasPrefix(got, "探测中") {
+		t.Fatalf("expected localized status, got %q", got)
+	}
+}
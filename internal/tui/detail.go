@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// moveSelection 在当前快照的 hop 之间移动选中行（按 TTL 记录，快照刷新后保持不变）。
+func (m *model) moveSelection(delta int) {
+	if m.snapshot == nil || len(m.snapshot.Hops) == 0 {
+		return
+	}
+	ttls := make([]int, 0, len(m.snapshot.Hops))
+	if m.grouped {
+		ttls = m.selectableTTLs()
+	} else {
+		for _, hop := range m.snapshot.Hops {
+			ttls = append(ttls, hop.TTL)
+		}
+	}
+	if len(ttls) == 0 {
+		return
+	}
+	idx := -1
+	for i, ttl := range ttls {
+		if ttl == m.selectedTTL {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		idx = 0
+	} else {
+		idx += delta
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(ttls) {
+		idx = len(ttls) - 1
+	}
+	m.selectedTTL = ttls[idx]
+}
+
+func (m *model) selectedIndex() int {
+	if m.snapshot == nil || m.selectedTTL == 0 {
+		return -1
+	}
+	for i, hop := range m.snapshot.Hops {
+		if hop.TTL == m.selectedTTL {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) selectedHop() (mtr.SnapshotHop, bool) {
+	idx := m.selectedIndex()
+	if idx < 0 {
+		return mtr.SnapshotHop{}, false
+	}
+	return m.snapshot.Hops[idx], true
+}
+
+// maxDetailMarkers 为详情面板中展示的最近时间标记数量。
+const maxDetailMarkers = 3
+
+// renderDetail 渲染选中 hop 的详情面板，并附带最近的时间标记便于对照该跳的统计。
+func renderDetail(hop mtr.SnapshotHop, markers []mtr.Marker) string {
+	var b strings.Builder
+	addr := hop.IP
+	if addr == "" {
+		addr = "*"
+	}
+	fmt.Fprintf(&b, "%s TTL %d  %s", i18n.T("tui.detail.title"), hop.TTL, addr)
+	if hop.Hostname != "" {
+		fmt.Fprintf(&b, " (%s)", hop.Hostname)
+	}
+	b.WriteString("\n")
+	if loc := hop.Location.String(); loc != "" {
+		fmt.Fprintf(&b, "  Location: %s\n", loc)
+	}
+	fmt.Fprintf(&b, "  Sent: %d  Received: %d  Loss: %.1f%%\n", hop.Stats.Sent, hop.Stats.Received, hop.Stats.Loss)
+	if hop.Foreign {
+		fmt.Fprintf(&b, "  %s\n", i18n.T("tui.detail.foreign"))
+	}
+	if hop.Stats.Suspicious > 0 {
+		fmt.Fprintf(&b, "  %s\n", i18n.Tf("tui.detail.suspicious", map[string]interface{}{"Count": hop.Stats.Suspicious}))
+	}
+	if len(hop.Tags) > 0 {
+		fmt.Fprintf(&b, "  Tags: %s\n", strings.Join(hop.Tags, ", "))
+	}
+
+	if len(hop.Stats.Responses) > 0 {
+		kinds := make([]string, 0, len(hop.Stats.Responses))
+		for k := range hop.Stats.Responses {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		parts := make([]string, 0, len(kinds))
+		for _, k := range kinds {
+			parts = append(parts, fmt.Sprintf("%s=%d", k, hop.Stats.Responses[k]))
+		}
+		fmt.Fprintf(&b, "  Responses: %s\n", strings.Join(parts, "  "))
+	}
+
+	if ts := hop.ICMPTimestamp; ts != nil {
+		fmt.Fprintf(&b, "  ICMP timestamp: originate=%d receive=%d transmit=%d", ts.Originate, ts.Receive, ts.Transmit)
+		if ts.ClockOffsetMs != nil {
+			fmt.Fprintf(&b, " offset≈%dms", *ts.ClockOffsetMs)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(markers) > maxDetailMarkers {
+		markers = markers[len(markers)-maxDetailMarkers:]
+	}
+	for _, mk := range markers {
+		fmt.Fprintf(&b, "  Marker: %s round %d", mk.Time.Format("15:04:05"), mk.Round+1)
+		if mk.Note != "" {
+			fmt.Fprintf(&b, "  %s", mk.Note)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
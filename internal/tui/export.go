@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// castRecorder 将 TUI 输出同时写入终端与 asciicast v2 文件。
+// 实现 term.File 接口，保证 bubbletea 仍能识别终端（raw mode、窗口尺寸等）。
+type castRecorder struct {
+	*os.File
+
+	mu    sync.Mutex
+	out   io.WriteCloser
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+type castHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+func newCastRecorder(tty *os.File, path, title string) (*castRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height, err := term.GetSize(tty.Fd())
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+
+	r := &castRecorder{
+		File:  tty,
+		out:   f,
+		enc:   json.NewEncoder(f),
+		start: time.Now(),
+	}
+	if err := r.enc.Encode(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+	}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *castRecorder) Write(p []byte) (int, error) {
+	n, err := r.File.Write(p)
+	if n > 0 {
+		r.mu.Lock()
+		if r.err == nil {
+			elapsed := time.Since(r.start).Seconds()
+			r.err = r.enc.Encode([]interface{}{elapsed, "o", string(p[:n])})
+		}
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+// Close 仅关闭录制文件，不关闭底层终端。
+func (r *castRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.out.Close(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// writeViewFile 将当前画面（含 ANSI 样式）写入文件。
+func writeViewFile(path, view string) error {
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(view), 0o644)
+}
+
+func defaultViewFileName(now time.Time) string {
+	return fmt.Sprintf("mymtr-%s.ans", now.Format("20060102-150405"))
+}
@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// hopGroup 为分组视图中连续且归属相同（ISP，缺失时为国家）的一组 hop。
+type hopGroup struct {
+	key  string
+	hops []mtr.SnapshotHop
+}
+
+// id 标识分组的展开状态；分组成员随快照变化时以首个 TTL 与归属区分。
+func (g hopGroup) id() string {
+	return fmt.Sprintf("%d/%s", g.hops[0].TTL, g.key)
+}
+
+// exit 返回组内最后一个有应答的 hop（没有时为最后一个 hop），其时延即为离开该网络时的累计时延。
+func (g hopGroup) exit() mtr.SnapshotHop {
+	for i := len(g.hops) - 1; i >= 0; i-- {
+		if g.hops[i].Stats.Received > 0 {
+			return g.hops[i]
+		}
+	}
+	return g.hops[len(g.hops)-1]
+}
+
+func (g hopGroup) contains(ttl int) bool {
+	for _, h := range g.hops {
+		if h.TTL == ttl {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey 返回 hop 的归属：优先使用 ISP，缺失时使用国家；无位置信息时为空。
+func groupKey(h mtr.SnapshotHop) string {
+	if h.Location == nil {
+		return ""
+	}
+	if isp := strings.TrimSpace(h.Location.ISP); isp != "" && isp != "0" {
+		return isp
+	}
+	if c := strings.TrimSpace(h.Location.Country); c != "" && c != "0" {
+		return c
+	}
+	return ""
+}
+
+// groupHops 将连续归属相同的 hop 合并为一组（跳过隐藏的 hop）。
+// 没有位置信息的 hop（如 *）若前后两侧归属相同则并入该组，否则单独成组。
+func groupHops(hops []mtr.SnapshotHop) []hopGroup {
+	visible := make([]mtr.SnapshotHop, 0, len(hops))
+	for _, h := range hops {
+		if !h.Hidden {
+			visible = append(visible, h)
+		}
+	}
+
+	var groups []hopGroup
+	for i := 0; i < len(visible); i++ {
+		h := visible[i]
+		key := groupKey(h)
+		if n := len(groups); n > 0 && groups[n-1].key != "" {
+			last := &groups[n-1]
+			if key == last.key {
+				last.hops = append(last.hops, h)
+				continue
+			}
+			if key == "" {
+				// 向后查找下一个有归属的 hop，归属相同则连同中间的 hop 一并并入
+				j := i
+				for j < len(visible) && groupKey(visible[j]) == "" {
+					j++
+				}
+				if j < len(visible) && groupKey(visible[j]) == last.key {
+					last.hops = append(last.hops, visible[i:j]...)
+					i = j - 1
+					continue
+				}
+			}
+		}
+		groups = append(groups, hopGroup{key: key, hops: []mtr.SnapshotHop{h}})
+	}
+	return groups
+}
+
+// renderGroupRow 渲染折叠后的分组汇总行：丢包、收发数与时延均取离开该网络时的 hop。
+// 组内中间 hop 的丢包常是路由器限速 ICMP 所致，不代表经过该网络的流量丢包，因此不做累加。
+func renderGroupRow(cols []column, g hopGroup, expanded bool) string {
+	exit := g.exit()
+	marker := "▸"
+	if expanded {
+		marker = "▾"
+	}
+
+	cells := make([]string, 0, len(cols))
+	for _, c := range cols {
+		var v string
+		switch c.key {
+		case "ttl":
+			v = fmt.Sprintf("%d-%d", g.hops[0].TTL, g.hops[len(g.hops)-1].TTL)
+		case "address":
+			v = i18n.Tf("tui.groupHops", map[string]interface{}{"Marker": marker, "Count": len(g.hops)})
+		case "hostname":
+			v = "-"
+		case "location":
+			v = g.key
+		default:
+			v = c.value(exit)
+		}
+		cells = append(cells, fitCell(v, c.width, c.right))
+	}
+	return strings.TrimRight(strings.Join(cells, strings.Repeat(" ", columnGap)), " ")
+}
+
+// renderGroupedRows 渲染分组视图的表格行：单个 hop 的分组按普通行显示，其余分组显示汇总行，展开时再列出各 hop。
+func (m *model) renderGroupedRows(b *strings.Builder, cols []column) {
+	for _, g := range groupHops(m.snapshot.Hops) {
+		if len(g.hops) == 1 {
+			m.writeRow(b, renderRow(cols, g.hops[0]), g.hops[0].TTL == m.selectedTTL)
+			continue
+		}
+		expanded := m.expanded[g.id()]
+		m.writeRow(b, renderGroupRow(cols, g, expanded), !expanded && g.contains(m.selectedTTL))
+		if !expanded {
+			continue
+		}
+		for _, h := range g.hops {
+			m.writeRow(b, renderRow(cols, h), h.TTL == m.selectedTTL)
+		}
+	}
+}
+
+func (m *model) writeRow(b *strings.Builder, line string, selected bool) {
+	if selected {
+		line = m.styles.selected.Render(line)
+	}
+	b.WriteString(line)
+	b.WriteString("\n")
+}
+
+// selectableTTLs 返回分组视图中可选中的 TTL：折叠的分组只保留首个 hop。
+func (m *model) selectableTTLs() []int {
+	var ttls []int
+	for _, g := range groupHops(m.snapshot.Hops) {
+		if len(g.hops) > 1 && !m.expanded[g.id()] {
+			ttls = append(ttls, g.hops[0].TTL)
+			continue
+		}
+		for _, h := range g.hops {
+			ttls = append(ttls, h.TTL)
+		}
+	}
+	return ttls
+}
+
+// setGroupExpanded 展开或折叠选中 hop 所在的分组，折叠后选中行落在分组的汇总行上。
+func (m *model) setGroupExpanded(expanded bool) {
+	if !m.grouped || m.snapshot == nil {
+		return
+	}
+	for _, g := range groupHops(m.snapshot.Hops) {
+		if len(g.hops) > 1 && g.contains(m.selectedTTL) {
+			if m.expanded == nil {
+				m.expanded = make(map[string]bool)
+			}
+			m.expanded[g.id()] = expanded
+			if !expanded {
+				m.selectedTTL = g.hops[0].TTL
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/geoip"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func groupedHops() []mtr.SnapshotHop {
+	isp := func(name string) *geoip.GeoLocation { return &geoip.GeoLocation{Country: "中国", ISP: name} }
+	return []mtr.SnapshotHop{
+		{TTL: 1, IP: "192.168.1.1"},
+		{TTL: 2, IP: "10.0.0.1", Location: isp("电信"), Stats: mtr.SnapshotHopSta{Sent: 10, Received: 10, Avg: "3ms"}},
+		{TTL: 3, Stats: mtr.SnapshotHopSta{Sent: 10}},
+		{TTL: 4, IP: "10.0.0.4", Location: isp("电信"), Stats: mtr.SnapshotHopSta{Sent: 10, Received: 8, Loss: 20, Avg: "9ms"}},
+		{TTL: 5, IP: "10.0.0.5", Location: &geoip.GeoLocation{Country: "美国"}, Stats: mtr.SnapshotHopSta{Sent: 10, Received: 10, Avg: "150ms"}},
+	}
+}
+
+func TestGroupHops(t *testing.T) {
+	groups := groupHops(groupedHops())
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got=%+v", groups)
+	}
+	if g := groups[1]; g.key != "电信" || len(g.hops) != 3 || g.exit().TTL != 4 {
+		t.Fatalf("expected unlocated hop absorbed into ISP group, got=%+v", g)
+	}
+	if groups[0].key != "" || groups[2].key != "美国" {
+		t.Fatalf("unexpected group keys: %q %q", groups[0].key, groups[2].key)
+	}
+
+	row := renderGroupRow(layoutColumns(tableColumns, 0), groups[1], false)
+	for _, want := range []string{"2-4", "20.0", "▸ 3 hops", "9ms", "电信"} {
+		if !strings.Contains(row, want) {
+			t.Fatalf("group row missing %q: %q", want, row)
+		}
+	}
+	// 中间 hop（TTL 3）无应答不应计入分组丢包
+	if strings.Contains(row, "40.0") {
+		t.Fatalf("group loss should come from the exit hop: %q", row)
+	}
+}
+
+func TestGroupedViewNavigation(t *testing.T) {
+	m := newModel(context.Background(), nil, nil, nil)
+	m.snapshot = &mtr.Snapshot{Hops: groupedHops()}
+	m.grouped = true
+	rows := func() string {
+		var b strings.Builder
+		m.renderGroupedRows(&b, layoutColumns(tableColumns, 0))
+		return b.String()
+	}
+
+	m.moveSelection(0)
+	m.moveSelection(1)
+	m.moveSelection(1)
+	if m.selectedTTL != 5 {
+		t.Fatalf("expected collapsed group to be skipped, got ttl=%d", m.selectedTTL)
+	}
+
+	m.moveSelection(-1)
+	m.setGroupExpanded(true)
+	m.moveSelection(1)
+	if m.selectedTTL != 3 {
+		t.Fatalf("expected to move into expanded group, got ttl=%d", m.selectedTTL)
+	}
+	if out := rows(); !strings.Contains(out, "▾ 3 hops") || !strings.Contains(out, "10.0.0.4") {
+		t.Fatalf("expanded group not rendered:\n%s", out)
+	}
+
+	m.setGroupExpanded(false)
+	if m.selectedTTL != 2 || strings.Contains(rows(), "10.0.0.4") {
+		t.Fatalf("expected group collapsed with selection on its summary row, got ttl=%d", m.selectedTTL)
+	}
+}
@@ -3,7 +3,10 @@ package tui
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -12,11 +15,30 @@ import (
 	"github.com/hyqhyq3/mymtr/internal/mtr"
 )
 
-type eventMsg struct {
-	ev mtr.Event
+// eventsMsg/doneMsg 带有 Controller 的代次，重启后旧 Controller 的消息会被丢弃。
+// eventsMsg 合并了一批已到达的事件，处理完后只渲染一次。
+type eventsMsg struct {
+	evs []mtr.Event
+	gen int
+	// closed 表示事件通道已在本批之后关闭
+	closed bool
 }
 
-type doneMsg struct{}
+type doneMsg struct {
+	gen int
+}
+
+// progressTickMsg 定时触发重绘，使探测某跳较慢时状态栏的进度仍在走动。
+type progressTickMsg struct{}
+
+const progressTickInterval = 500 * time.Millisecond
+
+func progressTick() tea.Cmd {
+	return tea.Tick(progressTickInterval, func(time.Time) tea.Msg { return progressTickMsg{} })
+}
+
+// RestartFunc 以新配置创建（尚未运行的）Controller，用于命令模式重启探测。
+type RestartFunc func(cfg *mtr.Config) (*mtr.Controller, error)
 
 type model struct {
 	ctx    context.Context
@@ -24,82 +46,228 @@ type model struct {
 
 	controller *mtr.Controller
 	snapshot   *mtr.Snapshot
+	runCancel  context.CancelFunc
+	gen        int
+	restart    RestartFunc
 
 	width  int
 	height int
+	// resizeSeq 随每次窗口尺寸变化递增，用于合并连续的尺寸变化（见 resize）
+	resizeSeq int
 
 	lastRound int
 	err       error
 	done      bool
 	paused    bool
+	notice    string
+	// view 为 n/l/j 键切换的列显示选项
+	view columnView
+	// grouped 为 true 时按 ISP/国家分组显示，expanded 记录已展开的分组（见 hopGroup.id）
+	grouped  bool
+	expanded map[string]bool
+	// noTitle 为 true 时不更新终端标题；title 为最近一次设置的标题
+	noTitle bool
+	title   string
+
+	selectedTTL int
+	showDetail  bool
+	// showChart 为 true 时在表格下方绘制选中 hop 的延迟图（v 键切换），rounds 为绘图用的逐轮结果（暂停时不更新）
+	showChart bool
+	rounds    []mtr.RoundSnapshot
+
+	cmdMode  bool
+	cmdInput string
+
+	// clipboard 为 OSC 52 剪贴板序列的输出目标（终端）
+	clipboard io.Writer
 
 	styles styles
 }
 
 type styles struct {
-	title  lipgloss.Style
-	header lipgloss.Style
-	muted  lipgloss.Style
+	title    lipgloss.Style
+	header   lipgloss.Style
+	muted    lipgloss.Style
+	selected lipgloss.Style
 }
 
-func newModel(ctx context.Context, cancel context.CancelFunc, controller *mtr.Controller) *model {
+func newModel(ctx context.Context, cancel context.CancelFunc, controller *mtr.Controller, restart RestartFunc) *model {
 	return &model{
 		ctx:        ctx,
 		cancel:     cancel,
 		controller: controller,
+		restart:    restart,
+		clipboard:  os.Stdout,
 		styles: styles{
-			title:  lipgloss.NewStyle().Bold(true),
-			header: lipgloss.NewStyle().Bold(true),
-			muted:  lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+			title:    lipgloss.NewStyle().Bold(true),
+			header:   lipgloss.NewStyle().Bold(true),
+			muted:    lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
+			selected: lipgloss.NewStyle().Reverse(true),
 		},
 	}
 }
 
 func (m *model) Init() tea.Cmd {
-	return waitForEvent(m.controller.Events())
+	return tea.Batch(m.startController(), progressTick())
+}
+
+// startController 在独立的子 context 中运行当前 Controller，便于重启时单独停止。
+func (m *model) startController() tea.Cmd {
+	runCtx, runCancel := context.WithCancel(m.ctx)
+	m.runCancel = runCancel
+	m.gen++
+	go m.controller.Run(runCtx)
+	return waitForEvents(m.controller.Events(), m.gen)
+}
+
+func (m *model) stopController() {
+	if m.runCancel != nil {
+		m.runCancel()
+	}
 }
 
 func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		return m, nil
+		return m, m.resize(msg)
+	case resizeSettledMsg:
+		return m, m.settled(msg)
+	case tea.ResumeMsg:
+		return m, m.resume()
 	case tea.KeyMsg:
+		if m.cmdMode {
+			return m, m.updateCommandInput(msg)
+		}
 		switch msg.String() {
+		case ":":
+			if m.restart != nil {
+				m.cmdMode = true
+				m.cmdInput = ""
+			}
+			return m, nil
 		case "p":
 			m.paused = !m.paused
 			return m, nil
+		case "s":
+			m.saveView()
+			return m, nil
+		case "m":
+			m.addMarker("")
+			return m, nil
+		case "y":
+			m.yank(false)
+			return m, nil
+		case "Y":
+			m.yank(true)
+			return m, nil
+		case " ":
+			m.controller.Trigger()
+			return m, nil
+		case "n":
+			m.view.noHostname = !m.view.noHostname
+			return m, nil
+		case "l":
+			m.view.noLocation = !m.view.noLocation
+			return m, nil
+		case "j":
+			m.view.jitter = !m.view.jitter
+			return m, nil
+		case "g":
+			m.grouped = !m.grouped
+			return m, nil
+		case "right":
+			m.setGroupExpanded(true)
+			return m, nil
+		case "left":
+			m.setGroupExpanded(false)
+			return m, nil
+		case "+", "-":
+			m.adjustInterval(msg.String() == "+")
+			return m, nil
+		case "up":
+			m.moveSelection(-1)
+			return m, nil
+		case "down":
+			m.moveSelection(1)
+			return m, nil
+		case "enter", "d":
+			m.showDetail = !m.showDetail
+			if m.showDetail && m.selectedTTL == 0 {
+				m.moveSelection(0)
+			}
+			return m, nil
+		case "v":
+			m.showChart = !m.showChart
+			if m.showChart && m.selectedTTL == 0 {
+				m.moveSelection(0)
+			}
+			return m, nil
+		case "ctrl+z":
+			return m, requestSuspend
 		case "q", "esc", "ctrl+c":
 			if m.cancel != nil {
 				m.cancel()
 			}
 			return m, tea.Quit
 		}
-	case eventMsg:
-		switch msg.ev.Type {
-		case mtr.EventTypeHopUpdated, mtr.EventTypeRoundCompleted:
-			if !m.paused {
-				m.snapshot = m.controller.Snapshot()
-				m.lastRound = msg.ev.Round
-			}
-		case mtr.EventTypeError:
-			m.err = msg.ev.Err
-			if !m.paused {
-				m.snapshot = m.controller.Snapshot()
-			}
-		case mtr.EventTypeDone:
+	case eventsMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
+		for _, ev := range msg.evs {
+			m.applyEvent(ev)
+		}
+		if msg.closed {
 			m.done = true
-			m.snapshot = m.controller.Snapshot()
+			return m, m.updateTitle(nil)
 		}
-		return m, waitForEvent(m.controller.Events())
+		return m, m.updateTitle(waitForEvents(m.controller.Events(), m.gen))
 	case doneMsg:
+		if msg.gen != m.gen {
+			return m, nil
+		}
 		m.done = true
 		return m, nil
+	case progressTickMsg:
+		return m, progressTick()
 	}
 	return m, nil
 }
 
+// applyEvent 将单个事件应用到视图模型：hop 更新只增量替换对应行，
+// 每轮结束（及出错、完成）时再从 Controller 同步一次完整快照。
+func (m *model) applyEvent(ev mtr.Event) {
+	switch ev.Type {
+	case mtr.EventTypeHopUpdated:
+		if m.paused {
+			return
+		}
+		if m.snapshot == nil || ev.Hop == nil {
+			m.snapshot = m.controller.Snapshot()
+		} else {
+			m.snapshot.ApplyHop(*ev.Hop)
+		}
+		m.lastRound = ev.Round
+	case mtr.EventTypeRoundCompleted:
+		if !m.paused {
+			m.snapshot = m.controller.Snapshot()
+			m.rounds = m.controller.RoundSnapshots()
+			m.lastRound = ev.Round
+		}
+	case mtr.EventTypeWarning, mtr.EventTypeReachability:
+		m.notice = ev.Message
+	case mtr.EventTypeError:
+		m.err = ev.Err
+		if !m.paused {
+			m.snapshot = m.controller.Snapshot()
+		}
+	case mtr.EventTypeDone:
+		m.done = true
+		m.snapshot = m.controller.Snapshot()
+		m.rounds = m.controller.RoundSnapshots()
+	}
+}
+
 func (m *model) View() string {
 	if m.snapshot == nil {
 		return m.styles.muted.Render(i18n.T("tui.starting") + "\n")
@@ -108,7 +276,21 @@ func (m *model) View() string {
 	status := []string{
 		fmt.Sprintf("Target: %s (%s)", m.snapshot.Target, m.snapshot.TargetIP),
 		fmt.Sprintf("Protocol: %s", m.snapshot.Protocol),
+	}
+	if m.snapshot.ProbeMode != "" {
+		// 权限不足时降级为无特权探测，需要让用户意识到结果可能与所选协议不同
+		status = append(status, i18n.Tf("tui.probeMode", map[string]interface{}{"Mode": m.snapshot.ProbeMode}))
+	}
+	status = append(status,
 		fmt.Sprintf("Round: %d", m.lastRound+1),
+		i18n.Tf("tui.path", map[string]interface{}{
+			"Percent": fmt.Sprintf("%.0f", m.snapshot.Completeness.Percent), "Responded": m.snapshot.Completeness.Responded, "Total": m.snapshot.Completeness.Total,
+		}),
+	)
+	if !m.done && !m.paused {
+		if s := progressStatus(m.controller.Progress(), time.Now()); s != "" {
+			status = append(status, m.styles.muted.Render(s))
+		}
 	}
 	if m.snapshot.Count == 0 {
 		status = append(status, "Count: ∞")
@@ -121,9 +303,43 @@ func (m *model) View() string {
 	if m.done {
 		status = append(status, i18n.T("tui.done"))
 	}
+	if len(m.snapshot.Loops) > 0 {
+		status = append(status, i18n.T("tui.loop"))
+	}
+	if n := len(m.snapshot.Violations); n > 0 {
+		status = append(status, i18n.Tf("tui.policyViolated", map[string]interface{}{"Count": n}))
+	}
+	if m.snapshot.DNSCheck.Mismatch() {
+		status = append(status, i18n.T("tui.dnsMismatch"))
+	}
+	if t := m.snapshot.TCPRTT; t != nil && t.Received > 0 {
+		label := i18n.Tf("tui.tcpRTT", map[string]interface{}{"Avg": emptyAsDash(m.snapshot.FormatMs(t.AvgMs))})
+		if t.Deprioritized {
+			label += " " + i18n.T("tui.tcpRTTDeprioritized")
+		}
+		status = append(status, label)
+	}
+	if a := m.snapshot.Anycast; a != nil {
+		status = append(status, i18n.Tf("tui.anycast", map[string]interface{}{"Sites": len(a.Sites)}))
+	}
+	if d := m.snapshot.Destination; d.State == mtr.DestinationStateUnreachable {
+		status = append(status, i18n.Tf("tui.unreachable", map[string]interface{}{"TTL": d.TTL}))
+	}
+	if n := len(m.snapshot.Markers); n > 0 {
+		status = append(status, i18n.Tf("tui.markers", map[string]interface{}{"Count": n}))
+	}
+	if n := m.snapshot.ProbeErrors.Total(); n > 0 {
+		status = append(status, i18n.Tf("tui.probeErrors", map[string]interface{}{"Count": n}))
+	}
+	if pe := m.snapshot.ProbeErrors; pe.LastSendError != "" {
+		status = append(status, i18n.Tf("tui.sendFailed", map[string]interface{}{"Error": pe.LastSendError}))
+	}
 	if m.err != nil && !m.done {
 		status = append(status, fmt.Sprintf("Error: %v", m.err))
 	}
+	if m.notice != "" {
+		status = append(status, m.notice)
+	}
 
 	var b strings.Builder
 	b.WriteString(m.styles.title.Render("MyMTR"))
@@ -131,84 +347,129 @@ func (m *model) View() string {
 	b.WriteString(strings.Join(status, "  "))
 	b.WriteString("\n\n")
 
-	b.WriteString(m.styles.header.Render("TTL  Loss%  Snt  Rcv  Last      Avg       Best      Wrst      StDev     Address            Hostname                Location"))
+	cols := layoutColumns(m.view.filter(tableColumns), m.width)
+	b.WriteString(m.styles.header.Render(renderHeader(cols)))
 	b.WriteString("\n")
 
-	for _, hop := range m.snapshot.Hops {
-		addr := hop.IP
-		if addr == "" {
-			addr = "*"
-		}
-		host := hop.Hostname
-		if host == "" {
-			host = "-"
-		}
-		loc := "-"
-		if hop.Location != nil {
-			loc = hop.Location.String()
-			if loc == "" {
-				loc = "-"
+	if m.grouped {
+		m.renderGroupedRows(&b, cols)
+	} else {
+		for _, hop := range m.snapshot.Hops {
+			if hop.Hidden {
+				continue
 			}
+			m.writeRow(&b, renderRow(cols, hop), hop.TTL == m.selectedTTL)
 		}
+	}
 
-		line := fmt.Sprintf(
-			"%-3d  %5.1f  %-3d  %-3d  %-8s  %-8s  %-8s  %-8s  %-8s  %-16s  %-20s  %s",
-			hop.TTL,
-			hop.Stats.Loss,
-			hop.Stats.Sent,
-			hop.Stats.Received,
-			emptyAsDash(hop.Stats.Last),
-			emptyAsDash(hop.Stats.Avg),
-			emptyAsDash(hop.Stats.Best),
-			emptyAsDash(hop.Stats.Worst),
-			emptyAsDash(hop.Stats.StdDev),
-			trunc(addr, 16),
-			trunc(host, 20),
-			trunc(loc, max(20, m.width-3-6-4-4-8-8-8-8-8-16-20-8)),
-		)
-		b.WriteString(line)
+	if hop, ok := m.selectedHop(); ok && m.showDetail {
+		b.WriteString("\n")
+		b.WriteString(renderDetail(hop, m.snapshot.Markers))
+	}
+	if m.showChart && m.selectedTTL > 0 {
 		b.WriteString("\n")
+		b.WriteString(renderChart(m.selectedTTL, hopSeries(m.rounds, m.selectedTTL), m.width, m.snapshot.FormatMs))
 	}
 
 	b.WriteString("\n")
-	b.WriteString(m.styles.muted.Render(i18n.T("tui.help")))
+	if m.cmdMode {
+		b.WriteString(":" + m.cmdInput + "█")
+	} else {
+		b.WriteString(m.styles.muted.Render(i18n.T("tui.help")))
+	}
 	b.WriteString("\n")
 	return b.String()
 }
 
-func waitForEvent(ch <-chan mtr.Event) tea.Cmd {
-	return func() tea.Msg {
-		ev, ok := <-ch
-		if !ok {
-			return doneMsg{}
-		}
-		return eventMsg{ev: ev}
+// addMarker 在 Controller 中插入时间标记，并立即反映到当前画面（暂停时同样生效）。
+func (m *model) addMarker(note string) {
+	mk := m.controller.AddMarker(note)
+	var n int
+	if m.snapshot != nil {
+		m.snapshot.Markers = append(m.snapshot.Markers, mk)
+		n = len(m.snapshot.Markers)
+	} else {
+		// 首个事件到达前尚无视图快照，标记数以 Controller 为准
+		n = len(m.controller.Snapshot().Markers)
 	}
+	m.notice = i18n.Tf("tui.markerAdded", map[string]interface{}{
+		"N":     n,
+		"Time":  mk.Time.Format("15:04:05"),
+		"Round": mk.Round + 1,
+	})
 }
 
-func emptyAsDash(s string) string {
-	if s == "" {
-		return "-"
+// minLiveInterval 为 +/- 键可调到的最小轮间隔。
+const minLiveInterval = 100 * time.Millisecond
+
+// adjustInterval 将轮间隔加倍（up）或减半，不低于 minLiveInterval；无需重启探测。
+func (m *model) adjustInterval(up bool) {
+	d := m.controller.Config().Interval
+	if up {
+		d *= 2
+	} else {
+		d /= 2
 	}
-	return s
+	if d < minLiveInterval {
+		d = minLiveInterval
+	}
+	m.controller.SetInterval(d)
+	if m.snapshot != nil {
+		m.snapshot.IntervalMs = d.Milliseconds()
+	}
+	m.notice = i18n.Tf("tui.intervalChanged", map[string]interface{}{"Interval": d.String()})
 }
 
-func trunc(s string, n int) string {
-	if n <= 0 {
-		return ""
+// saveView 将当前画面导出为 ANSI 文本文件（当前目录，按时间命名）。
+func (m *model) saveView() {
+	path := defaultViewFileName(time.Now())
+	m.notice = ""
+	if err := writeViewFile(path, m.View()); err != nil {
+		m.notice = i18n.Tf("tui.viewSaveFailed", map[string]interface{}{"Error": err.Error()})
+		return
 	}
-	if len(s) <= n {
-		return s
+	m.notice = i18n.Tf("tui.viewSaved", map[string]interface{}{"Path": path})
+}
+
+// progressStatus 描述本轮正在探测的 TTL、在途探测数与本轮已用时间；不在探测中时返回空串。
+func progressStatus(p mtr.RoundProgress, now time.Time) string {
+	if p.TTL <= 0 || p.Started.IsZero() {
+		return ""
 	}
-	if n <= 1 {
-		return s[:n]
+	return i18n.Tf("tui.probing", map[string]interface{}{
+		"TTL": p.TTL, "InFlight": p.InFlight, "Elapsed": fmt.Sprintf("%.1f", now.Sub(p.Started).Seconds()),
+	})
+}
+
+// maxEventBatch 限制单批合并的事件数，避免高频探测时画面长时间不刷新。
+const maxEventBatch = 64
+
+// waitForEvents 阻塞等待下一个事件，并顺带取走通道中已排队的事件，合并为一批。
+func waitForEvents(ch <-chan mtr.Event, gen int) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-ch
+		if !ok {
+			return doneMsg{gen: gen}
+		}
+		evs := []mtr.Event{ev}
+		for len(evs) < maxEventBatch {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return eventsMsg{evs: evs, gen: gen, closed: true}
+				}
+				evs = append(evs, ev)
+			default:
+				return eventsMsg{evs: evs, gen: gen}
+			}
+		}
+		return eventsMsg{evs: evs, gen: gen}
 	}
-	return s[:n-1] + "…"
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+func emptyAsDash(s string) string {
+	if s == "" {
+		return "-"
 	}
-	return b
+	return s
 }
@@ -2,14 +2,54 @@ package tui
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/hyqhyq3/mymtr/internal/mtr"
 )
 
-func Run(ctx context.Context, cancel context.CancelFunc, controller *mtr.Controller) error {
-	p := tea.NewProgram(newModel(ctx, cancel, controller), tea.WithAltScreen())
+type Options struct {
+	// CastFile 非空时将整个会话录制为 asciicast v2 文件。
+	CastFile string
+	// DumpFile 非空时在退出时将最后一帧画面写入该文件（ANSI 文本）。
+	DumpFile string
+	// Restart 非空时启用命令模式（`:` 键），用于以新参数重启探测。
+	Restart RestartFunc
+	// NoTitle 为 true 时不把目标的丢包与时延写入终端标题。
+	NoTitle bool
+}
+
+// Run 运行 TUI 并在其中启动 controller；返回退出时正在使用的 Controller（命令模式可能已将其替换）。
+func Run(ctx context.Context, cancel context.CancelFunc, controller *mtr.Controller, opts Options) (*mtr.Controller, error) {
+	m := newModel(ctx, cancel, controller, opts.Restart)
+	m.noTitle = opts.NoTitle
+	progOpts := []tea.ProgramOption{tea.WithAltScreen()}
+
+	if opts.CastFile != "" {
+		rec, err := newCastRecorder(os.Stdout, opts.CastFile, "mymtr "+controller.Target())
+		if err != nil {
+			return controller, err
+		}
+		defer rec.Close()
+		progOpts = append(progOpts, tea.WithOutput(rec))
+	}
+
+	if !opts.NoTitle {
+		fmt.Fprint(os.Stdout, pushTitleSeq)
+		defer fmt.Fprint(os.Stdout, restoreTitleSeq)
+	}
+
+	p := tea.NewProgram(m, progOpts...)
+	stop := forwardSuspend(p)
 	_, err := p.Run()
-	return err
+	stop()
+	if err != nil {
+		return m.controller, err
+	}
+	if opts.DumpFile != "" {
+		return m.controller, writeViewFile(opts.DumpFile, m.View())
+	}
+	return m.controller, nil
 }
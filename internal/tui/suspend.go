@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// resizeSettle 为窗口尺寸停止变化后等待的时间；连续拖动窗口时只在最后一次变化后整屏重绘一次，
+// 避免缩小窗口时残留旧行，又不至于每个 WindowSizeMsg 都清屏闪烁。
+const resizeSettle = 150 * time.Millisecond
+
+// resizeSettledMsg 在最后一次尺寸变化 resizeSettle 之后到达；seq 用于丢弃已过时的定时。
+type resizeSettledMsg struct{ seq int }
+
+// resize 记录新的窗口尺寸，并安排尺寸稳定后的整屏重绘。
+func (m *model) resize(msg tea.WindowSizeMsg) tea.Cmd {
+	m.width, m.height = msg.Width, msg.Height
+	m.resizeSeq++
+	seq := m.resizeSeq
+	return tea.Tick(resizeSettle, func(time.Time) tea.Msg { return resizeSettledMsg{seq: seq} })
+}
+
+// settled 在尺寸稳定后清屏重绘；期间又有新的尺寸变化时忽略。
+func (m *model) settled(msg resizeSettledMsg) tea.Cmd {
+	if msg.seq != m.resizeSeq {
+		return nil
+	}
+	return tea.ClearScreen
+}
+
+// resume 在挂起并恢复（fg）后调用：终端已恢复原始模式与备用屏幕（见 forwardSuspend），
+// 这里清屏重绘，并重新设置终端标题（挂起期间 shell 可能已改写标题）。
+func (m *model) resume() tea.Cmd {
+	m.title = ""
+	return m.updateTitle(tea.ClearScreen)
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package tui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// forwardSuspend 在不支持 SIGTSTP 的平台上无事可做。
+func forwardSuspend(*tea.Program) func() { return func() {} }
+
+// requestSuspend 响应 ctrl+z；bubbletea 在这些平台上不支持挂起，SuspendMsg 会被忽略。
+func requestSuspend() tea.Msg { return tea.SuspendMsg{} }
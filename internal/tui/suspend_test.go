@@ -0,0 +1,22 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestResizeSettlesOnLatest(t *testing.T) {
+	m := &model{}
+	m.resize(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m.resize(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if m.width != 100 || m.height != 30 {
+		t.Fatalf("unexpected size: %dx%d", m.width, m.height)
+	}
+	if cmd := m.settled(resizeSettledMsg{seq: 1}); cmd != nil {
+		t.Fatalf("stale resize should not repaint")
+	}
+	if cmd := m.settled(resizeSettledMsg{seq: 2}); cmd == nil {
+		t.Fatalf("latest resize should repaint")
+	}
+}
@@ -0,0 +1,59 @@
+//go:build unix
+
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// forwardSuspend 接管 SIGTSTP（ctrl+z 经 requestSuspend 转为该信号，以及 kill -TSTP 等外部作业控制）：
+// 先释放终端（退出备用屏幕、恢复 cooked 模式），再以 SIGSTOP 停止进程组；收到 SIGCONT 后恢复终端、
+// 重新进入备用屏幕并通知模型重绘。否则进程会带着原始模式直接停止，终端需要 reset 才能恢复。
+// 不使用 bubbletea 自带的挂起：它向进程组重发 SIGTSTP，而 Go 运行时捕获过该信号后无法再恢复其默认的停止行为。
+// 返回的函数用于停止接管。
+func forwardSuspend(p *tea.Program) func() {
+	tstp := make(chan os.Signal, 1)
+	cont := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(tstp, syscall.SIGTSTP)
+	signal.Notify(cont, syscall.SIGCONT)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-tstp:
+			}
+			if err := p.ReleaseTerminal(); err != nil {
+				continue
+			}
+			select {
+			case <-cont: // 丢弃此前与挂起无关的 SIGCONT
+			default:
+			}
+			_ = syscall.Kill(0, syscall.SIGSTOP)
+			select {
+			case <-done:
+				return
+			case <-cont:
+			}
+			_ = p.RestoreTerminal()
+			p.Send(tea.ResumeMsg{})
+		}
+	}()
+	return func() {
+		close(done)
+		signal.Stop(tstp)
+		signal.Stop(cont)
+	}
+}
+
+// requestSuspend 响应 ctrl+z：原始模式下终端不会产生 SIGTSTP，这里向本进程发送该信号，统一由 forwardSuspend 处理。
+func requestSuspend() tea.Msg {
+	_ = syscall.Kill(os.Getpid(), syscall.SIGTSTP)
+	return nil
+}
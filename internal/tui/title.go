@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/hyqhyq3/mymtr/internal/i18n"
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+// windowTitle 生成终端标题，便于在后台标签页中一眼看到目标的丢包与时延。
+// 统计取自目标所在的 hop；尚未到达目标时只显示正在探测（途中的 hop 常因限速 ICMP 而全丢，不代表目标状况）。
+func windowTitle(s *mtr.Snapshot) string {
+	if s == nil {
+		return "mymtr"
+	}
+	title := "mymtr " + s.Target
+	if s.Destination.State != mtr.DestinationStateReached {
+		if len(s.Hops) == 0 {
+			return title
+		}
+		return title + " — " + i18n.T("tui.title.probing")
+	}
+	for _, h := range s.Hops {
+		if h.TTL == s.Destination.TTL {
+			return title + " — " + i18n.Tf("tui.title.stats", map[string]interface{}{
+				"Loss": fmt.Sprintf("%.0f", h.Stats.Loss), "Avg": emptyAsDash(h.Stats.Avg),
+			})
+		}
+	}
+	return title
+}
+
+// 进入 TUI 时把原标题压入终端的标题栈，退出时先清空再弹出，恢复原标题（不支持标题栈的终端至少不会残留探测结果）。
+const (
+	pushTitleSeq    = "\x1b[22;0t"
+	restoreTitleSeq = "\x1b]2;\x07\x1b[23;0t"
+)
+
+// updateTitle 在标题内容变化时追加设置终端标题的命令；关闭标题更新时原样返回 cmd。
+func (m *model) updateTitle(cmd tea.Cmd) tea.Cmd {
+	if m.noTitle {
+		return cmd
+	}
+	t := windowTitle(m.snapshot)
+	if t == m.title {
+		return cmd
+	}
+	m.title = t
+	return tea.Batch(cmd, tea.SetWindowTitle(t))
+}
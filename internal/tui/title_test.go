@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/hyqhyq3/mymtr/internal/mtr"
+)
+
+func TestWindowTitle(t *testing.T) {
+	s := &mtr.Snapshot{Target: "example.com"}
+	if got := windowTitle(s); got != "mymtr example.com" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+
+	s.Hops = []mtr.SnapshotHop{
+		{TTL: 1, Stats: mtr.SnapshotHopSta{Sent: 4, Received: 4, AvgMs: 1}},
+		{TTL: 2, Stats: mtr.SnapshotHopSta{Sent: 4, Received: 3, Loss: 25, AvgMs: 12}},
+		{TTL: 3, Stats: mtr.SnapshotHopSta{Sent: 4, Loss: 100}},
+	}
+	if got := windowTitle(s); got != "mymtr example.com — probing…" {
+		t.Fatalf("unexpected title before reaching destination: %q", got)
+	}
+
+	s.Hops[1].Stats.Avg = "12.3ms"
+	s.Destination = mtr.DestinationStatus{State: mtr.DestinationStateReached, TTL: 2}
+	if got := windowTitle(s); got != "mymtr example.com — loss 25% avg 12.3ms" {
+		t.Fatalf("unexpected title: %q", got)
+	}
+}